@@ -0,0 +1,145 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ride-hail/internal/admin-service/adapters/service/database"
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/service"
+	"ride-hail/internal/filestore"
+	"ride-hail/internal/logger"
+)
+
+type DocumentsHandler struct {
+	documentsService *service.DocumentsService
+	mylog            logger.Logger
+}
+
+func NewDocumentsHandler(mylog logger.Logger, documentsService *service.DocumentsService) *DocumentsHandler {
+	return &DocumentsHandler{
+		documentsService: documentsService,
+		mylog:            mylog,
+	}
+}
+
+// ListDocuments is the review queue, ?status=PENDING by default
+func (dh *DocumentsHandler) ListDocuments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		status := dto.DocumentStatusPending
+		if r.URL.Query().Has("status") {
+			status = r.URL.Query().Get("status")
+		}
+
+		documents, err := dh.documentsService.ListDocuments(ctx, status, r.URL.Query().Get("driver_id"))
+		if err != nil {
+			dh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, documents)
+	}
+}
+
+func (dh *DocumentsHandler) ListDriverDocuments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		documents, err := dh.documentsService.ListDocuments(ctx, r.URL.Query().Get("status"), r.PathValue("driver_id"))
+		if err != nil {
+			dh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, documents)
+	}
+}
+
+// GetDocumentFile streams the uploaded file for review
+func (dh *DocumentsHandler) GetDocumentFile() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), 6*WaitTime*time.Second)
+		defer cancel()
+
+		document, file, err := dh.documentsService.OpenDocument(ctx, r.PathValue("document_id"))
+		if err != nil {
+			dh.writeError(w, err)
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", document.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(document.SizeBytes, 10))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", document.FileName))
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, file); err != nil {
+			dh.mylog.Action("document_download_failed").Error("Failed to send document file", err)
+		}
+	}
+}
+
+func (dh *DocumentsHandler) ApproveDocument() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		req := dto.DocumentReviewRequest{}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				JsonError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		review, err := dh.documentsService.ApproveDocument(ctx, r.PathValue("document_id"), r.Header.Get("X-UserId"), req)
+		if err != nil {
+			dh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, review)
+	}
+}
+
+func (dh *DocumentsHandler) RejectDocument() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		req := dto.DocumentReviewRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		review, err := dh.documentsService.RejectDocument(ctx, r.PathValue("document_id"), r.Header.Get("X-UserId"), req)
+		if err != nil {
+			dh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, review)
+	}
+}
+
+func (dh *DocumentsHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReview):
+		JsonError(w, http.StatusBadRequest, err)
+	case errors.Is(err, database.ErrDocumentNotFound), errors.Is(err, filestore.ErrNotFound):
+		JsonError(w, http.StatusNotFound, err)
+	default:
+		dh.mylog.Action("document_request_failed").Error("Document request failed", err)
+		JsonError(w, http.StatusInternalServerError, fmt.Errorf("document request failed: %v", err))
+	}
+}
@@ -0,0 +1,45 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/admin-service/adapters/service/database"
+	"ride-hail/internal/admin-service/core/service"
+	"ride-hail/internal/logger"
+)
+
+type DriverScoreHandler struct {
+	driverScoreService *service.DriverScoreService
+	mylog              logger.Logger
+}
+
+func NewDriverScoreHandler(mylog logger.Logger, driverScoreService *service.DriverScoreService) *DriverScoreHandler {
+	return &DriverScoreHandler{
+		driverScoreService: driverScoreService,
+		mylog:              mylog,
+	}
+}
+
+func (dh *DriverScoreHandler) GetDriverScore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		score, err := dh.driverScoreService.GetDriverScore(ctx, r.PathValue("driver_id"))
+		switch {
+		case errors.Is(err, database.ErrDriverNotFound):
+			JsonError(w, http.StatusNotFound, err)
+			return
+		case err != nil:
+			dh.mylog.Action("driver_score_failed").Error("Failed to get driver score", err)
+			JsonError(w, http.StatusInternalServerError, fmt.Errorf("failed to get driver score: %v", err))
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, score)
+	}
+}
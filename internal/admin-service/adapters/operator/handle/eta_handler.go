@@ -0,0 +1,49 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ride-hail/internal/admin-service/core/service"
+	"ride-hail/internal/logger"
+)
+
+type EtaHandler struct {
+	etaService *service.EtaService
+	mylog      logger.Logger
+}
+
+func NewEtaHandler(mylog logger.Logger, etaService *service.EtaService) *EtaHandler {
+	return &EtaHandler{
+		etaService: etaService,
+		mylog:      mylog,
+	}
+}
+
+func (eh *EtaHandler) GetAccuracy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		days := 7
+		if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+			d, err := strconv.Atoi(daysStr)
+			if err != nil || d < 1 || d > 90 {
+				JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid days parameter, allowed [1, 90]"))
+				return
+			}
+			days = d
+		}
+
+		accuracy, err := eh.etaService.GetAccuracy(ctx, days)
+		if err != nil {
+			JsonError(w, http.StatusInternalServerError, fmt.Errorf("failed to get eta accuracy: %v", err))
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, accuracy)
+	}
+}
@@ -0,0 +1,39 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/admin-service/core/service"
+	"ride-hail/internal/logger"
+)
+
+type StorageHandler struct {
+	storageService *service.StorageService
+	mylog          logger.Logger
+}
+
+func NewStorageHandler(mylog logger.Logger, storageService *service.StorageService) *StorageHandler {
+	return &StorageHandler{
+		storageService: storageService,
+		mylog:          mylog,
+	}
+}
+
+func (sh *StorageHandler) GetLocationStorage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		storage, err := sh.storageService.GetLocationStorage(ctx)
+		if err != nil {
+			sh.mylog.Action("location_storage_failed").Error("Failed to get location storage", err)
+			JsonError(w, http.StatusInternalServerError, fmt.Errorf("failed to get location storage: %v", err))
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, storage)
+	}
+}
@@ -0,0 +1,125 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/admin-service/adapters/service/database"
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/service"
+	"ride-hail/internal/logger"
+)
+
+type ZonesHandler struct {
+	zonesService *service.ZonesService
+	mylog        logger.Logger
+}
+
+func NewZonesHandler(mylog logger.Logger, zonesService *service.ZonesService) *ZonesHandler {
+	return &ZonesHandler{
+		zonesService: zonesService,
+		mylog:        mylog,
+	}
+}
+
+func (zh *ZonesHandler) CreateZone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		req := dto.ZoneRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		zone, err := zh.zonesService.CreateZone(ctx, req)
+		if err != nil {
+			zh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusCreated, zone)
+	}
+}
+
+func (zh *ZonesHandler) ListZones() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		zones, err := zh.zonesService.ListZones(ctx, r.URL.Query().Get("type"))
+		if err != nil {
+			zh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, zones)
+	}
+}
+
+func (zh *ZonesHandler) GetZone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		zone, err := zh.zonesService.GetZone(ctx, r.PathValue("zone_id"))
+		if err != nil {
+			zh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, zone)
+	}
+}
+
+func (zh *ZonesHandler) UpdateZone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		req := dto.ZoneRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		zone, err := zh.zonesService.UpdateZone(ctx, r.PathValue("zone_id"), req)
+		if err != nil {
+			zh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, zone)
+	}
+}
+
+func (zh *ZonesHandler) DeleteZone() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		if err := zh.zonesService.DeleteZone(ctx, r.PathValue("zone_id")); err != nil {
+			zh.writeError(w, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusNoContent, nil)
+	}
+}
+
+func (zh *ZonesHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidZone), errors.Is(err, service.ErrInvalidGeometry):
+		JsonError(w, http.StatusBadRequest, err)
+	case errors.Is(err, database.ErrZoneNotFound):
+		JsonError(w, http.StatusNotFound, err)
+	default:
+		zh.mylog.Action("zone_request_failed").Error("Zone request failed", err)
+		JsonError(w, http.StatusInternalServerError, fmt.Errorf("zone request failed: %v", err))
+	}
+}
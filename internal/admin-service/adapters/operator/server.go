@@ -14,6 +14,7 @@ import (
 	"ride-hail/internal/admin-service/core/ports"
 	"ride-hail/internal/admin-service/core/service"
 	"ride-hail/internal/config"
+	"ride-hail/internal/filestore"
 	"ride-hail/internal/logger"
 )
 
@@ -27,6 +28,7 @@ type Server struct {
 	srv    *http.Server
 	mylog  logger.Logger
 	db     ports.IDB
+	store  filestore.Store
 	ctx    context.Context
 	appCtx context.Context
 	mu     sync.Mutex
@@ -53,6 +55,13 @@ func (s *Server) Run() error {
 	}
 	mylog.Action("db_connected").Info("Successful database connection")
 
+	store, err := filestore.NewLocal(s.cfg.Documents.Dir)
+	if err != nil {
+		mylog.Action("document_store_failed").Error("Document store is not available", err)
+		return err
+	}
+	s.store = store
+
 	// Configure routes and handlers
 	s.Configure()
 
@@ -126,18 +135,51 @@ func (s *Server) Configure() {
 	// Repositories and services
 	systemOverviewRepo := database.NewSystemOverviewRepo(s.db)
 	activeRidesRepo := database.NewActiveDrivesRepo(s.db)
+	zonesRepo := database.NewZonesRepo(s.db)
+	etaRepo := database.NewEtaRepo(s.db)
+	driverScoresRepo := database.NewDriverScoresRepo(s.db)
+	storageRepo := database.NewStorageRepo(s.db)
+	documentsRepo := database.NewDocumentsRepo(s.db)
 
 	systemOverviewService := service.NewSystemOverviewService(s.ctx, s.mylog, systemOverviewRepo)
 	activeRidesService := service.NewActiveDrivesService(s.ctx, s.mylog, activeRidesRepo)
+	zonesService := service.NewZonesService(s.ctx, s.mylog, zonesRepo)
+	etaService := service.NewEtaService(s.ctx, s.mylog, etaRepo)
+	driverScoreService := service.NewDriverScoreService(s.ctx, s.mylog, s.cfg.Scoring, driverScoresRepo)
+	storageService := service.NewStorageService(s.ctx, s.mylog, storageRepo)
+	documentsService := service.NewDocumentsService(s.ctx, s.mylog, documentsRepo, s.store)
 
 	systemOverviewHandler := handle2.NewSystemOverviewHandler(s.mylog, systemOverviewService)
 	activeRidesHandler := handle2.NewActiveDrivesHandler(s.mylog, activeRidesService)
+	zonesHandler := handle2.NewZonesHandler(s.mylog, zonesService)
+	etaHandler := handle2.NewEtaHandler(s.mylog, etaService)
+	driverScoreHandler := handle2.NewDriverScoreHandler(s.mylog, driverScoreService)
+	storageHandler := handle2.NewStorageHandler(s.mylog, storageService)
+	documentsHandler := handle2.NewDocumentsHandler(s.mylog, documentsService)
 
 	authMiddleware := middleware.NewAuthMiddleware(s.cfg.App.PublicJwtSecret)
 
 	// Register routes
 	s.mux.Handle("GET /admin/overview", authMiddleware.Wrap(systemOverviewHandler.GetSystemOverview()))
 	s.mux.Handle("GET /admin/rides/active", authMiddleware.Wrap(activeRidesHandler.GetActiveRides()))
+
+	s.mux.Handle("POST /admin/zones", authMiddleware.Wrap(zonesHandler.CreateZone()))
+	s.mux.Handle("GET /admin/zones", authMiddleware.Wrap(zonesHandler.ListZones()))
+	s.mux.Handle("GET /admin/zones/{zone_id}", authMiddleware.Wrap(zonesHandler.GetZone()))
+	s.mux.Handle("PUT /admin/zones/{zone_id}", authMiddleware.Wrap(zonesHandler.UpdateZone()))
+	s.mux.Handle("DELETE /admin/zones/{zone_id}", authMiddleware.Wrap(zonesHandler.DeleteZone()))
+
+	s.mux.Handle("GET /admin/eta/accuracy", authMiddleware.Wrap(etaHandler.GetAccuracy()))
+
+	s.mux.Handle("GET /admin/drivers/{driver_id}/score", authMiddleware.Wrap(driverScoreHandler.GetDriverScore()))
+
+	s.mux.Handle("GET /admin/storage/location-history", authMiddleware.Wrap(storageHandler.GetLocationStorage()))
+
+	s.mux.Handle("GET /admin/documents", authMiddleware.Wrap(documentsHandler.ListDocuments()))
+	s.mux.Handle("GET /admin/drivers/{driver_id}/documents", authMiddleware.Wrap(documentsHandler.ListDriverDocuments()))
+	s.mux.Handle("GET /admin/documents/{document_id}/file", authMiddleware.Wrap(documentsHandler.GetDocumentFile()))
+	s.mux.Handle("POST /admin/documents/{document_id}/approve", authMiddleware.Wrap(documentsHandler.ApproveDocument()))
+	s.mux.Handle("POST /admin/documents/{document_id}/reject", authMiddleware.Wrap(documentsHandler.RejectDocument()))
 }
 
 func (s *Server) initializeDatabase() error {
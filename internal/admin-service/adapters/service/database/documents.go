@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const documentColumns = `
+	document_id,
+	driver_id,
+	document_type,
+	status,
+	file_key,
+	file_name,
+	content_type,
+	size_bytes,
+	to_char(expires_at, 'YYYY-MM-DD'),
+	uploaded_at,
+	reviewed_at,
+	reviewed_by,
+	COALESCE(review_note, '')
+`
+
+type DocumentsRepo struct {
+	db ports.IDB
+}
+
+func NewDocumentsRepo(db ports.IDB) *DocumentsRepo {
+	return &DocumentsRepo{db: db}
+}
+
+// ListDocuments filters by status and driver, empty means any. Oldest first so the review
+// queue is worked in upload order.
+func (dr *DocumentsRepo) ListDocuments(ctx context.Context, status, driverID string) ([]dto.Document, error) {
+	q := `
+	SELECT ` + documentColumns + `
+	FROM driver_documents
+	WHERE ($1 = '' OR status = $1) AND ($2 = '' OR driver_id::text = $2)
+	ORDER BY uploaded_at
+	`
+
+	rows, err := dr.db.GetConn().Query(ctx, q, status, driverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []dto.Document{}
+	for rows.Next() {
+		document, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return documents, nil
+}
+
+func (dr *DocumentsRepo) GetDocument(ctx context.Context, documentID string) (dto.Document, error) {
+	q := `SELECT ` + documentColumns + ` FROM driver_documents WHERE document_id = $1`
+
+	document, err := scanDocument(dr.db.GetConn().QueryRow(ctx, q, documentID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.Document{}, ErrDocumentNotFound
+		}
+		return dto.Document{}, fmt.Errorf("failed to get document: %w", err)
+	}
+	return document, nil
+}
+
+// ReviewDocument sets the status of the document and recomputes drivers.is_verified: a driver
+// is verified with an approved, unexpired document of every required type
+func (dr *DocumentsRepo) ReviewDocument(ctx context.Context, documentID, status, reviewerID, note string, required []string) (dto.DocumentReview, error) {
+	tx, err := dr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return dto.DocumentReview{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := `
+	UPDATE driver_documents
+	SET status = $2, reviewed_at = NOW(), reviewed_by = $3, review_note = NULLIF($4, '')
+	WHERE document_id = $1
+	RETURNING ` + documentColumns
+
+	document, err := scanDocument(tx.QueryRow(ctx, q, documentID, status, reviewerID, note))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.DocumentReview{}, ErrDocumentNotFound
+		}
+		return dto.DocumentReview{}, fmt.Errorf("failed to review document: %w", err)
+	}
+
+	verifyQuery := `
+	UPDATE drivers d
+	SET is_verified = (
+		SELECT COUNT(DISTINCT dd.document_type)
+		FROM driver_documents dd
+		WHERE dd.driver_id = d.driver_id AND dd.status = 'APPROVED'
+			AND dd.expires_at >= CURRENT_DATE AND dd.document_type = ANY($2)
+	) = cardinality($2::text[]),
+		updated_at = NOW()
+	WHERE d.driver_id = $1
+	RETURNING d.is_verified
+	`
+	review := dto.DocumentReview{Document: document}
+	if err := tx.QueryRow(ctx, verifyQuery, document.DriverID, required).Scan(&review.DriverVerified); err != nil {
+		return dto.DocumentReview{}, fmt.Errorf("failed to update driver verification: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return dto.DocumentReview{}, fmt.Errorf("failed to commit review: %w", err)
+	}
+	return review, nil
+}
+
+func scanDocument(row pgx.Row) (dto.Document, error) {
+	var document dto.Document
+	err := row.Scan(
+		&document.DocumentID,
+		&document.DriverID,
+		&document.DocumentType,
+		&document.Status,
+		&document.FileKey,
+		&document.FileName,
+		&document.ContentType,
+		&document.SizeBytes,
+		&document.ExpiresAt,
+		&document.UploadedAt,
+		&document.ReviewedAt,
+		&document.ReviewedBy,
+		&document.ReviewNote,
+	)
+	return document, err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type DriverScoresRepo struct {
+	db ports.IDB
+}
+
+func NewDriverScoresRepo(db ports.IDB) *DriverScoresRepo {
+	return &DriverScoresRepo{db: db}
+}
+
+// GetDriverScore returns the last computed components, drivers without a score get the defaults
+func (dr *DriverScoresRepo) GetDriverScore(ctx context.Context, driverID string) (dto.DriverScore, error) {
+	q := `
+	SELECT
+		d.driver_id,
+		d.vehicle_type,
+		d.status,
+		COALESCE(s.acceptance_rate, 1)::float,
+		COALESCE(s.cancellation_rate, 0)::float,
+		COALESCE(s.completion_rate, 1)::float,
+		COALESCE(s.rating, d.rating, 5)::float,
+		COALESCE(s.offers_count, 0),
+		COALESCE(s.rides_count, 0),
+		COALESCE(s.window_days, 0),
+		s.computed_at
+	FROM drivers d
+	LEFT JOIN driver_scores s ON s.driver_id = d.driver_id
+	WHERE d.driver_id = $1`
+
+	var (
+		score      dto.DriverScore
+		computedAt *time.Time
+	)
+	err := dr.db.GetConn().QueryRow(ctx, q, driverID).Scan(
+		&score.DriverId,
+		&score.VehicleType,
+		&score.Status,
+		&score.Components.AcceptanceRate,
+		&score.Components.CancellationRate,
+		&score.Components.CompletionRate,
+		&score.Components.Rating,
+		&score.OffersCount,
+		&score.RidesCount,
+		&score.WindowDays,
+		&computedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.DriverScore{}, ErrDriverNotFound
+		}
+		return dto.DriverScore{}, fmt.Errorf("failed to get driver score: %w", err)
+	}
+	if computedAt != nil {
+		score.ComputedAt = computedAt.Format(time.RFC3339)
+	}
+	return score, nil
+}
@@ -0,0 +1,10 @@
+package database
+
+import "errors"
+
+var (
+	ErrZoneNotFound   = errors.New("zone not found")
+	ErrDriverNotFound = errors.New("driver not found")
+
+	ErrDocumentNotFound = errors.New("document not found")
+)
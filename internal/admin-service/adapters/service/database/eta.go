@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+)
+
+type EtaRepo struct {
+	db ports.IDB
+}
+
+func NewEtaRepo(db ports.IDB) *EtaRepo {
+	return &EtaRepo{db: db}
+}
+
+// GetAccuracy returns accuracy per ETA kind. Pickups are compared with arrived_at
+// (started_at when the arrival was never reported), dropoffs with completed_at.
+func (er *EtaRepo) GetAccuracy(ctx context.Context, days int) (map[string]dto.EtaAccuracyParams, error) {
+	q := `
+	SELECT
+		p.kind,
+		COUNT(*),
+		AVG(ABS(EXTRACT(EPOCH FROM (a.actual - p.predicted_arrival))) / 60)::float,
+		COALESCE(AVG(
+			ABS(EXTRACT(EPOCH FROM (a.actual - p.predicted_arrival)))
+			/ NULLIF(EXTRACT(EPOCH FROM (a.actual - p.predicted_at)), 0)
+		) * 100, 0)::float,
+		AVG(EXTRACT(EPOCH FROM (a.actual - p.predicted_arrival)) / 60)::float,
+		(COUNT(*) FILTER (WHERE p.source = 'profile'))::float / COUNT(*)::float
+	FROM eta_predictions p
+	JOIN rides r ON r.ride_id = p.ride_id
+	CROSS JOIN LATERAL (
+		SELECT CASE
+			WHEN p.kind = 'PICKUP' THEN COALESCE(r.arrived_at, r.started_at)
+			ELSE r.completed_at
+		END AS actual
+	) a
+	WHERE a.actual IS NOT NULL
+		AND a.actual > p.predicted_at
+		AND p.predicted_at >= NOW() - make_interval(days => $1)
+	GROUP BY p.kind`
+
+	rows, err := er.db.GetConn().Query(ctx, q, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get eta accuracy: %v", err)
+	}
+	defer rows.Close()
+
+	res := make(map[string]dto.EtaAccuracyParams)
+	for rows.Next() {
+		var (
+			kind   string
+			params dto.EtaAccuracyParams
+		)
+		if err := rows.Scan(&kind, &params.Samples, &params.MaeMinutes, &params.MapePercent, &params.MeanBiasMinutes, &params.ProfileSamplesRate); err != nil {
+			return nil, fmt.Errorf("failed to scan eta accuracy: %v", err)
+		}
+		res[kind] = params
+	}
+	return res, rows.Err()
+}
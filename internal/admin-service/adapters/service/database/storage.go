@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+)
+
+type StorageRepo struct {
+	db ports.IDB
+}
+
+func NewStorageRepo(db ports.IDB) *StorageRepo {
+	return &StorageRepo{db: db}
+}
+
+// GetLocationPartitions returns the size of every location_history partition,
+// row counts are the planner estimates to keep the report cheap
+func (sr *StorageRepo) GetLocationPartitions(ctx context.Context) ([]dto.PartitionStorage, error) {
+	q := `
+	SELECT
+		c.relname,
+		pg_get_expr(c.relpartbound, c.oid),
+		GREATEST(c.reltuples, 0)::bigint,
+		pg_total_relation_size(c.oid),
+		pg_size_pretty(pg_total_relation_size(c.oid))
+	FROM pg_inherits i
+	JOIN pg_class c ON c.oid = i.inhrelid
+	WHERE i.inhparent = 'location_history'::regclass
+	ORDER BY c.relname`
+
+	rows, err := sr.db.GetConn().Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get location partitions: %v", err)
+	}
+	defer rows.Close()
+
+	partitions := []dto.PartitionStorage{}
+	for rows.Next() {
+		var p dto.PartitionStorage
+		if err := rows.Scan(&p.Name, &p.Bounds, &p.EstimatedRows, &p.TotalBytes, &p.TotalSize); err != nil {
+			return nil, fmt.Errorf("failed to scan location partition: %v", err)
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+func (sr *StorageRepo) GetRideTracksStorage(ctx context.Context) (dto.TableStorage, error) {
+	q := `
+	SELECT
+		(SELECT COUNT(*) FROM ride_tracks),
+		pg_total_relation_size('ride_tracks'),
+		pg_size_pretty(pg_total_relation_size('ride_tracks'))`
+
+	var t dto.TableStorage
+	if err := sr.db.GetConn().QueryRow(ctx, q).Scan(&t.Rows, &t.TotalBytes, &t.TotalSize); err != nil {
+		return dto.TableStorage{}, fmt.Errorf("failed to get ride tracks storage: %v", err)
+	}
+	return t, nil
+}
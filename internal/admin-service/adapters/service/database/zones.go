@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const zoneColumns = `
+	zone_id,
+	name,
+	zone_type,
+	surcharge::float,
+	is_active,
+	ST_AsGeoJSON(geom)::text,
+	created_at,
+	updated_at
+`
+
+type ZonesRepo struct {
+	db ports.IDB
+}
+
+func NewZonesRepo(db ports.IDB) *ZonesRepo {
+	return &ZonesRepo{db: db}
+}
+
+func (zr *ZonesRepo) CreateZone(ctx context.Context, req dto.ZoneRequest) (dto.Zone, error) {
+	q := `
+	INSERT INTO zones (name, zone_type, surcharge, is_active, geom)
+	VALUES ($1, $2, COALESCE($3, 0), COALESCE($4, true), ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($5), 4326)))
+	RETURNING ` + zoneColumns
+
+	row := zr.db.GetConn().QueryRow(ctx, q, req.Name, req.ZoneType, req.Surcharge, req.IsActive, string(req.Geometry))
+	zone, err := scanZone(row)
+	if err != nil {
+		return dto.Zone{}, fmt.Errorf("failed to create zone: %w", err)
+	}
+	return zone, nil
+}
+
+func (zr *ZonesRepo) GetZone(ctx context.Context, zoneID string) (dto.Zone, error) {
+	q := `SELECT ` + zoneColumns + ` FROM zones WHERE zone_id = $1`
+
+	zone, err := scanZone(zr.db.GetConn().QueryRow(ctx, q, zoneID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.Zone{}, ErrZoneNotFound
+		}
+		return dto.Zone{}, fmt.Errorf("failed to get zone: %w", err)
+	}
+	return zone, nil
+}
+
+func (zr *ZonesRepo) ListZones(ctx context.Context, zoneType string) ([]dto.Zone, error) {
+	q := `
+	SELECT ` + zoneColumns + `
+	FROM zones
+	WHERE $1 = '' OR zone_type::text = $1
+	ORDER BY created_at DESC
+	`
+
+	rows, err := zr.db.GetConn().Query(ctx, q, zoneType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []dto.Zone{}
+	for rows.Next() {
+		zone, err := scanZone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan zone: %w", err)
+		}
+		zones = append(zones, zone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return zones, nil
+}
+
+func (zr *ZonesRepo) UpdateZone(ctx context.Context, zoneID string, req dto.ZoneRequest) (dto.Zone, error) {
+	var geometry *string
+	if len(req.Geometry) > 0 {
+		g := string(req.Geometry)
+		geometry = &g
+	}
+
+	q := `
+	UPDATE zones
+	SET
+		name = COALESCE($2, name),
+		zone_type = COALESCE($3::zone_type, zone_type),
+		surcharge = COALESCE($4, surcharge),
+		is_active = COALESCE($5, is_active),
+		geom = COALESCE(ST_Multi(ST_SetSRID(ST_GeomFromGeoJSON($6), 4326)), geom),
+		updated_at = NOW()
+	WHERE zone_id = $1
+	RETURNING ` + zoneColumns
+
+	row := zr.db.GetConn().QueryRow(ctx, q, zoneID, req.Name, req.ZoneType, req.Surcharge, req.IsActive, geometry)
+	zone, err := scanZone(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dto.Zone{}, ErrZoneNotFound
+		}
+		return dto.Zone{}, fmt.Errorf("failed to update zone: %w", err)
+	}
+	return zone, nil
+}
+
+func (zr *ZonesRepo) DeleteZone(ctx context.Context, zoneID string) error {
+	q := `DELETE FROM zones WHERE zone_id = $1`
+
+	tag, err := zr.db.GetConn().Exec(ctx, q, zoneID)
+	if err != nil {
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrZoneNotFound
+	}
+	return nil
+}
+
+func scanZone(row pgx.Row) (dto.Zone, error) {
+	var (
+		zone     dto.Zone
+		geometry string
+	)
+	err := row.Scan(
+		&zone.ZoneID,
+		&zone.Name,
+		&zone.ZoneType,
+		&zone.Surcharge,
+		&zone.IsActive,
+		&geometry,
+		&zone.CreatedAt,
+		&zone.UpdatedAt,
+	)
+	if err != nil {
+		return dto.Zone{}, err
+	}
+	zone.Geometry = []byte(geometry)
+	return zone, nil
+}
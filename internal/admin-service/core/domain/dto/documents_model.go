@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+const (
+	DocumentStatusPending  = "PENDING"
+	DocumentStatusApproved = "APPROVED"
+	DocumentStatusRejected = "REJECTED"
+)
+
+// RequiredDocuments a driver needs approved and unexpired to be verified
+var RequiredDocuments = []string{"LICENSE", "INSURANCE", "VEHICLE_REGISTRATION"}
+
+type Document struct {
+	DocumentID   string     `json:"document_id"`
+	DriverID     string     `json:"driver_id"`
+	DocumentType string     `json:"document_type"`
+	Status       string     `json:"status"`
+	FileKey      string     `json:"-"`
+	FileName     string     `json:"file_name"`
+	ContentType  string     `json:"content_type"`
+	SizeBytes    int64      `json:"size_bytes"`
+	ExpiresAt    string     `json:"expires_at"`
+	UploadedAt   time.Time  `json:"uploaded_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy   *string    `json:"reviewed_by,omitempty"`
+	ReviewNote   string     `json:"review_note,omitempty"`
+}
+
+type Documents struct {
+	Documents  []Document `json:"documents"`
+	TotalCount int        `json:"total_count"`
+}
+
+type DocumentReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// DocumentReview is the reviewed document and whether the driver is verified after it
+type DocumentReview struct {
+	Document
+	DriverVerified bool `json:"driver_verified"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+import (
+	"ride-hail/internal/config"
+	"ride-hail/internal/scoring"
+)
+
+type DriverScore struct {
+	DriverId    string              `json:"driver_id"`
+	VehicleType string              `json:"vehicle_type"`
+	Status      string              `json:"status"`
+	Score       float64             `json:"score"`
+	Components  scoring.Components  `json:"components"`
+	Weights     config.ScoreWeights `json:"weights"`
+	OffersCount int                 `json:"offers_count"`
+	RidesCount  int                 `json:"rides_count"`
+	WindowDays  int                 `json:"window_days"`
+	ComputedAt  string              `json:"computed_at,omitempty"`
+}
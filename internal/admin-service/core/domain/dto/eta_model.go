@@ -0,0 +1,23 @@
+package dto
+
+const (
+	EtaKindPickup  = "PICKUP"
+	EtaKindDropoff = "DROPOFF"
+)
+
+type EtaAccuracy struct {
+	Timestamp  string            `json:"timestamp"`
+	WindowDays int               `json:"window_days"`
+	Pickup     EtaAccuracyParams `json:"pickup"`
+	Dropoff    EtaAccuracyParams `json:"dropoff"`
+}
+
+// EtaAccuracyParams compares the first ETA shown for a ride leg with the actual arrival.
+// MAPE is relative to the actual remaining time when the prediction was made.
+type EtaAccuracyParams struct {
+	Samples            int     `json:"samples"`
+	MaeMinutes         float64 `json:"mae_minutes"`
+	MapePercent        float64 `json:"mape_percent"`
+	MeanBiasMinutes    float64 `json:"mean_bias_minutes"` // positive = arrived later than predicted
+	ProfileSamplesRate float64 `json:"profile_samples_rate"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+type LocationStorage struct {
+	Timestamp  string             `json:"timestamp"`
+	Partitions []PartitionStorage `json:"partitions"`
+	TotalBytes int64              `json:"total_bytes"`
+	TotalSize  string             `json:"total_size"`
+	RideTracks TableStorage       `json:"ride_tracks"`
+}
+
+type PartitionStorage struct {
+	Name          string `json:"name"`
+	Bounds        string `json:"bounds"`
+	EstimatedRows int64  `json:"estimated_rows"`
+	TotalBytes    int64  `json:"total_bytes"`
+	TotalSize     string `json:"total_size"`
+}
+
+type TableStorage struct {
+	Rows       int64  `json:"rows"`
+	TotalBytes int64  `json:"total_bytes"`
+	TotalSize  string `json:"total_size"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ZoneTypeServiceArea = "SERVICE_AREA"
+	ZoneTypeAirport     = "AIRPORT"
+	ZoneTypeRestricted  = "RESTRICTED"
+	ZoneTypeSurcharge   = "SURCHARGE"
+)
+
+type Zone struct {
+	ZoneID    string          `json:"zone_id"`
+	Name      string          `json:"name"`
+	ZoneType  string          `json:"zone_type"`
+	Surcharge float64         `json:"surcharge"`
+	IsActive  bool            `json:"is_active"`
+	Geometry  json.RawMessage `json:"geometry"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ZoneRequest is used for both create and update, on update nil fields are left unchanged.
+// Geometry must be a GeoJSON Polygon or MultiPolygon in WGS84 (lng, lat) order.
+type ZoneRequest struct {
+	Name      *string         `json:"name"`
+	ZoneType  *string         `json:"zone_type"`
+	Surcharge *float64        `json:"surcharge"`
+	IsActive  *bool           `json:"is_active"`
+	Geometry  json.RawMessage `json:"geometry"`
+}
+
+type Zones struct {
+	Zones      []Zone `json:"zones"`
+	TotalCount int    `json:"total_count"`
+}
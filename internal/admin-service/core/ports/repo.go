@@ -23,3 +23,30 @@ type ISystemOverviewRepo interface {
 type IActiveRidesRepo interface {
 	GetActiveRides(ctx context.Context, page, pageSize int) (int, []dto.Ride, error)
 }
+
+type IZonesRepo interface {
+	CreateZone(ctx context.Context, req dto.ZoneRequest) (dto.Zone, error)
+	GetZone(ctx context.Context, zoneID string) (dto.Zone, error)
+	ListZones(ctx context.Context, zoneType string) ([]dto.Zone, error)
+	UpdateZone(ctx context.Context, zoneID string, req dto.ZoneRequest) (dto.Zone, error)
+	DeleteZone(ctx context.Context, zoneID string) error
+}
+
+type IEtaRepo interface {
+	GetAccuracy(ctx context.Context, days int) (map[string]dto.EtaAccuracyParams, error)
+}
+
+type IDriverScoresRepo interface {
+	GetDriverScore(ctx context.Context, driverID string) (dto.DriverScore, error)
+}
+
+type IStorageRepo interface {
+	GetLocationPartitions(ctx context.Context) ([]dto.PartitionStorage, error)
+	GetRideTracksStorage(ctx context.Context) (dto.TableStorage, error)
+}
+
+type IDocumentsRepo interface {
+	ListDocuments(ctx context.Context, status, driverID string) ([]dto.Document, error)
+	GetDocument(ctx context.Context, documentID string) (dto.Document, error)
+	ReviewDocument(ctx context.Context, documentID, status, reviewerID, note string, required []string) (dto.DocumentReview, error)
+}
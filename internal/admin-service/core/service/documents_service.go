@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+	"ride-hail/internal/filestore"
+	"ride-hail/internal/logger"
+)
+
+var ErrInvalidReview = errors.New("invalid review")
+
+var AllowedDocumentStatuses = map[string]bool{
+	dto.DocumentStatusPending:  true,
+	dto.DocumentStatusApproved: true,
+	dto.DocumentStatusRejected: true,
+}
+
+type DocumentsService struct {
+	ctx           context.Context
+	mylog         logger.Logger
+	documentsRepo ports.IDocumentsRepo
+	store         filestore.Store
+}
+
+func NewDocumentsService(ctx context.Context, mylog logger.Logger, documentsRepo ports.IDocumentsRepo, store filestore.Store) *DocumentsService {
+	return &DocumentsService{
+		ctx:           ctx,
+		mylog:         mylog,
+		documentsRepo: documentsRepo,
+		store:         store,
+	}
+}
+
+func (ds *DocumentsService) ListDocuments(ctx context.Context, status, driverID string) (dto.Documents, error) {
+	status = strings.ToUpper(status)
+	if status != "" && !AllowedDocumentStatuses[status] {
+		return dto.Documents{}, fmt.Errorf("%w: unknown status %s", ErrInvalidReview, status)
+	}
+
+	documents, err := ds.documentsRepo.ListDocuments(ctx, status, driverID)
+	if err != nil {
+		return dto.Documents{}, err
+	}
+	return dto.Documents{Documents: documents, TotalCount: len(documents)}, nil
+}
+
+// OpenDocument returns the document with its file, the caller closes the file
+func (ds *DocumentsService) OpenDocument(ctx context.Context, documentID string) (dto.Document, io.ReadCloser, error) {
+	document, err := ds.documentsRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		return dto.Document{}, nil, err
+	}
+	file, err := ds.store.Open(ctx, document.FileKey)
+	if err != nil {
+		return dto.Document{}, nil, err
+	}
+	return document, file, nil
+}
+
+func (ds *DocumentsService) ApproveDocument(ctx context.Context, documentID, reviewerID string, req dto.DocumentReviewRequest) (dto.DocumentReview, error) {
+	document, err := ds.documentsRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		return dto.DocumentReview{}, err
+	}
+	if document.ExpiresAt < time.Now().UTC().Format(time.DateOnly) {
+		return dto.DocumentReview{}, fmt.Errorf("%w: document expired on %s", ErrInvalidReview, document.ExpiresAt)
+	}
+	return ds.review(ctx, documentID, dto.DocumentStatusApproved, reviewerID, req.Note)
+}
+
+func (ds *DocumentsService) RejectDocument(ctx context.Context, documentID, reviewerID string, req dto.DocumentReviewRequest) (dto.DocumentReview, error) {
+	if strings.TrimSpace(req.Note) == "" {
+		return dto.DocumentReview{}, fmt.Errorf("%w: note is required to reject a document", ErrInvalidReview)
+	}
+	return ds.review(ctx, documentID, dto.DocumentStatusRejected, reviewerID, req.Note)
+}
+
+func (ds *DocumentsService) review(ctx context.Context, documentID, status, reviewerID, note string) (dto.DocumentReview, error) {
+	review, err := ds.documentsRepo.ReviewDocument(ctx, documentID, status, reviewerID, strings.TrimSpace(note), dto.RequiredDocuments)
+	if err != nil {
+		return dto.DocumentReview{}, err
+	}
+	ds.mylog.Action("document_reviewed").Info("Document reviewed",
+		"document_id", documentID, "driver_id", review.DriverID, "status", status, "driver_verified", review.DriverVerified)
+	return review, nil
+}
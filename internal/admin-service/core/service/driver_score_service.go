@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+	"ride-hail/internal/config"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/scoring"
+)
+
+type DriverScoreService struct {
+	ctx             context.Context
+	mylog           logger.Logger
+	cfg             *config.Scoringconfig
+	driverScoreRepo ports.IDriverScoresRepo
+}
+
+func NewDriverScoreService(ctx context.Context, mylog logger.Logger, cfg *config.Scoringconfig, driverScoreRepo ports.IDriverScoresRepo) *DriverScoreService {
+	return &DriverScoreService{
+		ctx:             ctx,
+		mylog:           mylog,
+		cfg:             cfg,
+		driverScoreRepo: driverScoreRepo,
+	}
+}
+
+// GetDriverScore returns the quality components with the weights of the driver's vehicle type
+func (ds *DriverScoreService) GetDriverScore(ctx context.Context, driverID string) (dto.DriverScore, error) {
+	score, err := ds.driverScoreRepo.GetDriverScore(ctx, driverID)
+	if err != nil {
+		return dto.DriverScore{}, err
+	}
+
+	score.Weights = ds.cfg.Weights[score.VehicleType]
+	score.Score = scoring.Quality(score.Weights, score.Components)
+	return score, nil
+}
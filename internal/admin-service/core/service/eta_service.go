@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+	"ride-hail/internal/logger"
+)
+
+type EtaService struct {
+	ctx     context.Context
+	mylog   logger.Logger
+	etaRepo ports.IEtaRepo
+}
+
+func NewEtaService(ctx context.Context, mylog logger.Logger, etaRepo ports.IEtaRepo) *EtaService {
+	return &EtaService{
+		ctx:     ctx,
+		mylog:   mylog,
+		etaRepo: etaRepo,
+	}
+}
+
+func (es *EtaService) GetAccuracy(ctx context.Context, days int) (dto.EtaAccuracy, error) {
+	byKind, err := es.etaRepo.GetAccuracy(ctx, days)
+	if err != nil {
+		return dto.EtaAccuracy{}, fmt.Errorf("Failed to get eta accuracy: %v", err)
+	}
+
+	return dto.EtaAccuracy{
+		Timestamp:  time.Now().Format(time.RFC3339),
+		WindowDays: days,
+		Pickup:     byKind[dto.EtaKindPickup],
+		Dropoff:    byKind[dto.EtaKindDropoff],
+	}, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+	"ride-hail/internal/logger"
+)
+
+type StorageService struct {
+	ctx         context.Context
+	mylog       logger.Logger
+	storageRepo ports.IStorageRepo
+}
+
+func NewStorageService(ctx context.Context, mylog logger.Logger, storageRepo ports.IStorageRepo) *StorageService {
+	return &StorageService{
+		ctx:         ctx,
+		mylog:       mylog,
+		storageRepo: storageRepo,
+	}
+}
+
+func (ss *StorageService) GetLocationStorage(ctx context.Context) (dto.LocationStorage, error) {
+	partitions, err := ss.storageRepo.GetLocationPartitions(ctx)
+	if err != nil {
+		return dto.LocationStorage{}, err
+	}
+	tracks, err := ss.storageRepo.GetRideTracksStorage(ctx)
+	if err != nil {
+		return dto.LocationStorage{}, err
+	}
+
+	var total int64
+	for _, p := range partitions {
+		total += p.TotalBytes
+	}
+
+	return dto.LocationStorage{
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Partitions: partitions,
+		TotalBytes: total,
+		TotalSize:  prettyBytes(total),
+		RideTracks: tracks,
+	}, nil
+}
+
+func prettyBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
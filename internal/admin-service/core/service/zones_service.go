@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"ride-hail/internal/admin-service/core/domain/dto"
+	"ride-hail/internal/admin-service/core/ports"
+	"ride-hail/internal/logger"
+)
+
+var (
+	ErrInvalidZone     = errors.New("invalid zone")
+	ErrInvalidGeometry = errors.New("geometry must be a GeoJSON Polygon or MultiPolygon")
+)
+
+var AllowedZoneTypes = map[string]bool{
+	dto.ZoneTypeServiceArea: true,
+	dto.ZoneTypeAirport:     true,
+	dto.ZoneTypeRestricted:  true,
+	dto.ZoneTypeSurcharge:   true,
+}
+
+type ZonesService struct {
+	ctx       context.Context
+	mylog     logger.Logger
+	zonesRepo ports.IZonesRepo
+}
+
+func NewZonesService(ctx context.Context, mylog logger.Logger, zonesRepo ports.IZonesRepo) *ZonesService {
+	return &ZonesService{
+		ctx:       ctx,
+		mylog:     mylog,
+		zonesRepo: zonesRepo,
+	}
+}
+
+func (zs *ZonesService) CreateZone(ctx context.Context, req dto.ZoneRequest) (dto.Zone, error) {
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		return dto.Zone{}, fmt.Errorf("%w: name is required", ErrInvalidZone)
+	}
+	if req.ZoneType == nil {
+		return dto.Zone{}, fmt.Errorf("%w: zone_type is required", ErrInvalidZone)
+	}
+	if len(req.Geometry) == 0 {
+		return dto.Zone{}, fmt.Errorf("%w: geometry is required", ErrInvalidZone)
+	}
+	if err := validateZoneRequest(&req); err != nil {
+		return dto.Zone{}, err
+	}
+
+	zone, err := zs.zonesRepo.CreateZone(ctx, req)
+	if err != nil {
+		return dto.Zone{}, err
+	}
+	zs.mylog.Action("zone_created").Info("Zone created", "zone_id", zone.ZoneID, "zone_type", zone.ZoneType)
+	return zone, nil
+}
+
+func (zs *ZonesService) GetZone(ctx context.Context, zoneID string) (dto.Zone, error) {
+	return zs.zonesRepo.GetZone(ctx, zoneID)
+}
+
+func (zs *ZonesService) ListZones(ctx context.Context, zoneType string) (dto.Zones, error) {
+	zoneType = strings.ToUpper(zoneType)
+	if zoneType != "" && !AllowedZoneTypes[zoneType] {
+		return dto.Zones{}, fmt.Errorf("%w: unknown zone_type %s", ErrInvalidZone, zoneType)
+	}
+
+	zones, err := zs.zonesRepo.ListZones(ctx, zoneType)
+	if err != nil {
+		return dto.Zones{}, err
+	}
+	return dto.Zones{Zones: zones, TotalCount: len(zones)}, nil
+}
+
+func (zs *ZonesService) UpdateZone(ctx context.Context, zoneID string, req dto.ZoneRequest) (dto.Zone, error) {
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return dto.Zone{}, fmt.Errorf("%w: name cannot be empty", ErrInvalidZone)
+	}
+	if err := validateZoneRequest(&req); err != nil {
+		return dto.Zone{}, err
+	}
+
+	zone, err := zs.zonesRepo.UpdateZone(ctx, zoneID, req)
+	if err != nil {
+		return dto.Zone{}, err
+	}
+	zs.mylog.Action("zone_updated").Info("Zone updated", "zone_id", zone.ZoneID)
+	return zone, nil
+}
+
+func (zs *ZonesService) DeleteZone(ctx context.Context, zoneID string) error {
+	if err := zs.zonesRepo.DeleteZone(ctx, zoneID); err != nil {
+		return err
+	}
+	zs.mylog.Action("zone_deleted").Info("Zone deleted", "zone_id", zoneID)
+	return nil
+}
+
+// validateZoneRequest normalizes the zone type and checks the optional fields that are set
+func validateZoneRequest(req *dto.ZoneRequest) error {
+	if req.ZoneType != nil {
+		zoneType := strings.ToUpper(*req.ZoneType)
+		if !AllowedZoneTypes[zoneType] {
+			return fmt.Errorf("%w: unknown zone_type %s", ErrInvalidZone, *req.ZoneType)
+		}
+		req.ZoneType = &zoneType
+	}
+	if req.Surcharge != nil && *req.Surcharge < 0 {
+		return fmt.Errorf("%w: surcharge cannot be negative", ErrInvalidZone)
+	}
+	if len(req.Geometry) > 0 {
+		if err := validateGeometry(req.Geometry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateGeometry(raw json.RawMessage) error {
+	geometry := struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}{}
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	switch geometry.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &rings); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		return validatePolygon(rings)
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		if len(polygons) == 0 {
+			return fmt.Errorf("%w: no polygons", ErrInvalidGeometry)
+		}
+		for _, rings := range polygons {
+			if err := validatePolygon(rings); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return ErrInvalidGeometry
+	}
+}
+
+func validatePolygon(rings [][][]float64) error {
+	if len(rings) == 0 {
+		return fmt.Errorf("%w: polygon has no rings", ErrInvalidGeometry)
+	}
+	for _, ring := range rings {
+		if len(ring) < 4 {
+			return fmt.Errorf("%w: ring must have at least 4 positions", ErrInvalidGeometry)
+		}
+		for _, position := range ring {
+			if len(position) < 2 {
+				return fmt.Errorf("%w: position must be [lng, lat]", ErrInvalidGeometry)
+			}
+			if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return fmt.Errorf("%w: position out of range", ErrInvalidGeometry)
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("%w: ring must be closed", ErrInvalidGeometry)
+		}
+	}
+	return nil
+}
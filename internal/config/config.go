@@ -4,16 +4,35 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	// "gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DB       *DBconfig
-	RabbitMq *RabbitMqconfig
-	WS       *WebSocketconfig
-	Srv      *Serviceconfig
-	Log      *Loggerconfig
-	App      *App
+	DB          *DBconfig
+	RabbitMq    *RabbitMqconfig
+	WS          *WebSocketconfig
+	Srv         *Serviceconfig
+	Log         *Loggerconfig
+	App         *App
+	Routing     *Routingconfig
+	Eta         *Etaconfig
+	Matching    *Matchingconfig
+	Dispatch    *Dispatchconfig
+	Scoring     *Scoringconfig
+	Index       *Indexconfig
+	Location    *Locationconfig
+	Writer      *LocationWriterconfig
+	Retention   *Retentionconfig
+	Arrival     *Arrivalconfig
+	Earnings    *Earningsconfig
+	Demand      *Demandconfig
+	Destination *Destinationconfig
+	Hours       *Hoursconfig
+	Documents   *Documentsconfig
+	Vehicles    *Vehiclesconfig
+	Resume      *Resumeconfig
+	Cluster     *Clusterconfig
 }
 
 type DBconfig struct {
@@ -47,6 +66,137 @@ type Loggerconfig struct {
 	Level string `yaml:"level"`
 }
 
+type Routingconfig struct {
+	OSRMURL      string  `yaml:"osrm_url"`
+	Profile      string  `yaml:"profile"`
+	TimeoutMs    int     `yaml:"timeout_ms"`
+	DetourFactor float64 `yaml:"detour_factor"`
+	AvgSpeedKmh  float64 `yaml:"avg_speed_kmh"`
+}
+
+type Etaconfig struct {
+	RefreshIntervalSec int     `yaml:"refresh_interval_sec"`
+	CellSizeDeg        float64 `yaml:"cell_size_deg"`
+	MinSamples         int     `yaml:"min_samples"`
+	LookbackDays       int     `yaml:"lookback_days"`
+}
+
+type Matchingconfig struct {
+	Mode          string `yaml:"mode"` // greedy | batch
+	BatchWindowMs int    `yaml:"batch_window_ms"`
+	BatchMinRides int    `yaml:"batch_min_rides"`
+	MaxRequeues   int    `yaml:"max_requeues"` // windows a contested ride waits before it is offered anyway
+}
+
+type Dispatchconfig struct {
+	Strategies      map[string]string `yaml:"strategies"` // vehicle type -> sequential | broadcast | cascade
+	OfferTimeoutSec int               `yaml:"offer_timeout_sec"`
+	BroadcastSize   int               `yaml:"broadcast_size"`
+	CascadeWaves    []int             `yaml:"cascade_waves"`
+}
+
+type Scoringconfig struct {
+	WindowDays         int                     `yaml:"window_days"`
+	ShortWindowDays    int                     `yaml:"short_window_days"`   // recent behaviour, blended into the long window
+	ShortWindowWeight  float64                 `yaml:"short_window_weight"` // share of the short window in [0, 1]
+	RefreshIntervalSec int                     `yaml:"refresh_interval_sec"`
+	Weights            map[string]ScoreWeights `yaml:"weights"` // per vehicle type
+}
+
+type ScoreWeights struct {
+	Distance     float64 `yaml:"distance"`
+	Rating       float64 `yaml:"rating"`
+	Acceptance   float64 `yaml:"acceptance"`
+	Completion   float64 `yaml:"completion"`
+	Cancellation float64 `yaml:"cancellation"`
+}
+
+type Indexconfig struct {
+	CellSizeDeg float64 `yaml:"cell_size_deg"`
+	ResyncSec   int     `yaml:"resync_sec"` // full reload from Postgres, 0 = only on startup
+}
+
+type Locationconfig struct {
+	MinIntervalSeconds float64 `yaml:"min_interval_seconds"`
+	MaxSpeedKmh        float64 `yaml:"max_speed_kmh"`
+	MaxAccuracyMeters  float64 `yaml:"max_accuracy_meters"`
+	ProcessNoiseMps    float64 `yaml:"process_noise_mps"`   // expected movement per second the filter allows for
+	OutlierResetAfter  int     `yaml:"outlier_reset_after"` // consecutive rejected jumps before the track restarts
+}
+
+type LocationWriterconfig struct {
+	BatchSize        int `yaml:"batch_size"`
+	FlushIntervalMs  int `yaml:"flush_interval_ms"`
+	QueueSize        int `yaml:"queue_size"`
+	EnqueueTimeoutMs int `yaml:"enqueue_timeout_ms"` // how long a full queue blocks before the update is refused
+}
+
+type Retentionconfig struct {
+	RideDays           int     `yaml:"ride_days"`     // raw points of rides, the partitions are dropped
+	NonRideDays        int     `yaml:"non_ride_days"` // raw points outside of rides
+	PartitionAheadDays int     `yaml:"partition_ahead_days"`
+	IntervalSec        int     `yaml:"interval_sec"`
+	CompactIntervalSec int     `yaml:"compact_interval_sec"`
+	SimplifyMeters     float64 `yaml:"simplify_meters"`
+}
+
+type Arrivalconfig struct {
+	RadiusMeters float64 `yaml:"radius_meters"` // distance to the pickup that counts as there
+	DwellSeconds float64 `yaml:"dwell_seconds"` // time the driver stays inside before ARRIVED
+}
+
+type Earningsconfig struct {
+	CommissionRate float64 `yaml:"commission_rate"` // platform share of the fare, tips are not commissioned
+	MaxTip         float64 `yaml:"max_tip"`
+}
+
+type Demandconfig struct {
+	CellSizeDeg    float64 `yaml:"cell_size_deg"`
+	WindowMinutes  int     `yaml:"window_minutes"` // recent requests and completed pickups
+	RefreshSec     int     `yaml:"refresh_sec"`    // heatmap recompute and demand_map push
+	RadiusKm       float64 `yaml:"radius_km"`      // cells shown and suggested around a driver
+	MaxSuggestions int     `yaml:"max_suggestions"`
+	MinShortage    float64 `yaml:"min_shortage"` // demand minus supply for a cell to be under-supplied
+}
+
+type Destinationconfig struct {
+	UsesPerDay  int     `yaml:"uses_per_day"`
+	MinProgress float64 `yaml:"min_progress"` // fraction of the distance to the target a ride has to remove
+}
+
+type Hoursconfig struct {
+	Limits            map[string]HoursLimits `yaml:"limits"` // per jurisdiction, DEFAULT for drivers of any other
+	WarnBeforeMinutes int                    `yaml:"warn_before_minutes"`
+	CheckIntervalSec  int                    `yaml:"check_interval_sec"`
+}
+
+type HoursLimits struct {
+	MaxOnlineHours  float64 `yaml:"max_online_hours"`  // continuous time online
+	MaxDrivingHours float64 `yaml:"max_driving_hours"` // time on trips within the same stretch
+	BreakMinutes    int     `yaml:"break_minutes"`     // time offline that ends the stretch
+}
+
+type Documentsconfig struct {
+	Dir         string `yaml:"dir"` // local file store, shared by driver-location-service and admin-service
+	MaxUploadMB int    `yaml:"max_upload_mb"`
+}
+
+type Vehiclesconfig struct {
+	OptIn map[string][]string `yaml:"opt_in"` // vehicle type -> other ride types its vehicles may opt in to
+}
+
+type Resumeconfig struct {
+	BufferSize int `yaml:"buffer_size"` // messages kept per driver for replay
+	TTLSec     int `yaml:"ttl_sec"`     // how long a disconnected session can be resumed
+}
+
+type Clusterconfig struct {
+	InstanceID   string `yaml:"instance_id"`    // unique per replica
+	HeartbeatSec int    `yaml:"heartbeat_sec"`  // registry refresh of the replica
+	TTLSec       int    `yaml:"ttl_sec"`        // replicas silent for longer are considered gone
+	RevokeTTLSec int    `yaml:"revoke_ttl_sec"` // a revoked passenger device is refused for this long
+}
+
 type App struct {
 	PublicJwtSecret  string `yaml:"public_jwt"`
 	PrivateJwtSecret string `yaml:"private_jwt"`
@@ -76,6 +226,131 @@ func New() (*Config, error) {
 		return val
 	}
 
+	getEnvFloat := func(key string, def float64) float64 {
+		valStr := os.Getenv(key)
+		if valStr == "" {
+			fmt.Printf("using default key: %v: %v\n", key, def)
+			return def
+		}
+		val, err := strconv.ParseFloat(valStr, 64)
+		if err != nil {
+			fmt.Printf("using default key: %v: %v", key, def)
+			return def
+		}
+		return val
+	}
+
+	getEnvList := func(key string, def []string) []string {
+		valStr, ok := os.LookupEnv(key)
+		if !ok {
+			fmt.Printf("using default key: %v: %v\n", key, def)
+			return def
+		}
+		vals := []string{}
+		for _, part := range strings.Split(valStr, ",") {
+			if part = strings.ToUpper(strings.TrimSpace(part)); part != "" {
+				vals = append(vals, part)
+			}
+		}
+		return vals
+	}
+
+	getEnvInts := func(key string, def []int) []int {
+		valStr := os.Getenv(key)
+		if valStr == "" {
+			fmt.Printf("using default key: %v: %v\n", key, def)
+			return def
+		}
+		var vals []int
+		for _, part := range strings.Split(valStr, ",") {
+			val, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || val < 1 {
+				fmt.Printf("using default key: %v: %v", key, def)
+				return def
+			}
+			vals = append(vals, val)
+		}
+		return vals
+	}
+
+	// "distance=0.5,rating=0.2,..." missing keys keep the default
+	getEnvWeights := func(key string, def ScoreWeights) ScoreWeights {
+		valStr := os.Getenv(key)
+		if valStr == "" {
+			fmt.Printf("using default key: %v: %+v\n", key, def)
+			return def
+		}
+		w := def
+		for _, part := range strings.Split(valStr, ",") {
+			name, numStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+			num, err := strconv.ParseFloat(numStr, 64)
+			if !ok || err != nil || num < 0 {
+				fmt.Printf("using default key: %v: %+v", key, def)
+				return def
+			}
+			switch name {
+			case "distance":
+				w.Distance = num
+			case "rating":
+				w.Rating = num
+			case "acceptance":
+				w.Acceptance = num
+			case "completion":
+				w.Completion = num
+			case "cancellation":
+				w.Cancellation = num
+			}
+		}
+		return w
+	}
+
+	// "online_hours=12,driving_hours=10,break_minutes=480" missing keys keep the default
+	getEnvHoursLimits := func(key string, def HoursLimits) HoursLimits {
+		valStr := os.Getenv(key)
+		if valStr == "" {
+			fmt.Printf("using default key: %v: %+v\n", key, def)
+			return def
+		}
+		l := def
+		for _, part := range strings.Split(valStr, ",") {
+			name, numStr, ok := strings.Cut(strings.TrimSpace(part), "=")
+			num, err := strconv.ParseFloat(numStr, 64)
+			if !ok || err != nil || num <= 0 {
+				fmt.Printf("using default key: %v: %+v", key, def)
+				return def
+			}
+			switch name {
+			case "online_hours":
+				l.MaxOnlineHours = num
+			case "driving_hours":
+				l.MaxDrivingHours = num
+			case "break_minutes":
+				l.BreakMinutes = int(num)
+			}
+		}
+		return l
+	}
+
+	defaultStrategy := getEnv("DISPATCH_STRATEGY", "sequential")
+	defaultWeights := ScoreWeights{Distance: 0.5, Rating: 0.2, Acceptance: 0.1, Completion: 0.1, Cancellation: 0.1}
+	premiumWeights := ScoreWeights{Distance: 0.35, Rating: 0.35, Acceptance: 0.1, Completion: 0.1, Cancellation: 0.1}
+
+	// every jurisdiction listed in HOURS_JURISDICTIONS reads its own HOURS_LIMITS_<NAME>
+	defaultHoursLimits := map[string]HoursLimits{
+		"DEFAULT": getEnvHoursLimits("HOURS_LIMITS_DEFAULT", HoursLimits{MaxOnlineHours: 12, MaxDrivingHours: 10, BreakMinutes: 480}),
+	}
+	for _, name := range strings.Split(os.Getenv("HOURS_JURISDICTIONS"), ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" || name == "DEFAULT" {
+			continue
+		}
+		defaultHoursLimits[name] = getEnvHoursLimits("HOURS_LIMITS_"+name, defaultHoursLimits["DEFAULT"])
+	}
+
+	// hostname and pid tell replicas apart, in Docker the hostname alone does
+	hostname, _ := os.Hostname()
+	defaultInstanceID := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+
 	cnf := &Config{
 		DB: &DBconfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -107,6 +382,117 @@ func New() (*Config, error) {
 			PublicJwtSecret:  getEnv("PUBLIC_JWT", "default-public-secret"),
 			PrivateJwtSecret: getEnv("PRIVATE_JWT", "default-private-secret"),
 		},
+		Routing: &Routingconfig{
+			OSRMURL:      getEnv("ROUTER_OSRM_URL", ""),
+			Profile:      getEnv("ROUTER_PROFILE", "driving"),
+			TimeoutMs:    getEnvInt("ROUTER_TIMEOUT_MS", 2000),
+			DetourFactor: getEnvFloat("ROUTER_DETOUR_FACTOR", 1.3),
+			AvgSpeedKmh:  getEnvFloat("ROUTER_AVG_SPEED_KMH", 30),
+		},
+		Eta: &Etaconfig{
+			RefreshIntervalSec: getEnvInt("ETA_REFRESH_INTERVAL_SEC", 900),
+			CellSizeDeg:        getEnvFloat("ETA_CELL_SIZE_DEG", 0.01),
+			MinSamples:         getEnvInt("ETA_MIN_SAMPLES", 5),
+			LookbackDays:       getEnvInt("ETA_LOOKBACK_DAYS", 28),
+		},
+		Matching: &Matchingconfig{
+			Mode:          getEnv("MATCHING_MODE", "greedy"),
+			BatchWindowMs: getEnvInt("MATCHING_BATCH_WINDOW_MS", 2000),
+			BatchMinRides: getEnvInt("MATCHING_BATCH_MIN_RIDES", 2),
+			MaxRequeues:   getEnvInt("MATCHING_BATCH_MAX_REQUEUES", 3),
+		},
+		Dispatch: &Dispatchconfig{
+			Strategies: map[string]string{
+				"ECONOMY": getEnv("DISPATCH_STRATEGY_ECONOMY", defaultStrategy),
+				"PREMIUM": getEnv("DISPATCH_STRATEGY_PREMIUM", defaultStrategy),
+				"XL":      getEnv("DISPATCH_STRATEGY_XL", defaultStrategy),
+			},
+			OfferTimeoutSec: getEnvInt("DISPATCH_OFFER_TIMEOUT_SEC", 15),
+			BroadcastSize:   getEnvInt("DISPATCH_BROADCAST_SIZE", 3),
+			CascadeWaves:    getEnvInts("DISPATCH_CASCADE_WAVES", []int{1, 2, 4}),
+		},
+		Scoring: &Scoringconfig{
+			WindowDays:         getEnvInt("SCORE_WINDOW_DAYS", 30),
+			ShortWindowDays:    getEnvInt("SCORE_SHORT_WINDOW_DAYS", 7),
+			ShortWindowWeight:  getEnvFloat("SCORE_SHORT_WINDOW_WEIGHT", 0.5),
+			RefreshIntervalSec: getEnvInt("SCORE_REFRESH_INTERVAL_SEC", 600),
+			Weights: map[string]ScoreWeights{
+				"ECONOMY": getEnvWeights("SCORE_WEIGHTS_ECONOMY", defaultWeights),
+				"PREMIUM": getEnvWeights("SCORE_WEIGHTS_PREMIUM", premiumWeights),
+				"XL":      getEnvWeights("SCORE_WEIGHTS_XL", defaultWeights),
+			},
+		},
+		Index: &Indexconfig{
+			CellSizeDeg: getEnvFloat("DRIVER_INDEX_CELL_SIZE_DEG", 0.01),
+			ResyncSec:   getEnvInt("DRIVER_INDEX_RESYNC_SEC", 300),
+		},
+		Location: &Locationconfig{
+			MinIntervalSeconds: getEnvFloat("LOCATION_MIN_INTERVAL_SECONDS", 3),
+			MaxSpeedKmh:        getEnvFloat("LOCATION_MAX_SPEED_KMH", 200),
+			MaxAccuracyMeters:  getEnvFloat("LOCATION_MAX_ACCURACY_METERS", 100),
+			ProcessNoiseMps:    getEnvFloat("LOCATION_PROCESS_NOISE_MPS", 3),
+			OutlierResetAfter:  getEnvInt("LOCATION_OUTLIER_RESET_AFTER", 3),
+		},
+		Writer: &LocationWriterconfig{
+			BatchSize:        getEnvInt("LOCATION_WRITER_BATCH_SIZE", 500),
+			FlushIntervalMs:  getEnvInt("LOCATION_WRITER_FLUSH_INTERVAL_MS", 200),
+			QueueSize:        getEnvInt("LOCATION_WRITER_QUEUE_SIZE", 10000),
+			EnqueueTimeoutMs: getEnvInt("LOCATION_WRITER_ENQUEUE_TIMEOUT_MS", 2000),
+		},
+		Retention: &Retentionconfig{
+			RideDays:           getEnvInt("LOCATION_RETENTION_RIDE_DAYS", 90),
+			NonRideDays:        getEnvInt("LOCATION_RETENTION_NON_RIDE_DAYS", 7),
+			PartitionAheadDays: getEnvInt("LOCATION_PARTITION_AHEAD_DAYS", 3),
+			IntervalSec:        getEnvInt("LOCATION_RETENTION_INTERVAL_SEC", 3600),
+			CompactIntervalSec: getEnvInt("RIDE_TRACK_COMPACT_INTERVAL_SEC", 60),
+			SimplifyMeters:     getEnvFloat("RIDE_TRACK_SIMPLIFY_METERS", 5),
+		},
+		Arrival: &Arrivalconfig{
+			RadiusMeters: getEnvFloat("ARRIVAL_RADIUS_METERS", 50),
+			DwellSeconds: getEnvFloat("ARRIVAL_DWELL_SECONDS", 20),
+		},
+		Earnings: &Earningsconfig{
+			CommissionRate: getEnvFloat("DRIVER_COMMISSION_RATE", 0.2),
+			MaxTip:         getEnvFloat("MAX_TIP", 500),
+		},
+		Demand: &Demandconfig{
+			CellSizeDeg:    getEnvFloat("DEMAND_CELL_SIZE_DEG", 0.01),
+			WindowMinutes:  getEnvInt("DEMAND_WINDOW_MINUTES", 30),
+			RefreshSec:     getEnvInt("DEMAND_REFRESH_SEC", 60),
+			RadiusKm:       getEnvFloat("DEMAND_RADIUS_KM", 5),
+			MaxSuggestions: getEnvInt("DEMAND_MAX_SUGGESTIONS", 3),
+			MinShortage:    getEnvFloat("DEMAND_MIN_SHORTAGE", 1),
+		},
+		Destination: &Destinationconfig{
+			UsesPerDay:  getEnvInt("DESTINATION_MODE_USES_PER_DAY", 2),
+			MinProgress: getEnvFloat("DESTINATION_MODE_MIN_PROGRESS", 0.2),
+		},
+		Hours: &Hoursconfig{
+			Limits:            defaultHoursLimits,
+			WarnBeforeMinutes: getEnvInt("HOURS_WARN_BEFORE_MINUTES", 30),
+			CheckIntervalSec:  getEnvInt("HOURS_CHECK_INTERVAL_SEC", 60),
+		},
+		Documents: &Documentsconfig{
+			Dir:         getEnv("DOCUMENTS_DIR", "./data/documents"),
+			MaxUploadMB: getEnvInt("DOCUMENTS_MAX_UPLOAD_MB", 10),
+		},
+		Vehicles: &Vehiclesconfig{
+			OptIn: map[string][]string{
+				"ECONOMY": getEnvList("VEHICLE_OPT_IN_ECONOMY", []string{}),
+				"PREMIUM": getEnvList("VEHICLE_OPT_IN_PREMIUM", []string{"ECONOMY"}),
+				"XL":      getEnvList("VEHICLE_OPT_IN_XL", []string{"ECONOMY"}),
+			},
+		},
+		Resume: &Resumeconfig{
+			BufferSize: getEnvInt("WS_RESUME_BUFFER_SIZE", 200),
+			TTLSec:     getEnvInt("WS_RESUME_TTL_SEC", 120),
+		},
+		Cluster: &Clusterconfig{
+			InstanceID:   getEnv("INSTANCE_ID", defaultInstanceID),
+			HeartbeatSec: getEnvInt("CLUSTER_HEARTBEAT_SEC", 5),
+			TTLSec:       getEnvInt("CLUSTER_TTL_SEC", 20),
+			RevokeTTLSec: getEnvInt("SESSION_REVOKE_TTL_SEC", 680400),
+		},
 	}
 
 	return cnf, nil
@@ -12,6 +12,7 @@ import (
 	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
 	"ride-hail/internal/driver-location-service/core/ports/driver"
 	"ride-hail/internal/logger"
+	"ride-hail/internal/wscodec"
 
 	"github.com/gorilla/websocket"
 )
@@ -34,6 +35,7 @@ func NewWebSocketHandler(wsManager *ws.WebSocketManager, auth driver.IAuthSerive
 			CheckOrigin:     func(r *http.Request) bool { return true },
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
+			Subprotocols:    wscodec.Subprotocols,
 		},
 		auth: auth,
 		log:  log,
@@ -50,38 +52,49 @@ func (h *WebSocketHandler) HandleDriverWebSocket(w http.ResponseWriter, r *http.
 	}
 
 	fromDriver := make(chan []byte, 100)
-	toDriver := make(chan []byte, 100)
-	if err := h.wsManager.RegisterDriver(r.Context(), driverID, fromDriver, toDriver); err != nil {
+	toDriver := make(chan []byte, h.wsManager.OutboxSize())
+	connectionID, err := h.wsManager.RegisterDriver(r.Context(), driverID, fromDriver, toDriver)
+	if err != nil {
 		log.Error("Failed to register driver:", err, driverID)
 		http.Error(w, "Failed to register driver", http.StatusInternalServerError)
 		return
 	}
-	defer h.wsManager.UnregisterDriver(r.Context(), driverID)
+	defer h.wsManager.UnregisterDriver(r.Context(), connectionID)
 	log.Info("Driver registered:", driverID)
 	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
 	defer conn.Close()
+	codec := wscodec.ForSubprotocol(conn.Subprotocol())
 
-	h.wsManager.SetConnection(driverID, conn)
+	h.wsManager.SetConnection(connectionID, conn)
 
 	conn.SetPongHandler(func(string) error {
-		h.wsManager.UpdatePing(driverID)
+		h.wsManager.UpdatePing(connectionID)
 		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 		return nil
 	})
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
 
-	go h.handleIncomingMessages(ctx, driverID, conn, fromDriver)
-	go h.handleOutgoingMessages(ctx, driverID, conn, toDriver)
+	// the connection ends with either side, the session stays resumable
+	go func() {
+		defer cancel()
+		h.handleIncomingMessages(ctx, driverID, connectionID, conn, codec, fromDriver)
+	}()
+	go func() {
+		defer cancel()
+		h.handleOutgoingMessages(ctx, driverID, conn, codec, toDriver)
+	}()
 	go h.handlePing(ctx, conn)
-	log.Info("WebSocket connection established for driver:", driverID)
+	log.Info("WebSocket connection established for driver:", driverID, "subprotocol", codec.Subprotocol())
 	<-ctx.Done()
 }
 
-func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID string, conn *websocket.Conn, incoming chan<- []byte) {
+// handleIncomingMessages reads frames in the negotiated codec, past this point every message
+// is JSON
+func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID, connectionID string, conn *websocket.Conn, codec wscodec.Codec, incoming chan<- []byte) {
 	log := h.log.Action("handleIncomingMessages")
 	defer close(incoming)
 
@@ -111,18 +124,29 @@ func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID
 				return
 			}
 
-			if messageType != websocket.TextMessage {
+			if messageType != codec.FrameType() {
+				continue
+			}
+			if message, err = codec.ToJSON(message); err != nil {
+				h.sendError(conn, codec, "invalid_message", err.Error())
+				log.Warn("Undecodable message from driver:", driverID, err)
 				continue
 			}
 
 			if !authenticated {
-				if authenticated = h.handleAuthentication(driverID, message); authenticated {
-					h.wsManager.SetAuthenticated(driverID, true)
-					h.sendAuthSuccess(conn)
+				if authMsg, ok := h.handleAuthentication(driverID, message); ok {
+					// auth_success and the missed messages go out through the outgoing queue
+					if err := h.wsManager.Authenticate(connectionID, authMsg.ResumeToken, authMsg.LastSeq); err != nil {
+						log.Error("Failed to start driver session:", err, driverID)
+						h.sendAuthError(conn, codec, "Authentication failed")
+						conn.Close()
+						return
+					}
+					authenticated = true
 					log.Info("Driver authenticated successfully:", driverID)
 				} else {
 					log.Warn("Authentication failed for driver:", driverID)
-					h.sendAuthError(conn, "Authentication failed")
+					h.sendAuthError(conn, codec, "Authentication failed")
 					conn.Close()
 					return
 				}
@@ -130,7 +154,7 @@ func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID
 			}
 			var userMessageType string
 			if userMessageType, err = h.validateMessage(message); err != nil {
-				h.sendError(conn, "invalid_message", err.Error())
+				h.sendError(conn, codec, "invalid_message", err.Error())
 				log.Warn("Invalid message from driver:", driverID, err)
 				continue
 			}
@@ -138,7 +162,10 @@ func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID
 			switch userMessageType {
 			case websocketdto.MessageTypeRideResponse:
 				log.Info("Received ride response from driver:", driverID)
-				incoming <- message
+				var driverMessage dto.DriverMessage
+				driverMessage.DriverID = driverID
+				driverMessage.Message = message
+				h.wsManager.FanIn <- driverMessage
 			case websocketdto.MessageTypeLocationUpdate:
 				log.Info("Received location update:", driverID)
 				var driverMessage dto.DriverMessage
@@ -153,7 +180,7 @@ func (h *WebSocketHandler) handleIncomingMessages(ctx context.Context, driverID
 	}
 }
 
-func (h *WebSocketHandler) handleOutgoingMessages(ctx context.Context, driverID string, conn *websocket.Conn, outgoing <-chan []byte) {
+func (h *WebSocketHandler) handleOutgoingMessages(ctx context.Context, driverID string, conn *websocket.Conn, codec wscodec.Codec, outgoing <-chan []byte) {
 	log := h.log.Action("handleOutgoingMessages")
 	for {
 		select {
@@ -165,8 +192,13 @@ func (h *WebSocketHandler) handleOutgoingMessages(ctx context.Context, driverID
 				return
 			}
 
+			frame, err := codec.FromJSON(message)
+			if err != nil {
+				log.Error("Error encoding message for driver:", err, driverID)
+				continue
+			}
 			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			if err := conn.WriteMessage(codec.FrameType(), frame); err != nil {
 				log.Error("Error sending message to driver:", err, driverID)
 				return
 			}
@@ -192,34 +224,34 @@ func (h *WebSocketHandler) handlePing(ctx context.Context, conn *websocket.Conn)
 	}
 }
 
-func (h *WebSocketHandler) handleAuthentication(driverID string, message []byte) bool {
+func (h *WebSocketHandler) handleAuthentication(driverID string, message []byte) (websocketdto.AuthMessage, bool) {
 	log := h.log.Action("handleAuthentication")
 	var baseMsg websocketdto.WebSocketMessage
 	if err := json.Unmarshal(message, &baseMsg); err != nil {
 		log.Error("Failed to unmarshal authentication message:", err, driverID)
-		return false
+		return websocketdto.AuthMessage{}, false
 	}
 
 	if baseMsg.Type != websocketdto.MessageTypeAuth {
-		return false
+		return websocketdto.AuthMessage{}, false
 	}
 
 	var authMsg websocketdto.AuthMessage
 	if err := json.Unmarshal(message, &authMsg); err != nil {
 		log.Error("Failed to unmarshal auth message:", err, driverID)
-		return false
+		return websocketdto.AuthMessage{}, false
 	}
 
 	tokenDriverID, err := h.auth.ValidateDriverToken(authMsg.Token)
 	if err != nil {
 		log.Error("Token validation failed:", err, driverID)
-		return false
+		return websocketdto.AuthMessage{}, false
 	}
 	if tokenDriverID != driverID {
 		log.Warn("Driver ID mismatch in token:", driverID)
-		return false
+		return websocketdto.AuthMessage{}, false
 	}
-	return true
+	return authMsg, true
 }
 
 func (h *WebSocketHandler) validateMessage(message []byte) (string, error) {
@@ -267,15 +299,7 @@ func (h *WebSocketHandler) validateLocationUpdate(update websocketdto.LocationUp
 	return nil
 }
 
-func (h *WebSocketHandler) sendAuthSuccess(conn *websocket.Conn) {
-	successMsg := websocketdto.WebSocketMessage{
-		Type: "auth_success",
-	}
-	messageBytes, _ := json.Marshal(successMsg)
-	conn.WriteMessage(websocket.TextMessage, messageBytes)
-}
-
-func (h *WebSocketHandler) sendAuthError(conn *websocket.Conn, message string) {
+func (h *WebSocketHandler) sendAuthError(conn *websocket.Conn, codec wscodec.Codec, message string) {
 	errorMsg := websocketdto.ErrorMessage{
 		WebSocketMessage: websocketdto.WebSocketMessage{
 			Type: websocketdto.MessageTypeError,
@@ -283,11 +307,11 @@ func (h *WebSocketHandler) sendAuthError(conn *websocket.Conn, message string) {
 		ErrorCode:    "auth_failed",
 		ErrorMessage: message,
 	}
-	messageBytes, _ := json.Marshal(errorMsg)
-	conn.WriteMessage(websocket.TextMessage, messageBytes)
+	messageBytes, _ := codec.Marshal(errorMsg)
+	conn.WriteMessage(codec.FrameType(), messageBytes)
 }
 
-func (h *WebSocketHandler) sendError(conn *websocket.Conn, code, message string) {
+func (h *WebSocketHandler) sendError(conn *websocket.Conn, codec wscodec.Codec, code, message string) {
 	errorMsg := websocketdto.ErrorMessage{
 		WebSocketMessage: websocketdto.WebSocketMessage{
 			Type: websocketdto.MessageTypeError,
@@ -295,6 +319,6 @@ func (h *WebSocketHandler) sendError(conn *websocket.Conn, code, message string)
 		ErrorCode:    code,
 		ErrorMessage: message,
 	}
-	messageBytes, _ := json.Marshal(errorMsg)
-	conn.WriteMessage(websocket.TextMessage, messageBytes)
+	messageBytes, _ := codec.Marshal(errorMsg)
+	conn.WriteMessage(codec.FrameType(), messageBytes)
 }
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/driver-location-service/core/services"
+	"ride-hail/internal/logger"
+)
+
+type DemandHandler struct {
+	demandService driver.IDemandService
+	log           logger.Logger
+}
+
+func NewDemandHandler(demandService driver.IDemandService, log logger.Logger) *DemandHandler {
+	return &DemandHandler{
+		demandService: demandService,
+		log:           log,
+	}
+}
+
+func (dh *DemandHandler) GetDemandMap(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("GetDemandMap")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := dh.demandService.GetDemandMap(ctx, driverID)
+	if errors.Is(err, services.ErrDriverNotOnline) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to get demand map", err)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
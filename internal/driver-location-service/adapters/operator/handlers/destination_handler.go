@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/driver-location-service/core/services"
+	"ride-hail/internal/logger"
+)
+
+type DestinationHandler struct {
+	destinationService driver.IDestinationService
+	log                logger.Logger
+}
+
+func NewDestinationHandler(destinationService driver.IDestinationService, log logger.Logger) *DestinationHandler {
+	return &DestinationHandler{
+		destinationService: destinationService,
+		log:                log,
+	}
+}
+
+func (dh *DestinationHandler) SetDestination(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("SetDestination")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	var req dto.SetDestination
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := dh.destinationService.SetDestination(ctx, driverID, req)
+	if errors.Is(err, services.ErrInvalidDestination) {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	} else if errors.Is(err, db.ErrDestinationLimit) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to set destination", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func (dh *DestinationHandler) GetDestination(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("GetDestination")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := dh.destinationService.GetDestination(ctx, driverID)
+	if err != nil {
+		log.Error("Failed to get destination", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func (dh *DestinationHandler) ClearDestination(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("ClearDestination")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := dh.destinationService.ClearDestination(ctx, driverID)
+	if errors.Is(err, db.ErrDestinationNotSet) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to clear destination", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/driver-location-service/core/services"
+	"ride-hail/internal/logger"
+)
+
+type DocumentHandler struct {
+	documentService driver.IDocumentService
+	log             logger.Logger
+}
+
+func NewDocumentHandler(documentService driver.IDocumentService, log logger.Logger) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+		log:             log,
+	}
+}
+
+// UploadDocument takes a multipart form with document_type, expires_at and file
+func (dh *DocumentHandler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("UploadDocument")
+	ctx, cancel := context.WithTimeout(context.Background(), 4*WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	// room for the other form fields on top of the file
+	r.Body = http.MaxBytesReader(w, r.Body, dh.documentService.MaxUploadBytes()+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			JsonError(w, http.StatusRequestEntityTooLarge, services.ErrDocumentTooLarge)
+			return
+		}
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		JsonError(w, http.StatusBadRequest, fmt.Errorf("file is required: %v", err))
+		return
+	}
+	defer file.Close()
+
+	res, err := dh.documentService.Upload(ctx, driverID, r.FormValue("document_type"), r.FormValue("expires_at"), filepath.Base(header.Filename), file)
+	if errors.Is(err, services.ErrInvalidDocument) {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	} else if errors.Is(err, services.ErrUnsupportedDocument) {
+		JsonError(w, http.StatusUnsupportedMediaType, err)
+		return
+	} else if errors.Is(err, services.ErrDocumentTooLarge) {
+		JsonError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to upload document", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, res)
+}
+
+func (dh *DocumentHandler) GetDocuments(w http.ResponseWriter, r *http.Request) {
+	log := dh.log.Action("GetDocuments")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := dh.documentService.GetDocuments(ctx, driverID)
+	if errors.Is(err, db.ErrDriverNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to get documents", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
@@ -3,10 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"ride-hail/internal/driver-location-service/adapters/service/db"
 	"ride-hail/internal/driver-location-service/core/domain/dto"
 	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/driver-location-service/core/services"
 	"ride-hail/internal/logger"
 
 	"github.com/gorilla/websocket"
@@ -54,7 +58,13 @@ func (dh *DriverHandler) GoOnline(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Driver_id = driverID
 	res, err := dh.driverService.GoOnline(ctx, req)
-	if err != nil {
+	if errors.Is(err, services.ErrOnBreak) || errors.Is(err, services.ErrDriverNotVerified) || errors.Is(err, services.ErrDocumentsExpired) {
+		JsonError(w, http.StatusForbidden, err)
+		return
+	} else if errors.Is(err, db.ErrVehicleNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
 		JsonError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -80,7 +90,10 @@ func (dh *DriverHandler) GoOffline(w http.ResponseWriter, r *http.Request) {
 
 	driver_id := driverID
 	res, err := dh.driverService.GoOffline(ctx, driver_id)
-	if err != nil {
+	if errors.Is(err, services.ErrRideInProgress) || errors.Is(err, db.ErrNoOpenSession) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
 		JsonError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -110,7 +123,19 @@ func (dh *DriverHandler) UpdateLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	res, err := dh.driverService.UpdateLocation(ctx, req, driver_id)
-	if err != nil {
+	if errors.Is(err, db.ErrWriterOverloaded) || errors.Is(err, db.ErrWriterClosed) {
+		JsonError(w, http.StatusServiceUnavailable, err)
+		return
+	} else if errors.Is(err, db.ErrNoCurrentLocation) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if errors.Is(err, services.ErrLocationThrottled) {
+		JsonError(w, http.StatusTooManyRequests, err)
+		return
+	} else if errors.Is(err, services.ErrLocationRejected) {
+		JsonError(w, http.StatusUnprocessableEntity, err)
+		return
+	} else if err != nil {
 		JsonError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -140,6 +165,7 @@ func (dh *DriverHandler) StartRide(w http.ResponseWriter, r *http.Request) {
 		JsonError(w, http.StatusBadRequest, err)
 		return
 	}
+	req.Driver_location.Driver_id = driverID
 	res, err := dh.driverService.StartRide(ctx, req)
 	if err != nil {
 		JsonError(w, http.StatusInternalServerError, err)
@@ -150,18 +176,11 @@ func (dh *DriverHandler) StartRide(w http.ResponseWriter, r *http.Request) {
 }
 
 func (dh *DriverHandler) CompleteRide(w http.ResponseWriter, r *http.Request) {
-	log := dh.log.Action("Go Online")
 	ctx := context.Background()
 
-	// Checking Driver For Existance
 	driverID := r.PathValue("driver_id")
-	if ok, err := dh.driverService.CheckDriverById(ctx, driverID); err == nil && !ok {
-		log.Info("Driver not found")
-		http.Error(w, "Forbidden: driver mismatch", http.StatusForbidden)
-		return
-	} else if err != nil {
-		log.Error("Failed to check the driver: ", err)
-		http.Error(w, "Forbidden: driver mismatch", http.StatusForbidden)
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
 		return
 	}
 
@@ -171,11 +190,84 @@ func (dh *DriverHandler) CompleteRide(w http.ResponseWriter, r *http.Request) {
 		JsonError(w, http.StatusBadRequest, err)
 		return
 	}
-	res, err := dh.driverService.CompleteRide(ctx, req)
-	if err != nil {
+	res, err := dh.driverService.CompleteRide(ctx, driverID, req)
+	if errors.Is(err, db.ErrNoActiveRide) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, db.ErrRideNotInProgress) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
 		JsonError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	jsonResponse(w, http.StatusAccepted, res)
 }
+
+func (dh *DriverHandler) GetLocationStats(w http.ResponseWriter, r *http.Request) {
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, dh.driverService.GetLocationStats(r.Context(), driverID))
+}
+
+func (dh *DriverHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, map[string]any{
+		"location_writer": dh.driverService.GetLocationWriterMetrics(r.Context()),
+	})
+}
+
+func (dh *DriverHandler) Arrived(w http.ResponseWriter, r *http.Request) {
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := dh.driverService.MarkArrived(r.Context(), driverID)
+	if errors.Is(err, db.ErrRideNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		dh.log.Action("Arrived").Error("Failed to mark arrival", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func (dh *DriverHandler) CancelRide(w http.ResponseWriter, r *http.Request) {
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	req := dto.CancelRide{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	res, err := dh.driverService.CancelRide(r.Context(), driverID, req)
+	if errors.Is(err, db.ErrNoActiveRide) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, db.ErrRideNotCancellable) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		dh.log.Action("CancelRide").Error("Failed to cancel ride", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
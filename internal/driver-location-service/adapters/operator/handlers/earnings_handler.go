@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/logger"
+)
+
+const maxStatementDays = 366
+
+type EarningsHandler struct {
+	earningsService driver.IEarningsService
+	log             logger.Logger
+}
+
+func NewEarningsHandler(earningsService driver.IEarningsService, log logger.Logger) *EarningsHandler {
+	return &EarningsHandler{
+		earningsService: earningsService,
+		log:             log,
+	}
+}
+
+// GetEarnings serves GET /drivers/{driver_id}/earnings?period=day|week|session&from=&to=&format=json|csv,
+// from and to are dates, both included, the last 30 days by default
+func (eh *EarningsHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	log := eh.log.Action("GetEarnings")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	query := r.URL.Query()
+	period := query.Get("period")
+	if period == "" {
+		period = model.EarningsPeriodDay
+	}
+	if period != model.EarningsPeriodDay && period != model.EarningsPeriodWeek && period != model.EarningsPeriodSession {
+		JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid period, allowed day, week, session"))
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid format, allowed json, csv"))
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today
+	if s := query.Get("to"); s != "" {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid to, YYYY-MM-DD expected"))
+			return
+		}
+		to = d
+	}
+	from := to.AddDate(0, 0, -29)
+	if s := query.Get("from"); s != "" {
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid from, YYYY-MM-DD expected"))
+			return
+		}
+		from = d
+	}
+	if from.After(to) || to.Sub(from) > maxStatementDays*24*time.Hour {
+		JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid range, from must be before to and at most %d days apart", maxStatementDays))
+		return
+	}
+
+	// to is included, the statement runs up to the start of the next day
+	res, err := eh.earningsService.GetStatement(ctx, driverID, period, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		log.Error("Failed to get earnings statement", err)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if format == "csv" {
+		writeEarningsCSV(w, res)
+		return
+	}
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func writeEarningsCSV(w http.ResponseWriter, statement dto.EarningsStatement) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"earnings_%s_%s_%s.csv\"", statement.Period, statement.From, statement.To))
+	w.WriteHeader(http.StatusOK)
+
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"period", "session_id", "start", "end", "duration_hours", "rides", "gross_fare", "commission", "tips", "net"})
+	for _, line := range statement.Lines {
+		_ = out.Write([]string{
+			statement.Period,
+			line.SessionId,
+			line.Start,
+			line.End,
+			money(line.DurationHours),
+			strconv.Itoa(line.Rides),
+			money(line.GrossFare),
+			money(line.Commission),
+			money(line.Tips),
+			money(line.Net),
+		})
+	}
+	total := statement.Total
+	_ = out.Write([]string{"total", "", statement.From, statement.To, money(total.DurationHours), strconv.Itoa(total.Rides), money(total.GrossFare), money(total.Commission), money(total.Tips), money(total.Net)})
+	out.Flush()
+}
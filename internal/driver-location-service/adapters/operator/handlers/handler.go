@@ -7,13 +7,29 @@ import (
 )
 
 type Handlers struct {
-	DriverHandler    *DriverHandler
-	WebSocketHandler *WebSocketHandler
+	DriverHandler      *DriverHandler
+	WebSocketHandler   *WebSocketHandler
+	OfferHandler       *OfferHandler
+	ScoreHandler       *ScoreHandler
+	EarningsHandler    *EarningsHandler
+	DemandHandler      *DemandHandler
+	DestinationHandler *DestinationHandler
+	HoursHandler       *HoursHandler
+	DocumentHandler    *DocumentHandler
+	VehicleHandler     *VehicleHandler
 }
 
 func New(service *services.Service, log logger.Logger, wsManager *ws.WebSocketManager) *Handlers {
 	return &Handlers{
-		DriverHandler:    NewDriverHandler(service.DriverService, log),
-		WebSocketHandler: NewWebSocketHandler(wsManager, service.AuthService, log),
+		DriverHandler:      NewDriverHandler(service.DriverService, log),
+		WebSocketHandler:   NewWebSocketHandler(wsManager, service.AuthService, log),
+		OfferHandler:       NewOfferHandler(service.OfferService, log),
+		ScoreHandler:       NewScoreHandler(service.ScoreService, log),
+		EarningsHandler:    NewEarningsHandler(service.EarningsService, log),
+		DemandHandler:      NewDemandHandler(service.DemandService, log),
+		DestinationHandler: NewDestinationHandler(service.DestinationService, log),
+		HoursHandler:       NewHoursHandler(service.HoursService, log),
+		DocumentHandler:    NewDocumentHandler(service.DocumentService, log),
+		VehicleHandler:     NewVehicleHandler(service.VehicleService, log),
 	}
 }
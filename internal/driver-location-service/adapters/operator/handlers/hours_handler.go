@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/logger"
+)
+
+type HoursHandler struct {
+	hoursService driver.IHoursService
+	log          logger.Logger
+}
+
+func NewHoursHandler(hoursService driver.IHoursService, log logger.Logger) *HoursHandler {
+	return &HoursHandler{
+		hoursService: hoursService,
+		log:          log,
+	}
+}
+
+func (hh *HoursHandler) GetHours(w http.ResponseWriter, r *http.Request) {
+	log := hh.log.Action("GetHours")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := hh.hoursService.GetHours(ctx, driverID)
+	if errors.Is(err, db.ErrDriverNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to get driving hours", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/logger"
+)
+
+type OfferHandler struct {
+	offerService driver.IOfferService
+	log          logger.Logger
+}
+
+func NewOfferHandler(offerService driver.IOfferService, log logger.Logger) *OfferHandler {
+	return &OfferHandler{
+		offerService: offerService,
+		log:          log,
+	}
+}
+
+func (oh *OfferHandler) GetOfferStats(w http.ResponseWriter, r *http.Request) {
+	log := oh.log.Action("GetOfferStats")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d < 1 || d > 365 {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid days parameter, allowed [1, 365]"))
+			return
+		}
+		days = d
+	}
+
+	res, err := oh.offerService.GetOfferStats(ctx, driverID, days)
+	if err != nil {
+		log.Error("Failed to get offer stats", err)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
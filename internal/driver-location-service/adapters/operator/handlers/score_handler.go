@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/logger"
+)
+
+type ScoreHandler struct {
+	scoreService driver.IScoreService
+	log          logger.Logger
+}
+
+func NewScoreHandler(scoreService driver.IScoreService, log logger.Logger) *ScoreHandler {
+	return &ScoreHandler{
+		scoreService: scoreService,
+		log:          log,
+	}
+}
+
+func (sh *ScoreHandler) GetDriverScore(w http.ResponseWriter, r *http.Request) {
+	log := sh.log.Action("GetDriverScore")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := sh.scoreService.GetDriverScore(ctx, driverID)
+	if errors.Is(err, db.ErrDriverNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to get driver score", err)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/ports/driver"
+	"ride-hail/internal/driver-location-service/core/services"
+	"ride-hail/internal/logger"
+)
+
+type VehicleHandler struct {
+	vehicleService driver.IVehicleService
+	log            logger.Logger
+}
+
+func NewVehicleHandler(vehicleService driver.IVehicleService, log logger.Logger) *VehicleHandler {
+	return &VehicleHandler{
+		vehicleService: vehicleService,
+		log:            log,
+	}
+}
+
+func (vh *VehicleHandler) CreateVehicle(w http.ResponseWriter, r *http.Request) {
+	log := vh.log.Action("CreateVehicle")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	var req dto.VehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := vh.vehicleService.CreateVehicle(ctx, driverID, req)
+	if errors.Is(err, services.ErrInvalidVehicle) {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	} else if errors.Is(err, db.ErrPlateTaken) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to create vehicle", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, res)
+}
+
+func (vh *VehicleHandler) ListVehicles(w http.ResponseWriter, r *http.Request) {
+	log := vh.log.Action("ListVehicles")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	res, err := vh.vehicleService.ListVehicles(ctx, driverID)
+	if err != nil {
+		log.Error("Failed to list vehicles", err, "driver_id", driverID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func (vh *VehicleHandler) UpdateVehicle(w http.ResponseWriter, r *http.Request) {
+	log := vh.log.Action("UpdateVehicle")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	var req dto.VehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	vehicleID := r.PathValue("vehicle_id")
+	res, err := vh.vehicleService.UpdateVehicle(ctx, driverID, vehicleID, req)
+	if errors.Is(err, services.ErrInvalidVehicle) {
+		JsonError(w, http.StatusBadRequest, err)
+		return
+	} else if errors.Is(err, db.ErrVehicleNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, db.ErrPlateTaken) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to update vehicle", err, "driver_id", driverID, "vehicle_id", vehicleID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, res)
+}
+
+func (vh *VehicleHandler) RemoveVehicle(w http.ResponseWriter, r *http.Request) {
+	log := vh.log.Action("RemoveVehicle")
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+	defer cancel()
+
+	driverID := r.PathValue("driver_id")
+	if r.Header.Get("X-UserId") != driverID {
+		JsonError(w, http.StatusForbidden, fmt.Errorf("Forbidden: driver mismatch"))
+		return
+	}
+
+	vehicleID := r.PathValue("vehicle_id")
+	err := vh.vehicleService.RemoveVehicle(ctx, driverID, vehicleID)
+	if errors.Is(err, db.ErrVehicleNotFound) {
+		JsonError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, db.ErrVehicleInUse) {
+		JsonError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		log.Error("Failed to remove vehicle", err, "driver_id", driverID, "vehicle_id", vehicleID)
+		JsonError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]any{
+		"vehicle_id": vehicleID,
+		"message":    "Vehicle removed",
+	})
+}
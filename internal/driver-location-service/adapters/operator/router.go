@@ -16,8 +16,26 @@ func Router(handlers *handlers.Handlers, cfg *config.Config) http.Handler {
 	mux.Handle("/drivers/{driver_id}/online", mdl.SessionHandler(func() http.HandlerFunc { return handlers.DriverHandler.GoOnline }()))
 	mux.Handle("/drivers/{driver_id}/offline", mdl.SessionHandler(func() http.HandlerFunc { return handlers.DriverHandler.GoOffline }()))
 	mux.Handle("/drivers/{driver_id}/location", mdl.SessionHandler(func() http.HandlerFunc { return handlers.DriverHandler.UpdateLocation }()))
+	mux.Handle("GET /drivers/{driver_id}/location/stats", mdl.SessionHandler(http.HandlerFunc(handlers.DriverHandler.GetLocationStats)))
+	mux.Handle("POST /drivers/{driver_id}/arrived", mdl.SessionHandler(http.HandlerFunc(handlers.DriverHandler.Arrived)))
+	mux.Handle("POST /drivers/{driver_id}/cancel", mdl.SessionHandler(http.HandlerFunc(handlers.DriverHandler.CancelRide)))
 	mux.Handle("/drivers/{driver_id}/start", mdl.SessionHandler(func() http.HandlerFunc { return handlers.DriverHandler.StartRide }()))
 	mux.Handle("/drivers/{driver_id}/complete", mdl.SessionHandler(func() http.HandlerFunc { return handlers.DriverHandler.CompleteRide }()))
+	mux.Handle("GET /drivers/{driver_id}/offers/stats", mdl.SessionHandler(http.HandlerFunc(handlers.OfferHandler.GetOfferStats)))
+	mux.Handle("GET /drivers/{driver_id}/score", mdl.SessionHandler(http.HandlerFunc(handlers.ScoreHandler.GetDriverScore)))
+	mux.Handle("GET /drivers/{driver_id}/earnings", mdl.SessionHandler(http.HandlerFunc(handlers.EarningsHandler.GetEarnings)))
+	mux.Handle("GET /drivers/{driver_id}/demand", mdl.SessionHandler(http.HandlerFunc(handlers.DemandHandler.GetDemandMap)))
+	mux.Handle("PUT /drivers/{driver_id}/destination", mdl.SessionHandler(http.HandlerFunc(handlers.DestinationHandler.SetDestination)))
+	mux.Handle("GET /drivers/{driver_id}/destination", mdl.SessionHandler(http.HandlerFunc(handlers.DestinationHandler.GetDestination)))
+	mux.Handle("DELETE /drivers/{driver_id}/destination", mdl.SessionHandler(http.HandlerFunc(handlers.DestinationHandler.ClearDestination)))
+	mux.Handle("GET /drivers/{driver_id}/hours", mdl.SessionHandler(http.HandlerFunc(handlers.HoursHandler.GetHours)))
+	mux.Handle("POST /drivers/{driver_id}/documents", mdl.SessionHandler(http.HandlerFunc(handlers.DocumentHandler.UploadDocument)))
+	mux.Handle("GET /drivers/{driver_id}/documents", mdl.SessionHandler(http.HandlerFunc(handlers.DocumentHandler.GetDocuments)))
+	mux.Handle("POST /drivers/{driver_id}/vehicles", mdl.SessionHandler(http.HandlerFunc(handlers.VehicleHandler.CreateVehicle)))
+	mux.Handle("GET /drivers/{driver_id}/vehicles", mdl.SessionHandler(http.HandlerFunc(handlers.VehicleHandler.ListVehicles)))
+	mux.Handle("PUT /drivers/{driver_id}/vehicles/{vehicle_id}", mdl.SessionHandler(http.HandlerFunc(handlers.VehicleHandler.UpdateVehicle)))
+	mux.Handle("DELETE /drivers/{driver_id}/vehicles/{vehicle_id}", mdl.SessionHandler(http.HandlerFunc(handlers.VehicleHandler.RemoveVehicle)))
+	mux.Handle("GET /metrics", http.HandlerFunc(handlers.DriverHandler.GetMetrics))
 
 	return mux
 }
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const wsService = "driver-location-service"
+
+type ConnectionRepository struct {
+	db *DataBase
+}
+
+func NewConnectionRepository(db *DataBase) *ConnectionRepository {
+	return &ConnectionRepository{db: db}
+}
+
+// Heartbeat refreshes the replica and replaces its rows with the sessions it holds.
+// Replicas silent for longer than ttl are removed with their rows. A driver who moved to
+// another replica in the meantime stays there.
+func (cr *ConnectionRepository) Heartbeat(ctx context.Context, instance_id string, sessions []model.DriverConnection, ttl time.Duration) error {
+	tx, err := cr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	InstanceQuery := `
+		INSERT INTO ws_instances(instance_id, service)
+		VALUES ($1, $2)
+		ON CONFLICT (instance_id) DO UPDATE SET heartbeat_at = NOW();
+	`
+	if _, err := tx.Exec(ctx, InstanceQuery, instance_id, wsService); err != nil {
+		return err
+	}
+
+	GoneQuery := `
+		DELETE FROM ws_instances
+		WHERE service = $1 AND heartbeat_at < NOW() - make_interval(secs => $2);
+	`
+	if _, err := tx.Exec(ctx, GoneQuery, wsService, ttl.Seconds()); err != nil {
+		return err
+	}
+
+	driverIds := make([]string, 0, len(sessions))
+	connected := make([]bool, 0, len(sessions))
+	for _, session := range sessions {
+		driverIds = append(driverIds, session.DriverId)
+		connected = append(connected, session.Connected)
+	}
+
+	StaleQuery := `
+		DELETE FROM driver_connections
+		WHERE instance_id = $1 AND NOT (driver_id::text = ANY($2::text[]));
+	`
+	if _, err := tx.Exec(ctx, StaleQuery, instance_id, driverIds); err != nil {
+		return err
+	}
+
+	UpsertQuery := `
+		INSERT INTO driver_connections(driver_id, instance_id, connected)
+		SELECT s.driver_id::uuid, $1, s.connected
+		FROM unnest($2::text[], $3::bool[]) AS s(driver_id, connected)
+		ON CONFLICT (driver_id) DO UPDATE
+		SET connected = EXCLUDED.connected, updated_at = NOW()
+		WHERE driver_connections.instance_id = EXCLUDED.instance_id;
+	`
+	if _, err := tx.Exec(ctx, UpsertQuery, instance_id, driverIds, connected); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Register moves the session of the driver to the replica and returns the replica that
+// held it before, empty when there was none
+func (cr *ConnectionRepository) Register(ctx context.Context, instance_id, driver_id string) (string, error) {
+	Query := `
+		WITH previous AS (
+			SELECT instance_id FROM driver_connections WHERE driver_id = $2
+		)
+		INSERT INTO driver_connections(driver_id, instance_id, connected)
+		VALUES ($2, $1, true)
+		ON CONFLICT (driver_id) DO UPDATE
+		SET instance_id = EXCLUDED.instance_id, connected = true, updated_at = NOW()
+		RETURNING COALESCE((SELECT instance_id FROM previous), '');
+	`
+	var previous string
+	err := cr.db.GetConn().QueryRow(ctx, Query, instance_id, driver_id).Scan(&previous)
+	return previous, err
+}
+
+// SetConnected marks the session as connected or waiting to be resumed, only on the
+// replica that holds it
+func (cr *ConnectionRepository) SetConnected(ctx context.Context, instance_id, driver_id string, connected bool) error {
+	Query := `
+		UPDATE driver_connections
+		SET connected = $3, updated_at = NOW()
+		WHERE driver_id = $2 AND instance_id = $1;
+	`
+	_, err := cr.db.GetConn().Exec(ctx, Query, instance_id, driver_id, connected)
+	return err
+}
+
+func (cr *ConnectionRepository) Remove(ctx context.Context, instance_id, driver_id string) error {
+	Query := `
+		DELETE FROM driver_connections
+		WHERE driver_id = $2 AND instance_id = $1;
+	`
+	_, err := cr.db.GetConn().Exec(ctx, Query, instance_id, driver_id)
+	return err
+}
+
+// GetConnections returns the sessions held by replicas alive within ttl
+func (cr *ConnectionRepository) GetConnections(ctx context.Context, ttl time.Duration) ([]model.DriverConnection, error) {
+	Query := `
+		SELECT dc.driver_id, dc.instance_id, dc.connected
+		FROM driver_connections dc
+		JOIN ws_instances i ON i.instance_id = dc.instance_id
+		WHERE i.heartbeat_at >= NOW() - make_interval(secs => $1);
+	`
+	rows, err := cr.db.GetConn().Query(ctx, Query, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var connections []model.DriverConnection
+	for rows.Next() {
+		var connection model.DriverConnection
+		if err := rows.Scan(&connection.DriverId, &connection.InstanceId, &connection.Connected); err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+	return connections, rows.Err()
+}
+
+func (cr *ConnectionRepository) GetConnection(ctx context.Context, driver_id string, ttl time.Duration) (model.DriverConnection, error) {
+	Query := `
+		SELECT dc.driver_id, dc.instance_id, dc.connected
+		FROM driver_connections dc
+		JOIN ws_instances i ON i.instance_id = dc.instance_id
+		WHERE dc.driver_id = $1 AND i.heartbeat_at >= NOW() - make_interval(secs => $2);
+	`
+	var connection model.DriverConnection
+	err := cr.db.GetConn().QueryRow(ctx, Query, driver_id, ttl.Seconds()).
+		Scan(&connection.DriverId, &connection.InstanceId, &connection.Connected)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.DriverConnection{}, ErrConnectionNotFound
+	}
+	return connection, err
+}
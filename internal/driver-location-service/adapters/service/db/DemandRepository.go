@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+)
+
+type DemandRepository struct {
+	db *DataBase
+}
+
+func NewDemandRepository(db *DataBase) *DemandRepository {
+	return &DemandRepository{db: db}
+}
+
+// GetDemand counts open requests, recent requests and completed pickups per grid cell
+func (dr *DemandRepository) GetDemand(ctx context.Context, since time.Time, cellSizeDeg float64) ([]model.DemandCell, error) {
+	Query := `
+		SELECT floor(pc.latitude / $2)::int, floor(pc.longitude / $2)::int,
+			COUNT(*) FILTER (WHERE r.status = 'REQUESTED'),
+			COUNT(*) FILTER (WHERE r.status <> 'REQUESTED' AND r.requested_at >= $1),
+			COUNT(*) FILTER (WHERE r.status = 'COMPLETED' AND r.requested_at < $1 AND r.completed_at >= $1)
+		FROM rides r
+		JOIN coordinates pc ON pc.coord_id = r.pickup_coord_id
+		WHERE r.status = 'REQUESTED'
+			OR r.requested_at >= $1
+			OR (r.status = 'COMPLETED' AND r.completed_at >= $1)
+		GROUP BY 1, 2;
+	`
+	rows, err := dr.db.GetConn().Query(ctx, Query, since, cellSizeDeg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []model.DemandCell
+	for rows.Next() {
+		var cell model.DemandCell
+		if err := rows.Scan(&cell.Lat, &cell.Lng, &cell.OpenRequests, &cell.RecentRequests, &cell.CompletedPickups); err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+	}
+	return cells, rows.Err()
+}
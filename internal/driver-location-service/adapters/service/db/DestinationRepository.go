@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+)
+
+type DestinationRepository struct {
+	db *DataBase
+}
+
+func NewDestinationRepository(db *DataBase) *DestinationRepository {
+	return &DestinationRepository{db: db}
+}
+
+// Activate replaces the active destination of the driver, unless the driver has used
+// destination mode usesPerDay times today already
+func (dr *DestinationRepository) Activate(ctx context.Context, destination model.DriverDestination, usesPerDay int) (model.DriverDestination, error) {
+	tx, err := dr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return model.DriverDestination{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// serializes activations of the same driver
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM drivers WHERE driver_id = $1 FOR UPDATE;`, destination.DriverId); err != nil {
+		return model.DriverDestination{}, err
+	}
+
+	var used int
+	UsesQuery := `
+		SELECT COUNT(*) FROM driver_destinations
+		WHERE driver_id = $1 AND created_at >= date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+	`
+	if err := tx.QueryRow(ctx, UsesQuery, destination.DriverId).Scan(&used); err != nil {
+		return model.DriverDestination{}, err
+	}
+	if used >= usesPerDay {
+		return model.DriverDestination{}, ErrDestinationLimit
+	}
+
+	DeactivateQuery := `
+		UPDATE driver_destinations
+		SET is_active = false, deactivated_at = NOW()
+		WHERE driver_id = $1 AND is_active;
+	`
+	if _, err := tx.Exec(ctx, DeactivateQuery, destination.DriverId); err != nil {
+		return model.DriverDestination{}, err
+	}
+
+	InsertQuery := `
+		INSERT INTO driver_destinations(driver_id, latitude, longitude, address)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING destination_id, created_at;
+	`
+	err = tx.QueryRow(ctx, InsertQuery, destination.DriverId, destination.Latitude, destination.Longitude, destination.Address).
+		Scan(&destination.DestinationId, &destination.CreatedAt)
+	if err != nil {
+		return model.DriverDestination{}, err
+	}
+	return destination, tx.Commit(ctx)
+}
+
+func (dr *DestinationRepository) Deactivate(ctx context.Context, driver_id string) (bool, error) {
+	Query := `
+		UPDATE driver_destinations
+		SET is_active = false, deactivated_at = NOW()
+		WHERE driver_id = $1 AND is_active;
+	`
+	tag, err := dr.db.GetConn().Exec(ctx, Query, driver_id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (dr *DestinationRepository) GetActive(ctx context.Context, driver_id string) (model.DriverDestination, error) {
+	destinations, err := dr.GetActiveFor(ctx, []string{driver_id})
+	if err != nil {
+		return model.DriverDestination{}, err
+	}
+	destination, ok := destinations[driver_id]
+	if !ok {
+		return model.DriverDestination{}, ErrDestinationNotSet
+	}
+	return destination, nil
+}
+
+// GetActiveFor returns the active destinations of the drivers that have one
+func (dr *DestinationRepository) GetActiveFor(ctx context.Context, driver_ids []string) (map[string]model.DriverDestination, error) {
+	Query := `
+		SELECT destination_id, driver_id, latitude::float, longitude::float, COALESCE(address, ''), created_at
+		FROM driver_destinations
+		WHERE driver_id = ANY($1) AND is_active;
+	`
+	rows, err := dr.db.GetConn().Query(ctx, Query, driver_ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	destinations := make(map[string]model.DriverDestination)
+	for rows.Next() {
+		var d model.DriverDestination
+		if err := rows.Scan(&d.DestinationId, &d.DriverId, &d.Latitude, &d.Longitude, &d.Address, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		destinations[d.DriverId] = d
+	}
+	return destinations, rows.Err()
+}
+
+func (dr *DestinationRepository) UsesToday(ctx context.Context, driver_id string) (int, error) {
+	Query := `
+		SELECT COUNT(*) FROM driver_destinations
+		WHERE driver_id = $1 AND created_at >= date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
+	`
+	var used int
+	err := dr.db.GetConn().QueryRow(ctx, Query, driver_id).Scan(&used)
+	return used, err
+}
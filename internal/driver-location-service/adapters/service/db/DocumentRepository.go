@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type DocumentRepository struct {
+	db *DataBase
+}
+
+func NewDocumentRepository(db *DataBase) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+func (dr *DocumentRepository) Create(ctx context.Context, document model.DriverDocument) (model.DriverDocument, error) {
+	Query := `
+		INSERT INTO driver_documents(driver_id, document_type, file_key, file_name, content_type, size_bytes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING document_id, status, uploaded_at;
+	`
+	err := dr.db.GetConn().QueryRow(ctx, Query,
+		document.DriverId,
+		document.DocumentType,
+		document.FileKey,
+		document.FileName,
+		document.ContentType,
+		document.SizeBytes,
+		document.ExpiresAt,
+	).Scan(&document.DocumentId, &document.Status, &document.UploadedAt)
+	if err != nil {
+		return model.DriverDocument{}, err
+	}
+	return document, nil
+}
+
+// List returns the documents of the driver, newest first
+func (dr *DocumentRepository) List(ctx context.Context, driver_id string) ([]model.DriverDocument, error) {
+	Query := `
+		SELECT document_id, driver_id, document_type, status, file_key, file_name, content_type, size_bytes,
+			expires_at, uploaded_at, reviewed_at, COALESCE(review_note, '')
+		FROM driver_documents
+		WHERE driver_id = $1
+		ORDER BY uploaded_at DESC;
+	`
+	rows, err := dr.db.GetConn().Query(ctx, Query, driver_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []model.DriverDocument
+	for rows.Next() {
+		var d model.DriverDocument
+		if err := rows.Scan(&d.DocumentId, &d.DriverId, &d.DocumentType, &d.Status, &d.FileKey, &d.FileName, &d.ContentType, &d.SizeBytes,
+			&d.ExpiresAt, &d.UploadedAt, &d.ReviewedAt, &d.ReviewNote); err != nil {
+			return nil, err
+		}
+		documents = append(documents, d)
+	}
+	return documents, rows.Err()
+}
+
+func (dr *DocumentRepository) IsVerified(ctx context.Context, driver_id string) (bool, error) {
+	var verified bool
+	err := dr.db.GetConn().QueryRow(ctx, `SELECT COALESCE(is_verified, false) FROM drivers WHERE driver_id = $1;`, driver_id).Scan(&verified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, ErrDriverNotFound
+	}
+	return verified, err
+}
+
+// GetCleared returns the drivers among the ids that are verified and have an approved,
+// unexpired document of every required type
+func (dr *DocumentRepository) GetCleared(ctx context.Context, driver_ids []string, required []string) (map[string]bool, error) {
+	Query := `
+		SELECT d.driver_id
+		FROM drivers d
+		WHERE d.driver_id = ANY($1) AND d.is_verified
+			AND (
+				SELECT COUNT(DISTINCT dd.document_type)
+				FROM driver_documents dd
+				WHERE dd.driver_id = d.driver_id AND dd.status = 'APPROVED'
+					AND dd.expires_at >= CURRENT_DATE AND dd.document_type = ANY($2)
+			) = cardinality($2::text[]);
+	`
+	rows, err := dr.db.GetConn().Query(ctx, Query, driver_ids, required)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cleared := make(map[string]bool)
+	for rows.Next() {
+		var driver_id string
+		if err := rows.Scan(&driver_id); err != nil {
+			return nil, err
+		}
+		cleared[driver_id] = true
+	}
+	return cleared, rows.Err()
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -36,14 +37,23 @@ func (dr *DriverRepository) GoOnline(ctx context.Context, coord model.DriverCoor
 	if err != nil {
 		return "", err
 	}
+	// a session left open by a crash or a lost offline request ends here
+	CloseQuery := `
+		UPDATE driver_sessions
+		SET ended_at = NOW()
+		WHERE driver_id = $1 AND ended_at IS NULL;
+	`
+	if _, err := dr.db.GetConn().Exec(ctx, CloseQuery, coord.Driver_id); err != nil {
+		return "", err
+	}
 	CreateQuery := `
-		INSERT INTO driver_sessions(driver_id)
-		VALUES ($1)
+		INSERT INTO driver_sessions(driver_id, vehicle_id)
+		VALUES ($1, NULLIF($2, '')::uuid)
 		RETURNING driver_session_id;
 	`
 
 	var session_id string
-	dr.db.GetConn().QueryRow(ctx, CreateQuery, coord.Driver_id).Scan(&session_id)
+	dr.db.GetConn().QueryRow(ctx, CreateQuery, coord.Driver_id, coord.Vehicle_id).Scan(&session_id)
 	return session_id, err
 }
 
@@ -51,9 +61,11 @@ func (dr *DriverRepository) GoOffline(ctx context.Context, driver_id string) (mo
 	var results model.DriverOfflineResponse
 	// Getting the summaries
 	SelectQuery := `
-		SELECT driver_session_id, extract(EPOCH from (NOW() - started_at))/3600.0, total_rides, total_earnings
+		SELECT driver_session_id, extract(EPOCH from (NOW() - started_at))/3600.0, COALESCE(total_rides, 0), COALESCE(total_earnings, 0)::float
 		FROM driver_sessions
-		WHERE driver_id = $1;
+		WHERE driver_id = $1 AND ended_at IS NULL
+		ORDER BY started_at DESC
+		LIMIT 1;
 	`
 	err := dr.db.GetConn().QueryRow(ctx, SelectQuery, driver_id).Scan(
 		&results.Session_id,
@@ -61,16 +73,18 @@ func (dr *DriverRepository) GoOffline(ctx context.Context, driver_id string) (mo
 		&results.Session_summary.Rides_completed,
 		&results.Session_summary.Earnings,
 	)
-	if err != nil {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.DriverOfflineResponse{}, ErrNoOpenSession
+	} else if err != nil {
 		return model.DriverOfflineResponse{}, err
 	}
 	// Update Session ended_at time
 	UpdateQuery := `
 		UPDATE driver_sessions
 		SET ended_at = NOW()
-		WHERE driver_id = $1;
+		WHERE driver_session_id = $1;
 	`
-	_, err = dr.db.GetConn().Exec(ctx, UpdateQuery, driver_id)
+	_, err = dr.db.GetConn().Exec(ctx, UpdateQuery, results.Session_id)
 	if err != nil {
 		return model.DriverOfflineResponse{}, err
 	}
@@ -84,44 +98,12 @@ func (dr *DriverRepository) GoOffline(ctx context.Context, driver_id string) (mo
 	return results, err
 }
 
-func (dr *DriverRepository) UpdateLocation(ctx context.Context, driver_id string, newLocation model.NewLocation) (model.NewLocationResponse, error) {
-	NewLocationQuery := `
-		INSERT INTO location_history(coord_id, driver_id, latitude, longitude, accuracy_meters, speed_kmh, heading_degrees, ride_id)
-		VALUES (
-			(SELECT coord_id FROM coordinates WHERE entity_id = $1),
-			$1,
-			$2,
-			$3,
-			$4,
-			$5,
-			$6,
-			(SELECT ride_id FROM rides WHERE driver_id = $1 AND status not in ('CANCELLED', 'COMPLETED'))
-		)
-	`
-	_, err := dr.db.GetConn().Exec(ctx, NewLocationQuery, driver_id, newLocation.Latitude, newLocation.Longitude, newLocation.Accuracy_meters, newLocation.Speed_kmh, newLocation.Heading_Degrees)
-	if err != nil {
-		return model.NewLocationResponse{}, err
-	}
-	var response model.NewLocationResponse
-	CoordinatesQuery := `
-		UPDATE coordinates
-		SET latitude = $1, 
-			longitude = $2,
-			updated_at = NOW()
-		WHERE entity_id = $3
-		RETURNING coord_id, updated_at;
-	`
-	err = dr.db.GetConn().QueryRow(ctx, CoordinatesQuery, newLocation.Latitude, newLocation.Longitude, driver_id).Scan(&response.Coordinate_id, &response.Updated_at)
-	if err != nil {
-		return model.NewLocationResponse{}, err
-	}
-	return response, nil
-}
-
 func (dr *DriverRepository) StartRide(ctx context.Context, requestData model.StartRide) (model.StartRideResponse, error) {
 	UpdateRideStatusQuery := `
 		UPDATE rides
-		SET status = 'IN_PROGRESS'
+		SET status = 'IN_PROGRESS',
+			started_at = NOW(),
+			updated_at = NOW()
 		WHERE ride_id = $1;
 	`
 	_, err := dr.db.GetConn().Exec(ctx, UpdateRideStatusQuery, requestData.Ride_id)
@@ -145,18 +127,34 @@ func (dr *DriverRepository) StartRide(ctx context.Context, requestData model.Sta
 	return response, nil
 }
 
-func (dr *DriverRepository) CompleteRide(ctx context.Context, requestData model.RideCompleteForm) (model.RideCompleteResponse, error) {
+// CompleteRide completes the ride in progress of the driver and books its earnings in the
+// same transaction. A ride the driver completed already is answered again as it was, without
+// touching the ride, the destination or the driver, so a retried request is harmless.
+func (dr *DriverRepository) CompleteRide(ctx context.Context, requestData model.RideCompleteForm, commissionRate float64) (model.RideCompleteResponse, error) {
 	var response model.RideCompleteResponse
 	response.Status = "AVAILABLE"
 	response.Ride_id = requestData.Ride_id
 	response.Message = "Ride completed successfully"
 
+	tx, err := dr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return model.RideCompleteResponse{}, err
+	}
+	defer tx.Rollback(ctx)
+
 	RidesQuery := `
 		UPDATE rides
-		SET status = 'COMPLETED'
-		WHERE ride_id = $1;
+		SET status = 'COMPLETED',
+			completed_at = NOW(),
+			updated_at = NOW()
+		WHERE ride_id = $1 AND driver_id = $2 AND status = 'IN_PROGRESS'
+		RETURNING completed_at;
 	`
-	_, err := dr.db.GetConn().Exec(ctx, RidesQuery, requestData.Ride_id)
+	var completedAt time.Time
+	err = tx.QueryRow(ctx, RidesQuery, requestData.Ride_id, requestData.Driver_id).Scan(&completedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dr.completedRide(ctx, tx, response, requestData, commissionRate)
+	}
 	if err != nil {
 		return model.RideCompleteResponse{}, err
 	}
@@ -170,8 +168,7 @@ func (dr *DriverRepository) CompleteRide(ctx context.Context, requestData model.
 		FROM rides
 		WHERE coordinates.coord_id = rides.destination_coord_id AND rides.ride_id = $5;
 	`
-
-	_, err = dr.db.GetConn().Exec(ctx, CoordinatesQuery, requestData.ActualDistancekm, requestData.ActualDurationm, requestData.FinalLocation.Latitude, requestData.FinalLocation.Longitude, requestData.Ride_id)
+	_, err = tx.Exec(ctx, CoordinatesQuery, requestData.ActualDistancekm, requestData.ActualDurationm, requestData.FinalLocation.Latitude, requestData.FinalLocation.Longitude, requestData.Ride_id)
 	if err != nil {
 		return model.RideCompleteResponse{}, err
 	}
@@ -179,30 +176,74 @@ func (dr *DriverRepository) CompleteRide(ctx context.Context, requestData model.
 	UpdateDriverStatusQuery := `
 		UPDATE drivers
 		SET status = 'AVAILABLE'
-		FROM rides
-		WHERE drivers.driver_id = rides.driver_id AND rides.ride_id = $1;
+		WHERE driver_id = $1;
 	`
-	_, err = dr.db.GetConn().Exec(ctx, UpdateDriverStatusQuery, requestData.Ride_id)
+	_, err = tx.Exec(ctx, UpdateDriverStatusQuery, requestData.Driver_id)
 	if err != nil {
 		return model.RideCompleteResponse{}, err
 	}
 
-	DriverEarningsQuery := `
-		SELECT final_fare FROM rides WHERE ride_id = $1;
+	earning, _, err := recordRide(ctx, tx, requestData.Ride_id, commissionRate)
+	if err != nil {
+		return model.RideCompleteResponse{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.RideCompleteResponse{}, err
+	}
+
+	response.Completed = true
+	response.DriverEarning = earning.Net
+	response.CompletedAt = completedAt.String()
+	return response, nil
+}
+
+// completedRide answers a completion of a ride that is not in progress: the same answer again
+// when the driver completed it already, ErrRideNotInProgress otherwise
+func (dr *DriverRepository) completedRide(ctx context.Context, tx pgx.Tx, response model.RideCompleteResponse, requestData model.RideCompleteForm, commissionRate float64) (model.RideCompleteResponse, error) {
+	Query := `
+		SELECT status::text, completed_at FROM rides WHERE ride_id = $1 AND driver_id = $2;
 	`
+	var (
+		status      string
+		completedAt *time.Time
+	)
+	err := tx.QueryRow(ctx, Query, requestData.Ride_id, requestData.Driver_id).Scan(&status, &completedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.RideCompleteResponse{}, ErrNoActiveRide
+	}
+	if err != nil {
+		return model.RideCompleteResponse{}, err
+	}
+	if status != "COMPLETED" {
+		return model.RideCompleteResponse{}, ErrRideNotInProgress
+	}
 
-	err = dr.db.GetConn().QueryRow(ctx, DriverEarningsQuery, requestData.Ride_id).Scan(&response.DriverEarning)
+	// a ride completed while its booking could still fail apart may have none yet
+	earning, recorded, err := recordRide(ctx, tx, requestData.Ride_id, commissionRate)
 	if err != nil {
 		return model.RideCompleteResponse{}, err
 	}
+	if !recorded {
+		if earning, err = rideEarning(ctx, tx, requestData.Ride_id); err != nil {
+			return model.RideCompleteResponse{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.RideCompleteResponse{}, err
+	}
 
-	response.CompletedAt = time.Now().String()
+	response.DriverEarning = earning.Net
+	if completedAt != nil {
+		response.CompletedAt = completedAt.String()
+	}
 	return response, nil
 }
 
-func (dr *DriverRepository) FindDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string) ([]model.DriverInfo, error) {
+// FindDrivers matches on the active vehicle: its own type, or a ride type it opted in to
+// when vehicles of its type may opt in to it (optInFrom)
+func (dr *DriverRepository) FindDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string, optInFrom []string) ([]model.DriverInfo, error) {
 	Query := `
-	SELECT d.driver_id, d.email, d.username, d.vehicle_attrs, d.rating, c.latitude, c.longitude,
+	SELECT d.driver_id, d.email, d.username, ` + activeVehicleJSON + `, d.rating, c.latitude, c.longitude,
        ST_Distance(
          ST_MakePoint(c.longitude, c.latitude)::geography,
          ST_MakePoint($1, $2)::geography
@@ -211,8 +252,12 @@ func (dr *DriverRepository) FindDrivers(ctx context.Context, longtitude, latitud
 	JOIN coordinates c ON c.entity_id = d.driver_id
   		AND c.entity_type = 'DRIVER'
   		AND c.is_current = true
+	` + activeVehicleJoin + `
 	WHERE d.status = 'AVAILABLE'
- 		AND d.vehicle_type = $3
+ 		AND (
+			COALESCE(v.vehicle_type, d.vehicle_type)::text = $3
+			OR ($3 = ANY(v.opt_in_types::text[]) AND v.vehicle_type::text = ANY($4))
+		)
   		AND ST_DWithin(
         	ST_MakePoint(c.longitude, c.latitude)::geography,
         	ST_MakePoint($1, $2)::geography,
@@ -221,7 +266,7 @@ func (dr *DriverRepository) FindDrivers(ctx context.Context, longtitude, latitud
 	ORDER BY distance_km, d.rating DESC
 	LIMIT 10;
 	`
-	rows, err := dr.db.GetConn().Query(ctx, Query, longtitude, latitude, vehicleType)
+	rows, err := dr.db.GetConn().Query(ctx, Query, longtitude, latitude, vehicleType, optInFrom)
 	if err != nil {
 		fmt.Println("Repository Error Arrived ", err)
 		return []model.DriverInfo{}, err
@@ -240,19 +285,6 @@ func (dr *DriverRepository) FindDrivers(ctx context.Context, longtitude, latitud
 	return result, nil
 }
 
-func (dr *DriverRepository) CalculateRideDetails(ctx context.Context, driverLocation model.Location, passagerLocation model.Location) (float64, error) {
-	q := `SELECT ST_Distance(ST_MakePoint($1, $2)::geography, ST_MakePoint($3, $4)::geography) / 1000 as distance_km`
-
-	db := dr.db.conn
-	row := db.QueryRow(ctx, q, driverLocation.Longitude, driverLocation.Latitude, passagerLocation.Longitude, passagerLocation.Latitude)
-	distance := 0.0
-	err := row.Scan(&distance)
-	if err != nil {
-		return 0.0, err
-	}
-	return distance, nil
-}
-
 func (dr *DriverRepository) UpdateDriverStatus(ctx context.Context, driver_id string, status string) error {
 	UpdateDriverStatusQuery := `
 		UPDATE drivers
@@ -263,6 +295,23 @@ func (dr *DriverRepository) UpdateDriverStatus(ctx context.Context, driver_id st
 	return err
 }
 
+// ClaimDriver makes the driver BUSY for the ride in one statement, only while the driver is
+// AVAILABLE and the ride still REQUESTED. false means another ride got the driver first, or
+// the ride is gone.
+func (dr *DriverRepository) ClaimDriver(ctx context.Context, driver_id, ride_id string) (bool, error) {
+	Query := `
+		UPDATE drivers
+		SET status = 'BUSY'
+		WHERE driver_id = $1 AND status = 'AVAILABLE'
+			AND EXISTS (SELECT 1 FROM rides WHERE ride_id = $2 AND status = 'REQUESTED');
+	`
+	tag, err := dr.db.GetConn().Exec(ctx, Query, driver_id, ride_id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (dr *DriverRepository) CheckDriverById(ctx context.Context, driver_id string) (bool, error) {
 	Query := `
 		SELECT EXISTS(SELECT 1 FROM drivers WHERE driver_id = $1);
@@ -275,6 +324,28 @@ func (dr *DriverRepository) CheckDriverById(ctx context.Context, driver_id strin
 	return exists, nil
 }
 
+// GetDriverStatuses returns the current status of the drivers, unknown drivers are left out
+func (dr *DriverRepository) GetDriverStatuses(ctx context.Context, driver_ids []string) (map[string]string, error) {
+	Query := `
+		SELECT driver_id, status::text FROM drivers WHERE driver_id = ANY($1::uuid[]);
+	`
+	rows, err := dr.db.GetConn().Query(ctx, Query, driver_ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]string, len(driver_ids))
+	for rows.Next() {
+		var driver_id, status string
+		if err := rows.Scan(&driver_id, &status); err != nil {
+			return nil, err
+		}
+		statuses[driver_id] = status
+	}
+	return statuses, rows.Err()
+}
+
 func (dr *DriverRepository) GetDriverIdByRideId(ctx context.Context, ride_id string) (string, error) {
 	Query := `
         SELECT driver_id FROM rides WHERE ride_id = $1;
@@ -334,6 +405,80 @@ func (dr *DriverRepository) GetRideDetailsByRideId(ctx context.Context, ride_id
 	return details, nil
 }
 
+// vehicle of the open session, drivers online without one keep the profile vehicle
+const activeVehicleJoin = `
+	LEFT JOIN LATERAL (
+		SELECT v.*
+		FROM driver_sessions s
+		JOIN vehicles v ON v.vehicle_id = s.vehicle_id
+		WHERE s.driver_id = d.driver_id AND s.ended_at IS NULL
+		ORDER BY s.started_at DESC
+		LIMIT 1
+	) v ON true
+`
+
+const activeVehicleJSON = `
+	CASE WHEN v.vehicle_id IS NULL THEN d.vehicle_attrs
+	ELSE jsonb_build_object(
+		'vehicle_id', v.vehicle_id, 'type', v.vehicle_type, 'make', v.make, 'model', v.model, 'year', v.year,
+		'color', v.color, 'plate', v.plate, 'seats', v.seats, 'capabilities', v.capabilities
+	) END
+`
+
+const liveDriverQuery = `
+	SELECT d.driver_id, d.username, d.email, ` + activeVehicleJSON + `, COALESCE(v.vehicle_type, d.vehicle_type)::text,
+		COALESCE(v.opt_in_types::text[], '{}'), d.status::text, COALESCE(d.rating, 5), c.latitude, c.longitude
+	FROM drivers d
+	JOIN coordinates c ON c.entity_id = d.driver_id
+		AND c.entity_type = 'DRIVER'
+		AND c.is_current = true
+	` + activeVehicleJoin + `
+	WHERE d.status <> 'OFFLINE'
+`
+
+// GetLiveDrivers returns every online driver with the current position
+func (dr *DriverRepository) GetLiveDrivers(ctx context.Context) ([]model.LiveDriver, error) {
+	rows, err := dr.db.GetConn().Query(ctx, liveDriverQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var drivers []model.LiveDriver
+	for rows.Next() {
+		var driver model.LiveDriver
+		if err := scanLiveDriver(rows, &driver); err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, driver)
+	}
+	return drivers, rows.Err()
+}
+
+func (dr *DriverRepository) GetLiveDriver(ctx context.Context, driver_id string) (model.LiveDriver, error) {
+	var driver model.LiveDriver
+	err := scanLiveDriver(dr.db.GetConn().QueryRow(ctx, liveDriverQuery+` AND d.driver_id = $1`, driver_id), &driver)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.LiveDriver{}, ErrDriverNotFound
+	}
+	return driver, err
+}
+
+func scanLiveDriver(row pgx.Row, driver *model.LiveDriver) error {
+	return row.Scan(
+		&driver.DriverId,
+		&driver.Name,
+		&driver.Email,
+		&driver.Vehicle,
+		&driver.VehicleType,
+		&driver.OptInTypes,
+		&driver.Status,
+		&driver.Rating,
+		&driver.Latitude,
+		&driver.Longitude,
+	)
+}
+
 /*
 SELECT d.driver_id, d.email, d.username, d.vehicle_attrs, d.rating, c.latitude, c.longitude,
        ST_Distance(
@@ -355,3 +500,109 @@ SELECT d.driver_id, d.email, d.username, d.vehicle_attrs, d.rating, c.latitude,
 	LIMIT 10;
 
 */
+
+// GetPickupRide returns the ride the driver accepted and has not started yet
+func (dr *DriverRepository) GetPickupRide(ctx context.Context, driver_id string) (model.PickupRide, error) {
+	Query := `
+		SELECT r.ride_id, r.status::text, pc.latitude, pc.longitude, r.arrived_at
+		FROM rides r
+		JOIN coordinates pc ON pc.coord_id = r.pickup_coord_id
+		WHERE r.driver_id = $1 AND r.status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED')
+		ORDER BY r.matched_at DESC NULLS LAST
+		LIMIT 1;
+	`
+	var ride model.PickupRide
+	err := dr.db.GetConn().QueryRow(ctx, Query, driver_id).Scan(&ride.Ride_id, &ride.Status, &ride.PickupLatitude, &ride.PickupLongitude, &ride.ArrivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.PickupRide{}, ErrRideNotFound
+	}
+	return ride, err
+}
+
+// AdvanceRideStatus moves the ride to the status only while it is in one of from,
+// false means the ride was already moved on. publish runs before the commit, its error
+// rolls the move back.
+func (dr *DriverRepository) AdvanceRideStatus(ctx context.Context, ride_id string, from []string, to string, publish func(at time.Time) error) (time.Time, bool, error) {
+	Query := `
+		UPDATE rides
+		SET status = $2,
+			arrived_at = CASE WHEN $2 = 'ARRIVED' THEN NOW() ELSE arrived_at END,
+			updated_at = NOW()
+		WHERE ride_id = $1 AND status::text = ANY($3)
+		RETURNING updated_at;
+	`
+	tx, err := dr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var updatedAt time.Time
+	err = tx.QueryRow(ctx, Query, ride_id, to, from).Scan(&updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if err := publish(updatedAt); err != nil {
+		return time.Time{}, false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return time.Time{}, false, err
+	}
+	return updatedAt, true, nil
+}
+
+func (dr *DriverRepository) GetActiveRide(ctx context.Context, driver_id string) (model.ActiveRide, error) {
+	Query := `
+		SELECT ride_id, status::text
+		FROM rides
+		WHERE driver_id = $1 AND status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')
+		ORDER BY matched_at DESC NULLS LAST
+		LIMIT 1;
+	`
+	var ride model.ActiveRide
+	err := dr.db.GetConn().QueryRow(ctx, Query, driver_id).Scan(&ride.Ride_id, &ride.Status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ActiveRide{}, ErrNoActiveRide
+	}
+	return ride, err
+}
+
+// CancelRide cancels a ride of the driver that was not picked up yet and frees the driver
+func (dr *DriverRepository) CancelRide(ctx context.Context, ride_id, driver_id, reason string) (time.Time, error) {
+	tx, err := dr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	RidesQuery := `
+		UPDATE rides
+		SET status = 'CANCELLED',
+			cancelled_at = NOW(),
+			cancellation_reason = $3,
+			cancelled_by = 'DRIVER',
+			updated_at = NOW()
+		WHERE ride_id = $1 AND driver_id = $2 AND status IN ('MATCHED', 'EN_ROUTE', 'ARRIVED')
+		RETURNING cancelled_at;
+	`
+	var cancelledAt time.Time
+	err = tx.QueryRow(ctx, RidesQuery, ride_id, driver_id, reason).Scan(&cancelledAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrRideNotCancellable
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	UpdateDriverStatusQuery := `
+		UPDATE drivers
+		SET status = 'AVAILABLE'
+		WHERE driver_id = $1 AND status <> 'OFFLINE';
+	`
+	if _, err := tx.Exec(ctx, UpdateDriverStatusQuery, driver_id); err != nil {
+		return time.Time{}, err
+	}
+	return cancelledAt, tx.Commit(ctx)
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type EarningsRepository struct {
+	db *DataBase
+}
+
+func NewEarningsRepository(db *DataBase) *EarningsRepository {
+	return &EarningsRepository{db: db}
+}
+
+// queryRower runs a query on the connection or inside a transaction
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// RecordRide books the earnings of a completed ride against the open session of the driver
+// and adds them to the session totals. false means the ride was booked before.
+func (er *EarningsRepository) RecordRide(ctx context.Context, ride_id string, commissionRate float64) (model.RideEarning, bool, error) {
+	return recordRide(ctx, er.db.GetConn(), ride_id, commissionRate)
+}
+
+func recordRide(ctx context.Context, q queryRower, ride_id string, commissionRate float64) (model.RideEarning, bool, error) {
+	Query := `
+		WITH earning AS (
+			INSERT INTO driver_earnings(driver_id, ride_id, driver_session_id, gross_fare, commission_rate, commission, net)
+			SELECT r.driver_id, r.ride_id, s.driver_session_id, f.fare, $2::numeric, ROUND(f.fare * $2::numeric, 2), f.fare - ROUND(f.fare * $2::numeric, 2)
+			FROM rides r
+			CROSS JOIN LATERAL (
+				SELECT COALESCE(r.final_fare, r.estimated_fare, 0)::numeric AS fare
+			) f
+			LEFT JOIN LATERAL (
+				SELECT driver_session_id FROM driver_sessions
+				WHERE driver_id = r.driver_id AND ended_at IS NULL
+				ORDER BY started_at DESC
+				LIMIT 1
+			) s ON true
+			WHERE r.ride_id = $1 AND r.driver_id IS NOT NULL
+			ON CONFLICT (ride_id) DO NOTHING
+			RETURNING ride_id, driver_session_id, gross_fare, commission_rate, commission, tip, net, earned_at
+		), session AS (
+			UPDATE driver_sessions ds
+			SET total_rides = COALESCE(ds.total_rides, 0) + 1,
+				total_earnings = COALESCE(ds.total_earnings, 0) + e.net
+			FROM earning e
+			WHERE ds.driver_session_id = e.driver_session_id
+		)
+		SELECT ride_id, driver_session_id, gross_fare::float, commission_rate::float, commission::float, tip::float, net::float, earned_at
+		FROM earning;
+	`
+	var earning model.RideEarning
+	err := q.QueryRow(ctx, Query, ride_id, commissionRate).Scan(
+		&earning.RideId,
+		&earning.SessionId,
+		&earning.GrossFare,
+		&earning.CommissionRate,
+		&earning.Commission,
+		&earning.Tip,
+		&earning.Net,
+		&earning.EarnedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.RideEarning{}, false, nil
+	}
+	if err != nil {
+		return model.RideEarning{}, false, err
+	}
+	return earning, true, nil
+}
+
+// rideEarning returns the booking of the ride
+func rideEarning(ctx context.Context, q queryRower, ride_id string) (model.RideEarning, error) {
+	Query := `
+		SELECT ride_id, driver_session_id, gross_fare::float, commission_rate::float, commission::float, tip::float, net::float, earned_at
+		FROM driver_earnings
+		WHERE ride_id = $1;
+	`
+	var earning model.RideEarning
+	err := q.QueryRow(ctx, Query, ride_id).Scan(
+		&earning.RideId,
+		&earning.SessionId,
+		&earning.GrossFare,
+		&earning.CommissionRate,
+		&earning.Commission,
+		&earning.Tip,
+		&earning.Net,
+		&earning.EarnedAt,
+	)
+	return earning, err
+}
+
+// GetStatement sums the earnings of the driver in [from, to) per day, week or session
+func (er *EarningsRepository) GetStatement(ctx context.Context, driver_id, period string, from, to time.Time) ([]model.EarningsLine, error) {
+	var Query string
+	switch period {
+	case model.EarningsPeriodDay, model.EarningsPeriodWeek:
+		Query = fmt.Sprintf(`
+			SELECT NULL::uuid, date_trunc('%s', earned_at AT TIME ZONE 'UTC'), NULL::timestamptz,
+				COUNT(*), SUM(gross_fare)::float, SUM(commission)::float, SUM(tip)::float, SUM(net)::float
+			FROM driver_earnings
+			WHERE driver_id = $1 AND earned_at >= $2 AND earned_at < $3
+			GROUP BY 2
+			ORDER BY 2;
+		`, period)
+	case model.EarningsPeriodSession:
+		Query = `
+			SELECT s.driver_session_id, s.started_at, s.ended_at,
+				COUNT(e.earning_id), COALESCE(SUM(e.gross_fare), 0)::float, COALESCE(SUM(e.commission), 0)::float,
+				COALESCE(SUM(e.tip), 0)::float, COALESCE(SUM(e.net), 0)::float
+			FROM driver_sessions s
+			LEFT JOIN driver_earnings e ON e.driver_session_id = s.driver_session_id
+			WHERE s.driver_id = $1 AND s.started_at >= $2 AND s.started_at < $3
+			GROUP BY s.driver_session_id
+			ORDER BY s.started_at;
+		`
+	default:
+		return nil, fmt.Errorf("unknown earnings period %q", period)
+	}
+
+	rows, err := er.db.GetConn().Query(ctx, Query, driver_id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []model.EarningsLine
+	for rows.Next() {
+		var line model.EarningsLine
+		if err := rows.Scan(&line.SessionId, &line.Start, &line.End, &line.Rides, &line.GrossFare, &line.Commission, &line.Tips, &line.Net); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
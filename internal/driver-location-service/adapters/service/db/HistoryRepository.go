@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	partitionPrefix = "location_history_p"
+	partitionLayout = "20060102"
+
+	// deleteBatch bounds the rows of one retention DELETE, short transactions keep
+	// locks and WAL small while the location writer inserts into the same table
+	deleteBatch = 5000
+)
+
+type HistoryRepository struct {
+	db *DataBase
+}
+
+func NewHistoryRepository(db *DataBase) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+// EnsurePartition creates the partition of the day unless it exists. Rows of that
+// day already in the default partition are moved into it.
+func (hr *HistoryRepository) EnsurePartition(ctx context.Context, day time.Time) (bool, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	name := partitionPrefix + from.Format(partitionLayout)
+
+	var exists bool
+	if err := hr.db.GetConn().QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	tx, err := hr.db.GetConn().Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	table := pgx.Identifier{name}.Sanitize()
+	CreateQuery := fmt.Sprintf(`CREATE TABLE %s (LIKE location_history INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`, table)
+	if _, err := tx.Exec(ctx, CreateQuery); err != nil {
+		return false, err
+	}
+	MoveQuery := fmt.Sprintf(`
+		WITH moved AS (
+			DELETE FROM location_history_default
+			WHERE recorded_at >= $1 AND recorded_at < $2
+			RETURNING *
+		)
+		INSERT INTO %s SELECT * FROM moved
+	`, table)
+	if _, err := tx.Exec(ctx, MoveQuery, from, to); err != nil {
+		return false, err
+	}
+	AttachQuery := fmt.Sprintf(`ALTER TABLE location_history ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+		table, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if _, err := tx.Exec(ctx, AttachQuery); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// ListPartitions returns the daily partitions, oldest first
+func (hr *HistoryRepository) ListPartitions(ctx context.Context) ([]model.HistoryPartition, error) {
+	Query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'location_history'::regclass AND c.relname LIKE 'location_history_p%'
+		ORDER BY c.relname;
+	`
+	rows, err := hr.db.GetConn().Query(ctx, Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []model.HistoryPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		day, err := time.Parse(partitionLayout, strings.TrimPrefix(name, partitionPrefix))
+		if err != nil {
+			continue // not managed by the service
+		}
+		partitions = append(partitions, model.HistoryPartition{Name: name, Day: day})
+	}
+	return partitions, rows.Err()
+}
+
+func (hr *HistoryRepository) DropPartition(ctx context.Context, name string) error {
+	_, err := hr.db.GetConn().Exec(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, pgx.Identifier{name}.Sanitize()))
+	return err
+}
+
+// DeleteNonRidePoints removes points recorded outside of rides before the time
+func (hr *HistoryRepository) DeleteNonRidePoints(ctx context.Context, before time.Time) (int64, error) {
+	Query := `
+		DELETE FROM location_history
+		WHERE (location_history_id, recorded_at) IN (
+			SELECT location_history_id, recorded_at
+			FROM location_history
+			WHERE ride_id IS NULL AND recorded_at < $1
+			LIMIT $2
+		);
+	`
+	return hr.deleteInBatches(ctx, Query, before)
+}
+
+// DeleteDefaultPoints removes old points that never got a daily partition
+func (hr *HistoryRepository) DeleteDefaultPoints(ctx context.Context, before time.Time) (int64, error) {
+	Query := `
+		DELETE FROM location_history_default
+		WHERE (location_history_id, recorded_at) IN (
+			SELECT location_history_id, recorded_at
+			FROM location_history_default
+			WHERE recorded_at < $1
+			LIMIT $2
+		);
+	`
+	return hr.deleteInBatches(ctx, Query, before)
+}
+
+// deleteInBatches runs the query, limited to deleteBatch rows by $2, until it deletes
+// less than a batch. Rows deleted before an error stay deleted.
+func (hr *HistoryRepository) deleteInBatches(ctx context.Context, query string, before time.Time) (int64, error) {
+	var total int64
+	for {
+		tag, err := hr.db.GetConn().Exec(ctx, query, before, deleteBatch)
+		if err != nil {
+			return total, err
+		}
+		total += tag.RowsAffected()
+		if tag.RowsAffected() < deleteBatch {
+			return total, nil
+		}
+	}
+}
+
+// RidesWithoutTrack returns rides completed before the time that have no track yet
+func (hr *HistoryRepository) RidesWithoutTrack(ctx context.Context, completedBefore time.Time, limit int) ([]string, error) {
+	Query := `
+		SELECT r.ride_id
+		FROM rides r
+		LEFT JOIN ride_tracks t ON t.ride_id = r.ride_id
+		WHERE r.status = 'COMPLETED'
+			AND t.ride_id IS NULL
+			AND COALESCE(r.completed_at, r.updated_at) < $1
+		ORDER BY COALESCE(r.completed_at, r.updated_at)
+		LIMIT $2;
+	`
+	rows, err := hr.db.GetConn().Query(ctx, Query, completedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (hr *HistoryRepository) GetRidePoints(ctx context.Context, ride_id string) ([]model.TrackPoint, error) {
+	Query := `
+		SELECT latitude::float, longitude::float, recorded_at
+		FROM location_history
+		WHERE ride_id = $1
+		ORDER BY recorded_at;
+	`
+	rows, err := hr.db.GetConn().Query(ctx, Query, ride_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []model.TrackPoint
+	for rows.Next() {
+		var point model.TrackPoint
+		if err := rows.Scan(&point.Latitude, &point.Longitude, &point.RecordedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+func (hr *HistoryRepository) SaveTrack(ctx context.Context, track model.RideTrack) error {
+	Query := `
+		INSERT INTO ride_tracks (ride_id, polyline, points_count, raw_points_count, distance_km, started_at, ended_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (ride_id) DO NOTHING;
+	`
+	_, err := hr.db.GetConn().Exec(ctx, Query,
+		track.RideId,
+		track.Polyline,
+		track.PointsCount,
+		track.RawPointsCount,
+		track.DistanceKm,
+		track.StartedAt,
+		track.EndedAt,
+	)
+	return err
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// hoursLockKey is the advisory lock of the replica that enforces driving hours
+const hoursLockKey = 7_140_043
+
+type HoursRepository struct {
+	db *DataBase
+}
+
+func NewHoursRepository(db *DataBase) *HoursRepository {
+	return &HoursRepository{db: db}
+}
+
+// TryLeadership takes the session advisory lock of the hours check, true while this
+// connection holds it. The lock goes with the connection, another replica takes over
+// once it is closed.
+func (hr *HoursRepository) TryLeadership(ctx context.Context) (bool, error) {
+	Query := `
+		SELECT CASE
+			WHEN EXISTS (
+				SELECT 1 FROM pg_locks
+				WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+					AND classid = 0 AND objid::bigint = $1::bigint AND objsubid = 1 AND granted
+			) THEN true
+			ELSE pg_try_advisory_lock($1::bigint)
+		END;
+	`
+	var leader bool
+	err := hr.db.GetConn().QueryRow(ctx, Query, int64(hoursLockKey)).Scan(&leader)
+	return leader, err
+}
+
+// GetOnlineSessions returns the sessions since the time of every driver that is online now,
+// ordered by driver and start
+func (hr *HoursRepository) GetOnlineSessions(ctx context.Context, since time.Time) ([]model.ShiftSession, error) {
+	Query := `
+		SELECT s.driver_id, d.jurisdiction, s.started_at, COALESCE(s.ended_at, NOW())
+		FROM driver_sessions s
+		JOIN drivers d ON d.driver_id = s.driver_id
+		WHERE COALESCE(s.ended_at, NOW()) >= $1
+			AND s.driver_id IN (SELECT driver_id FROM driver_sessions WHERE ended_at IS NULL)
+		ORDER BY s.driver_id, s.started_at;
+	`
+	return hr.sessions(ctx, Query, since)
+}
+
+// GetDriverSessions returns the sessions of the driver since the time, ordered by start
+func (hr *HoursRepository) GetDriverSessions(ctx context.Context, driver_id string, since time.Time) ([]model.ShiftSession, error) {
+	Query := `
+		SELECT s.driver_id, d.jurisdiction, s.started_at, COALESCE(s.ended_at, NOW())
+		FROM driver_sessions s
+		JOIN drivers d ON d.driver_id = s.driver_id
+		WHERE COALESCE(s.ended_at, NOW()) >= $1 AND s.driver_id = $2
+		ORDER BY s.started_at;
+	`
+	return hr.sessions(ctx, Query, since, driver_id)
+}
+
+func (hr *HoursRepository) sessions(ctx context.Context, query string, args ...any) ([]model.ShiftSession, error) {
+	rows, err := hr.db.GetConn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.ShiftSession
+	for rows.Next() {
+		var s model.ShiftSession
+		if err := rows.Scan(&s.DriverId, &s.Jurisdiction, &s.StartedAt, &s.EndedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (hr *HoursRepository) GetJurisdiction(ctx context.Context, driver_id string) (string, error) {
+	var jurisdiction string
+	err := hr.db.GetConn().QueryRow(ctx, `SELECT jurisdiction FROM drivers WHERE driver_id = $1;`, driver_id).Scan(&jurisdiction)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrDriverNotFound
+	}
+	return jurisdiction, err
+}
+
+// GetDrivingSeconds sums the time every driver spent on trips since the start of their shift
+func (hr *HoursRepository) GetDrivingSeconds(ctx context.Context, shiftStarts map[string]time.Time) (map[string]float64, error) {
+	driving := make(map[string]float64, len(shiftStarts))
+	if len(shiftStarts) == 0 {
+		return driving, nil
+	}
+	ids := make([]string, 0, len(shiftStarts))
+	starts := make([]time.Time, 0, len(shiftStarts))
+	for driver_id, start := range shiftStarts {
+		ids = append(ids, driver_id)
+		starts = append(starts, start)
+	}
+
+	Query := `
+		SELECT r.driver_id, SUM(EXTRACT(EPOCH FROM COALESCE(r.completed_at, r.cancelled_at, NOW()) - GREATEST(r.started_at, s.shift_start)))::float
+		FROM unnest($1::uuid[], $2::timestamptz[]) AS s(driver_id, shift_start)
+		JOIN rides r ON r.driver_id = s.driver_id
+		WHERE r.started_at IS NOT NULL AND COALESCE(r.completed_at, r.cancelled_at, NOW()) > s.shift_start
+		GROUP BY r.driver_id;
+	`
+	rows, err := hr.db.GetConn().Query(ctx, Query, ids, starts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var driver_id string
+		var seconds float64
+		if err := rows.Scan(&driver_id, &seconds); err != nil {
+			return nil, err
+		}
+		driving[driver_id] = seconds
+	}
+	return driving, rows.Err()
+}
+
+// StartBreak records a forced break unless the driver is already on one, the running
+// break is returned then
+func (hr *HoursRepository) StartBreak(ctx context.Context, brk model.DriverBreak) (model.DriverBreak, error) {
+	Query := `
+		INSERT INTO driver_breaks(driver_id, reason, online_hours, driving_hours, started_at, ends_at)
+		SELECT $1, $2, $3, $4, $5, $6
+		WHERE NOT EXISTS (SELECT 1 FROM driver_breaks WHERE driver_id = $1 AND ends_at > NOW())
+		RETURNING break_id;
+	`
+	err := hr.db.GetConn().QueryRow(ctx, Query, brk.DriverId, brk.Reason, brk.OnlineHours, brk.DrivingHours, brk.StartedAt, brk.EndsAt).Scan(&brk.BreakId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return hr.GetActiveBreak(ctx, brk.DriverId)
+	}
+	if err != nil {
+		return model.DriverBreak{}, err
+	}
+	return brk, nil
+}
+
+func (hr *HoursRepository) GetActiveBreak(ctx context.Context, driver_id string) (model.DriverBreak, error) {
+	Query := `
+		SELECT break_id, driver_id, reason, online_hours::float, driving_hours::float, started_at, ends_at
+		FROM driver_breaks
+		WHERE driver_id = $1 AND ends_at > NOW()
+		ORDER BY ends_at DESC
+		LIMIT 1;
+	`
+	var brk model.DriverBreak
+	err := hr.db.GetConn().QueryRow(ctx, Query, driver_id).Scan(&brk.BreakId, &brk.DriverId, &brk.Reason, &brk.OnlineHours, &brk.DrivingHours, &brk.StartedAt, &brk.EndsAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.DriverBreak{}, ErrNoActiveBreak
+	}
+	return brk, err
+}
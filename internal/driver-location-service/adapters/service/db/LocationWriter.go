@@ -0,0 +1,375 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/logger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const throughputWindow = time.Minute
+
+type locationWrite struct {
+	driver_id  string
+	location   model.NewLocation
+	recordedAt time.Time
+	result     chan locationWriteResult
+}
+
+type locationWriteResult struct {
+	response model.NewLocationResponse
+	err      error
+}
+
+type flushSample struct {
+	at   time.Time
+	rows int
+}
+
+// LocationWriter groups location updates and writes them to location_history and
+// coordinates in one COPY based transaction per batch. It owns its connection so
+// batches never wait for the request path queries.
+type LocationWriter struct {
+	db    *DataBase
+	cfg   *config.LocationWriterconfig
+	mylog logger.Logger
+
+	queue    chan locationWrite
+	stopping chan struct{}
+	done     chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	senders sync.WaitGroup
+
+	statsMu sync.Mutex
+	stats   model.LocationWriterStats
+	flushMs float64
+	waitMs  float64
+	recent  []flushSample
+}
+
+func NewLocationWriter(db *DataBase, cfg *config.LocationWriterconfig, mylog logger.Logger) *LocationWriter {
+	queueSize := max(cfg.QueueSize, 1)
+	return &LocationWriter{
+		db:       db,
+		cfg:      cfg,
+		mylog:    mylog,
+		queue:    make(chan locationWrite, queueSize),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+		stats:    model.LocationWriterStats{QueueCapacity: queueSize},
+	}
+}
+
+// Write queues the location and waits until its batch is committed. When the queue
+// stays full longer than the enqueue timeout the update is refused with ErrWriterOverloaded.
+func (lw *LocationWriter) Write(ctx context.Context, driver_id string, newLocation model.NewLocation) (model.NewLocationResponse, error) {
+	lw.mu.Lock()
+	if lw.closed {
+		lw.mu.Unlock()
+		return model.NewLocationResponse{}, ErrWriterClosed
+	}
+	lw.senders.Add(1)
+	lw.mu.Unlock()
+
+	write := locationWrite{
+		driver_id:  driver_id,
+		location:   newLocation,
+		recordedAt: time.Now(),
+		result:     make(chan locationWriteResult, 1),
+	}
+
+	timeout := time.NewTimer(time.Duration(lw.cfg.EnqueueTimeoutMs) * time.Millisecond)
+	defer timeout.Stop()
+	select {
+	case lw.queue <- write:
+		lw.senders.Done()
+	case <-lw.stopping:
+		lw.senders.Done()
+		return model.NewLocationResponse{}, ErrWriterClosed
+	case <-timeout.C:
+		lw.senders.Done()
+		lw.statsMu.Lock()
+		lw.stats.Refused++
+		lw.statsMu.Unlock()
+		return model.NewLocationResponse{}, ErrWriterOverloaded
+	case <-ctx.Done():
+		lw.senders.Done()
+		return model.NewLocationResponse{}, ctx.Err()
+	}
+
+	// once queued the write is flushed even if the caller stops waiting
+	select {
+	case res := <-write.result:
+		return res.response, res.err
+	case <-ctx.Done():
+		return model.NewLocationResponse{}, ctx.Err()
+	}
+}
+
+// Run flushes on batch size or interval until ctx is done, then flushes what is
+// left in the queue and closes the connection
+func (lw *LocationWriter) Run(ctx context.Context) {
+	log := lw.mylog.Action("LocationWriter")
+	defer close(lw.done)
+
+	batchSize := max(lw.cfg.BatchSize, 1)
+	interval := time.Duration(lw.cfg.FlushIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]locationWrite, 0, batchSize)
+	for {
+		select {
+		case write := <-lw.queue:
+			batch = append(batch, write)
+			if len(batch) >= batchSize {
+				lw.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				lw.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ctx.Done():
+			// refuse new writes and wait for the ones already sending
+			lw.mu.Lock()
+			lw.closed = true
+			lw.mu.Unlock()
+			close(lw.stopping)
+			lw.senders.Wait()
+
+			for len(lw.queue) > 0 {
+				batch = append(batch, <-lw.queue)
+				if len(batch) >= batchSize {
+					lw.flush(batch)
+					batch = batch[:0]
+				}
+			}
+			if len(batch) > 0 {
+				lw.flush(batch)
+			}
+			log.Info("Location writer flushed and stopped")
+			if err := lw.db.Close(); err != nil {
+				log.Error("Failed to close location writer connection", err)
+			}
+			return
+		}
+	}
+}
+
+// Wait blocks until Run has flushed the queue after shutdown
+func (lw *LocationWriter) Wait() {
+	<-lw.done
+}
+
+func (lw *LocationWriter) flush(batch []locationWrite) {
+	log := lw.mylog.Action("LocationWriter")
+	started := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	responses, err := lw.writeBatch(ctx, batch)
+	if err != nil && len(batch) > 1 {
+		if pingErr := lw.ping(); pingErr != nil {
+			// every row would wait out its own timeout on a dead connection, fail them all now
+			log.Error("Location writer connection is down, failing the batch", pingErr, "size", len(batch))
+			err = pingErr
+			if reconnectErr := lw.db.IsAlive(); reconnectErr != nil {
+				log.Error("Failed to reconnect location writer", reconnectErr)
+			}
+		} else {
+			// one bad point must not fail the others, retry row by row
+			log.Error("Failed to write location batch, retrying one by one", err, "size", len(batch))
+			for _, write := range batch {
+				lw.flush([]locationWrite{write})
+			}
+			return
+		}
+	}
+
+	finished := time.Now()
+	written := 0
+	for _, write := range batch {
+		res := locationWriteResult{err: err}
+		if err == nil {
+			if response, ok := responses[write.driver_id]; ok {
+				res.response = response
+				written++
+			} else {
+				res.err = ErrNoCurrentLocation
+			}
+		}
+		write.result <- res
+	}
+	lw.record(batch, written, finished.Sub(started), finished)
+}
+
+// ping checks the connection with a short deadline, a hung connection must not hold the writer
+func (lw *LocationWriter) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return lw.db.GetConn().Ping(ctx)
+}
+
+func (lw *LocationWriter) writeBatch(ctx context.Context, batch []locationWrite) (map[string]model.NewLocationResponse, error) {
+	tx, err := lw.db.GetConn().Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	CreateQuery := `
+		CREATE TEMP TABLE location_batch (
+			driver_id UUID,
+			latitude DOUBLE PRECISION,
+			longitude DOUBLE PRECISION,
+			accuracy_meters DOUBLE PRECISION,
+			speed_kmh DOUBLE PRECISION,
+			heading_degrees DOUBLE PRECISION,
+			recorded_at TIMESTAMPTZ
+		) ON COMMIT DROP;
+	`
+	if _, err := tx.Exec(ctx, CreateQuery); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]any, len(batch))
+	for i, write := range batch {
+		rows[i] = []any{
+			write.driver_id,
+			write.location.Latitude,
+			write.location.Longitude,
+			write.location.Accuracy_meters,
+			write.location.Speed_kmh,
+			write.location.Heading_Degrees,
+			write.recordedAt,
+		}
+	}
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"location_batch"},
+		[]string{"driver_id", "latitude", "longitude", "accuracy_meters", "speed_kmh", "heading_degrees", "recorded_at"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	HistoryQuery := `
+		INSERT INTO location_history(coord_id, driver_id, latitude, longitude, accuracy_meters, speed_kmh, heading_degrees, recorded_at, ride_id)
+		SELECT c.coord_id, b.driver_id, b.latitude, b.longitude, b.accuracy_meters, b.speed_kmh, b.heading_degrees, b.recorded_at, r.ride_id
+		FROM location_batch b
+		LEFT JOIN LATERAL (
+			SELECT coord_id FROM coordinates
+			WHERE entity_id = b.driver_id AND entity_type = 'DRIVER'
+			ORDER BY updated_at DESC
+			LIMIT 1
+		) c ON true
+		LEFT JOIN LATERAL (
+			SELECT ride_id FROM rides
+			WHERE driver_id = b.driver_id AND status NOT IN ('CANCELLED', 'COMPLETED')
+			ORDER BY requested_at DESC
+			LIMIT 1
+		) r ON true;
+	`
+	if _, err := tx.Exec(ctx, HistoryQuery); err != nil {
+		return nil, err
+	}
+
+	// only the latest point of each driver moves the current position
+	CoordinatesQuery := `
+		UPDATE coordinates c
+		SET latitude = l.latitude,
+			longitude = l.longitude,
+			updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (driver_id) driver_id, latitude, longitude
+			FROM location_batch
+			ORDER BY driver_id, recorded_at DESC
+		) l
+		WHERE c.entity_id = l.driver_id AND c.entity_type = 'DRIVER'
+		RETURNING c.entity_id, c.coord_id, c.updated_at;
+	`
+	result, err := tx.Query(ctx, CoordinatesQuery)
+	if err != nil {
+		return nil, err
+	}
+	responses := make(map[string]model.NewLocationResponse)
+	for result.Next() {
+		var (
+			driver_id string
+			response  model.NewLocationResponse
+		)
+		if err := result.Scan(&driver_id, &response.Coordinate_id, &response.Updated_at); err != nil {
+			result.Close()
+			return nil, err
+		}
+		responses[driver_id] = response
+	}
+	result.Close()
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return responses, tx.Commit(ctx)
+}
+
+func (lw *LocationWriter) record(batch []locationWrite, written int, took time.Duration, at time.Time) {
+	lw.statsMu.Lock()
+	defer lw.statsMu.Unlock()
+
+	flushMs := float64(took.Microseconds()) / 1000
+	lw.stats.Batches++
+	lw.stats.Written += int64(written)
+	lw.stats.Failed += int64(len(batch) - written)
+	lw.flushMs += flushMs
+	lw.stats.MaxFlushMs = max(lw.stats.MaxFlushMs, flushMs)
+	for _, write := range batch {
+		waitMs := float64(at.Sub(write.recordedAt).Microseconds()) / 1000
+		lw.waitMs += waitMs
+		lw.stats.MaxWaitMs = max(lw.stats.MaxWaitMs, waitMs)
+	}
+
+	lw.recent = append(lw.recent, flushSample{at: at, rows: written})
+	cut := 0
+	for cut < len(lw.recent) && at.Sub(lw.recent[cut].at) > throughputWindow {
+		cut++
+	}
+	lw.recent = lw.recent[cut:]
+}
+
+func (lw *LocationWriter) Stats() model.LocationWriterStats {
+	lw.statsMu.Lock()
+	defer lw.statsMu.Unlock()
+
+	stats := lw.stats
+	stats.QueueLength = len(lw.queue)
+	if stats.Batches > 0 {
+		stats.AvgFlushMs = lw.flushMs / float64(stats.Batches)
+		stats.AvgBatchSize = float64(stats.Written+stats.Failed) / float64(stats.Batches)
+	}
+	if total := stats.Written + stats.Failed; total > 0 {
+		stats.AvgWaitMs = lw.waitMs / float64(total)
+	}
+
+	rows := 0
+	now := time.Now()
+	for _, sample := range lw.recent {
+		if now.Sub(sample.at) <= throughputWindow {
+			rows += sample.rows
+		}
+	}
+	stats.ThroughputPerSec = float64(rows) / throughputWindow.Seconds()
+	return stats
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OfferRepository struct {
+	db *DataBase
+}
+
+func NewOfferRepository(db *DataBase) *OfferRepository {
+	return &OfferRepository{db: db}
+}
+
+func (or *OfferRepository) CreateOffer(ctx context.Context, offer model.RideOffer) error {
+	Query := `
+		INSERT INTO ride_offers (offer_id, ride_id, driver_id, status, strategy, sent_at, expires_at, instance_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''));
+	`
+	_, err := or.db.GetConn().Exec(ctx, Query,
+		offer.OfferId,
+		offer.RideId,
+		offer.DriverId,
+		offer.Status,
+		offer.Strategy,
+		offer.SentAt,
+		offer.ExpiresAt,
+		offer.InstanceId,
+	)
+	return err
+}
+
+// CloseOffer moves a SENT offer to its final status, closed offers are left untouched
+func (or *OfferRepository) CloseOffer(ctx context.Context, offer_id string, status string, reason string) error {
+	Query := `
+		UPDATE ride_offers
+		SET status = $2,
+			decline_reason = NULLIF($3, ''),
+			responded_at = CASE WHEN $2 IN ('ACCEPTED', 'DECLINED') THEN NOW() END
+		WHERE offer_id = $1 AND status = 'SENT';
+	`
+	_, err := or.db.GetConn().Exec(ctx, Query, offer_id, status, reason)
+	return err
+}
+
+func (or *OfferRepository) GetOffer(ctx context.Context, offer_id string) (model.RideOffer, error) {
+	Query := `
+		SELECT offer_id, ride_id, driver_id, status, strategy, COALESCE(instance_id, ''), COALESCE(decline_reason, ''), sent_at, expires_at, responded_at
+		FROM ride_offers
+		WHERE offer_id = $1;
+	`
+	var offer model.RideOffer
+	err := or.db.GetConn().QueryRow(ctx, Query, offer_id).Scan(
+		&offer.OfferId,
+		&offer.RideId,
+		&offer.DriverId,
+		&offer.Status,
+		&offer.Strategy,
+		&offer.InstanceId,
+		&offer.DeclineReason,
+		&offer.SentAt,
+		&offer.ExpiresAt,
+		&offer.RespondedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.RideOffer{}, ErrOfferNotFound
+	}
+	return offer, err
+}
+
+func (or *OfferRepository) GetOfferStats(ctx context.Context, driver_id string, days int) (model.OfferStats, error) {
+	Query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status <> 'FAILED'),
+			COUNT(*) FILTER (WHERE status = 'ACCEPTED'),
+			COUNT(*) FILTER (WHERE status = 'DECLINED'),
+			COUNT(*) FILTER (WHERE status = 'EXPIRED'),
+			COUNT(*) FILTER (WHERE status = 'WITHDRAWN')
+		FROM ride_offers
+		WHERE driver_id = $1 AND sent_at >= NOW() - make_interval(days => $2);
+	`
+	var stats model.OfferStats
+	err := or.db.GetConn().QueryRow(ctx, Query, driver_id, days).Scan(
+		&stats.Sent,
+		&stats.Accepted,
+		&stats.Declined,
+		&stats.Expired,
+		&stats.Withdrawn,
+	)
+	if err != nil {
+		return model.OfferStats{}, err
+	}
+
+	ReasonsQuery := `
+		SELECT COALESCE(decline_reason, 'unspecified'), COUNT(*)
+		FROM ride_offers
+		WHERE driver_id = $1 AND status = 'DECLINED' AND sent_at >= NOW() - make_interval(days => $2)
+		GROUP BY 1;
+	`
+	rows, err := or.db.GetConn().Query(ctx, ReasonsQuery, driver_id, days)
+	if err != nil {
+		return model.OfferStats{}, err
+	}
+	defer rows.Close()
+
+	stats.DeclineReasons = make(map[string]int)
+	for rows.Next() {
+		var (
+			reason string
+			count  int
+		)
+		if err := rows.Scan(&reason, &count); err != nil {
+			return model.OfferStats{}, err
+		}
+		stats.DeclineReasons[reason] = count
+	}
+	return stats, rows.Err()
+}
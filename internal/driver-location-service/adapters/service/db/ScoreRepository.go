@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ScoreRepository struct {
+	db *DataBase
+}
+
+func NewScoreRepository(db *DataBase) *ScoreRepository {
+	return &ScoreRepository{db: db}
+}
+
+// RefreshScores recomputes the quality components of every driver. Each rate blends the
+// last short_window_days, weighted by short_weight, with the last window_days, the long
+// window alone is used when the short one has no activity. Only cancellations by the driver
+// count against the cancellation and completion rates.
+func (sr *ScoreRepository) RefreshScores(ctx context.Context, window_days, short_window_days int, short_weight float64) (int64, error) {
+	Query := `
+		INSERT INTO driver_scores (
+			driver_id, acceptance_rate, cancellation_rate, completion_rate, rating,
+			offers_count, rides_count, window_days, computed_at
+		)
+		SELECT
+			d.driver_id,
+			COALESCE(
+				$3::float * (o.accepted_short::float / NULLIF(o.answered_short, 0)) + (1 - $3::float) * (o.accepted::float / NULLIF(o.answered, 0)),
+				o.accepted::float / NULLIF(o.answered, 0),
+				1
+			),
+			COALESCE(
+				$3::float * (r.cancelled_short::float / NULLIF(r.finished_short, 0)) + (1 - $3::float) * (r.cancelled::float / NULLIF(r.finished, 0)),
+				r.cancelled::float / NULLIF(r.finished, 0),
+				0
+			),
+			COALESCE(
+				$3::float * (r.completed_short::float / NULLIF(r.finished_short, 0)) + (1 - $3::float) * (r.completed::float / NULLIF(r.finished, 0)),
+				r.completed::float / NULLIF(r.finished, 0),
+				1
+			),
+			COALESCE(d.rating, 5),
+			COALESCE(o.answered, 0),
+			COALESCE(r.finished, 0),
+			$1,
+			NOW()
+		FROM drivers d
+		LEFT JOIN (
+			SELECT
+				driver_id,
+				COUNT(*) FILTER (WHERE status IN ('ACCEPTED', 'DECLINED', 'EXPIRED')) AS answered,
+				COUNT(*) FILTER (WHERE status = 'ACCEPTED') AS accepted,
+				COUNT(*) FILTER (WHERE status IN ('ACCEPTED', 'DECLINED', 'EXPIRED') AND sent_at >= NOW() - make_interval(days => $2)) AS answered_short,
+				COUNT(*) FILTER (WHERE status = 'ACCEPTED' AND sent_at >= NOW() - make_interval(days => $2)) AS accepted_short
+			FROM ride_offers
+			WHERE sent_at >= NOW() - make_interval(days => $1)
+			GROUP BY driver_id
+		) o ON o.driver_id = d.driver_id
+		LEFT JOIN (
+			SELECT
+				driver_id,
+				COUNT(*) FILTER (WHERE status = 'COMPLETED' OR cancelled_by = 'DRIVER') AS finished,
+				COUNT(*) FILTER (WHERE status = 'COMPLETED') AS completed,
+				COUNT(*) FILTER (WHERE cancelled_by = 'DRIVER') AS cancelled,
+				COUNT(*) FILTER (WHERE (status = 'COMPLETED' OR cancelled_by = 'DRIVER') AND requested_at >= NOW() - make_interval(days => $2)) AS finished_short,
+				COUNT(*) FILTER (WHERE status = 'COMPLETED' AND requested_at >= NOW() - make_interval(days => $2)) AS completed_short,
+				COUNT(*) FILTER (WHERE cancelled_by = 'DRIVER' AND requested_at >= NOW() - make_interval(days => $2)) AS cancelled_short
+			FROM rides
+			WHERE driver_id IS NOT NULL AND requested_at >= NOW() - make_interval(days => $1)
+			GROUP BY driver_id
+		) r ON r.driver_id = d.driver_id
+		ON CONFLICT (driver_id) DO UPDATE SET
+			acceptance_rate = EXCLUDED.acceptance_rate,
+			cancellation_rate = EXCLUDED.cancellation_rate,
+			completion_rate = EXCLUDED.completion_rate,
+			rating = EXCLUDED.rating,
+			offers_count = EXCLUDED.offers_count,
+			rides_count = EXCLUDED.rides_count,
+			window_days = EXCLUDED.window_days,
+			computed_at = EXCLUDED.computed_at;
+	`
+	short_window_days = min(max(short_window_days, 1), window_days)
+	short_weight = min(max(short_weight, 0), 1)
+	tag, err := sr.db.GetConn().Exec(ctx, Query, window_days, short_window_days, short_weight)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (sr *ScoreRepository) GetScores(ctx context.Context, driver_ids []string) (map[string]model.DriverScore, error) {
+	Query := `
+		SELECT driver_id, acceptance_rate::float, cancellation_rate::float, completion_rate::float, rating::float,
+			offers_count, rides_count, window_days, computed_at
+		FROM driver_scores
+		WHERE driver_id = ANY($1::uuid[]);
+	`
+	rows, err := sr.db.GetConn().Query(ctx, Query, driver_ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[string]model.DriverScore)
+	for rows.Next() {
+		var score model.DriverScore
+		if err := rows.Scan(
+			&score.DriverId,
+			&score.AcceptanceRate,
+			&score.CancellationRate,
+			&score.CompletionRate,
+			&score.Rating,
+			&score.OffersCount,
+			&score.RidesCount,
+			&score.WindowDays,
+			&score.ComputedAt,
+		); err != nil {
+			return nil, err
+		}
+		scores[score.DriverId] = score
+	}
+	return scores, rows.Err()
+}
+
+func (sr *ScoreRepository) GetScore(ctx context.Context, driver_id string) (model.DriverScore, error) {
+	Query := `
+		SELECT d.driver_id, d.vehicle_type,
+			COALESCE(s.acceptance_rate, 1)::float,
+			COALESCE(s.cancellation_rate, 0)::float,
+			COALESCE(s.completion_rate, 1)::float,
+			COALESCE(s.rating, d.rating, 5)::float,
+			COALESCE(s.offers_count, 0),
+			COALESCE(s.rides_count, 0),
+			COALESCE(s.window_days, 0),
+			s.computed_at
+		FROM drivers d
+		LEFT JOIN driver_scores s ON s.driver_id = d.driver_id
+		WHERE d.driver_id = $1;
+	`
+	var score model.DriverScore
+	err := sr.db.GetConn().QueryRow(ctx, Query, driver_id).Scan(
+		&score.DriverId,
+		&score.VehicleType,
+		&score.AcceptanceRate,
+		&score.CancellationRate,
+		&score.CompletionRate,
+		&score.Rating,
+		&score.OffersCount,
+		&score.RidesCount,
+		&score.WindowDays,
+		&score.ComputedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.DriverScore{}, ErrDriverNotFound
+	}
+	return score, err
+}
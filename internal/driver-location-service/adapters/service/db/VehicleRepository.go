@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"ride-hail/internal/driver-location-service/core/domain/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const vehicleColumns = `
+	v.vehicle_id, v.driver_id, v.vehicle_type::text, v.make, v.model, v.year, v.color, v.plate, v.seats,
+	v.capabilities, v.opt_in_types::text[], v.created_at, v.updated_at
+`
+
+type VehicleRepository struct {
+	db *DataBase
+}
+
+func NewVehicleRepository(db *DataBase) *VehicleRepository {
+	return &VehicleRepository{db: db}
+}
+
+func (vr *VehicleRepository) Create(ctx context.Context, vehicle model.Vehicle) (model.Vehicle, error) {
+	Query := `
+		INSERT INTO vehicles AS v (driver_id, vehicle_type, make, model, year, color, plate, seats, capabilities, opt_in_types)
+		VALUES ($1, $2::vehicle_type, $3, $4, $5, $6, $7, $8, $9, $10::text[]::vehicle_type[])
+		RETURNING ` + vehicleColumns
+	row := vr.db.GetConn().QueryRow(ctx, Query,
+		vehicle.DriverId,
+		vehicle.VehicleType,
+		vehicle.Make,
+		vehicle.Model,
+		vehicle.Year,
+		vehicle.Color,
+		vehicle.Plate,
+		vehicle.Seats,
+		vehicle.Capabilities,
+		vehicle.OptInTypes,
+	)
+	return scanVehicle(row)
+}
+
+// List returns the vehicles of the driver that were not removed
+func (vr *VehicleRepository) List(ctx context.Context, driver_id string) ([]model.Vehicle, error) {
+	Query := `SELECT ` + vehicleColumns + ` FROM vehicles v WHERE v.driver_id = $1 AND v.is_active ORDER BY v.created_at;`
+	rows, err := vr.db.GetConn().Query(ctx, Query, driver_id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vehicles []model.Vehicle
+	for rows.Next() {
+		vehicle, err := scanVehicle(rows)
+		if err != nil {
+			return nil, err
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+	return vehicles, rows.Err()
+}
+
+func (vr *VehicleRepository) Get(ctx context.Context, driver_id, vehicle_id string) (model.Vehicle, error) {
+	Query := `SELECT ` + vehicleColumns + ` FROM vehicles v WHERE v.driver_id = $1 AND v.vehicle_id = $2 AND v.is_active;`
+	return scanVehicle(vr.db.GetConn().QueryRow(ctx, Query, driver_id, vehicle_id))
+}
+
+// GetLastUsed returns the vehicle the driver went online with last, or the first one
+// registered when none was used yet
+func (vr *VehicleRepository) GetLastUsed(ctx context.Context, driver_id string) (model.Vehicle, error) {
+	Query := `
+		SELECT ` + vehicleColumns + `
+		FROM vehicles v
+		LEFT JOIN LATERAL (
+			SELECT MAX(s.started_at) AS last_used FROM driver_sessions s WHERE s.vehicle_id = v.vehicle_id
+		) u ON true
+		WHERE v.driver_id = $1 AND v.is_active
+		ORDER BY u.last_used DESC NULLS LAST, v.created_at
+		LIMIT 1;
+	`
+	return scanVehicle(vr.db.GetConn().QueryRow(ctx, Query, driver_id))
+}
+
+func (vr *VehicleRepository) Update(ctx context.Context, vehicle model.Vehicle) (model.Vehicle, error) {
+	Query := `
+		UPDATE vehicles AS v
+		SET vehicle_type = $3::vehicle_type, make = $4, model = $5, year = $6, color = $7, plate = $8, seats = $9,
+			capabilities = $10, opt_in_types = $11::text[]::vehicle_type[], updated_at = NOW()
+		WHERE v.driver_id = $1 AND v.vehicle_id = $2 AND v.is_active
+		RETURNING ` + vehicleColumns
+	row := vr.db.GetConn().QueryRow(ctx, Query,
+		vehicle.DriverId,
+		vehicle.VehicleId,
+		vehicle.VehicleType,
+		vehicle.Make,
+		vehicle.Model,
+		vehicle.Year,
+		vehicle.Color,
+		vehicle.Plate,
+		vehicle.Seats,
+		vehicle.Capabilities,
+		vehicle.OptInTypes,
+	)
+	return scanVehicle(row)
+}
+
+// Remove hides the vehicle, past sessions keep pointing at it. The vehicle of the open
+// session cannot be removed.
+func (vr *VehicleRepository) Remove(ctx context.Context, driver_id, vehicle_id string) error {
+	Query := `
+		UPDATE vehicles
+		SET is_active = false, updated_at = NOW()
+		WHERE driver_id = $1 AND vehicle_id = $2 AND is_active
+			AND NOT EXISTS (SELECT 1 FROM driver_sessions WHERE vehicle_id = $2 AND ended_at IS NULL);
+	`
+	tag, err := vr.db.GetConn().Exec(ctx, Query, driver_id, vehicle_id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	if _, err := vr.Get(ctx, driver_id, vehicle_id); err != nil {
+		return err
+	}
+	return ErrVehicleInUse
+}
+
+func scanVehicle(row pgx.Row) (model.Vehicle, error) {
+	var vehicle model.Vehicle
+	err := row.Scan(
+		&vehicle.VehicleId,
+		&vehicle.DriverId,
+		&vehicle.VehicleType,
+		&vehicle.Make,
+		&vehicle.Model,
+		&vehicle.Year,
+		&vehicle.Color,
+		&vehicle.Plate,
+		&vehicle.Seats,
+		&vehicle.Capabilities,
+		&vehicle.OptInTypes,
+		&vehicle.CreatedAt,
+		&vehicle.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Vehicle{}, ErrVehicleNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		return model.Vehicle{}, ErrPlateTaken
+	}
+	return vehicle, err
+}
@@ -0,0 +1,28 @@
+package db
+
+import "errors"
+
+var (
+	ErrOfferNotFound      = errors.New("offer not found")
+	ErrDriverNotFound     = errors.New("driver not found")
+	ErrRideNotFound       = errors.New("no ride waiting for pickup")
+	ErrNoActiveRide       = errors.New("driver has no active ride")
+	ErrRideNotCancellable = errors.New("ride can only be cancelled before the pickup")
+	ErrRideNotInProgress  = errors.New("ride is not in progress")
+	ErrNoOpenSession      = errors.New("driver is not online")
+
+	ErrDestinationLimit  = errors.New("destination mode used up for today")
+	ErrDestinationNotSet = errors.New("destination mode is off")
+
+	ErrNoActiveBreak = errors.New("driver is not on a break")
+
+	ErrVehicleNotFound = errors.New("vehicle not found")
+	ErrVehicleInUse    = errors.New("vehicle is in use by the current session")
+	ErrPlateTaken      = errors.New("a vehicle with this plate is already registered")
+
+	ErrConnectionNotFound = errors.New("driver has no WebSocket session on any replica")
+
+	ErrWriterOverloaded  = errors.New("location writer queue is full")
+	ErrWriterClosed      = errors.New("location writer is closed")
+	ErrNoCurrentLocation = errors.New("driver has no current location, go online first")
+)
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/logger"
+)
+
+// Jobs are the repositories of the background loops. A pgx.Conn runs one query at a time,
+// every loop gets a connection of its own so a long refresh never holds up the requests
+// on the shared one, nor another loop.
+type Jobs struct {
+	ScoreRepository   *ScoreRepository
+	IndexRepository   *DriverRepository // driver index resync
+	HistoryRepository *HistoryRepository
+	DemandRepository  *DemandRepository
+	HoursRepository   *HoursRepository
+	HoursRides        *DriverRepository // active rides checked before a forced break, on the hours connection
+
+	conns []*DataBase
+}
+
+func ConnectJobs(ctx context.Context, dbCfg *config.DBconfig, mylog logger.Logger) (*Jobs, error) {
+	jobs := &Jobs{}
+	connect := func() (*DataBase, error) {
+		database, err := ConnectDB(ctx, dbCfg, mylog)
+		if err != nil {
+			return nil, err
+		}
+		jobs.conns = append(jobs.conns, database)
+		return database, nil
+	}
+
+	scoreDB, err := connect()
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	jobs.ScoreRepository = NewScoreRepository(scoreDB)
+
+	indexDB, err := connect()
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	jobs.IndexRepository = NewDriverRepository(indexDB)
+
+	historyDB, err := connect()
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	jobs.HistoryRepository = NewHistoryRepository(historyDB)
+
+	demandDB, err := connect()
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	jobs.DemandRepository = NewDemandRepository(demandDB)
+
+	hoursDB, err := connect()
+	if err != nil {
+		jobs.Close()
+		return nil, err
+	}
+	jobs.HoursRepository = NewHoursRepository(hoursDB)
+	jobs.HoursRides = NewDriverRepository(hoursDB)
+
+	return jobs, nil
+}
+
+// Close closes the connections of every job
+func (j *Jobs) Close() error {
+	var errs []error
+	for _, database := range j.conns {
+		if err := database.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,11 +1,29 @@
 package db
 
 type Repository struct {
-	DriverRepository *DriverRepository
+	DriverRepository      *DriverRepository
+	OfferRepository       *OfferRepository
+	ScoreRepository       *ScoreRepository
+	HistoryRepository     *HistoryRepository
+	EarningsRepository    *EarningsRepository
+	DemandRepository      *DemandRepository
+	DestinationRepository *DestinationRepository
+	HoursRepository       *HoursRepository
+	DocumentRepository    *DocumentRepository
+	VehicleRepository     *VehicleRepository
 }
 
 func New(db *DataBase) *Repository {
 	return &Repository{
-		DriverRepository: NewDriverRepository(db),
+		DriverRepository:      NewDriverRepository(db),
+		OfferRepository:       NewOfferRepository(db),
+		ScoreRepository:       NewScoreRepository(db),
+		HistoryRepository:     NewHistoryRepository(db),
+		EarningsRepository:    NewEarningsRepository(db),
+		DemandRepository:      NewDemandRepository(db),
+		DestinationRepository: NewDestinationRepository(db),
+		HoursRepository:       NewHoursRepository(db),
+		DocumentRepository:    NewDocumentRepository(db),
+		VehicleRepository:     NewVehicleRepository(db),
 	}
 }
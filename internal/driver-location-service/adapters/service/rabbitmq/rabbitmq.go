@@ -141,6 +141,58 @@ func (r *RabbitMQ) Consume(ctx context.Context, queueName, bindingKey string, op
 	return out, nil
 }
 
+// ConsumeExclusive declares a queue owned by this connection and deleted with it, bound to
+// the exchange. The replica gets the messages addressed to it, nobody else reads them.
+func (r *RabbitMQ) ConsumeExclusive(ctx context.Context, exchange, queueName, bindingKey string) (<-chan amqp.Delivery, error) {
+	if !r.IsAlive() {
+		return nil, errors.New("amqp closed")
+	}
+	if err := r.ensureExchange(exchange); err != nil {
+		return nil, fmt.Errorf("declare exchange: %w", err)
+	}
+	if _, err := r.ch.QueueDeclare(
+		queueName,
+		false, // durable
+		true,  // auto-delete
+		true,  // exclusive
+		false, // no-wait
+		nil,
+	); err != nil {
+		return nil, fmt.Errorf("queue declare: %w", err)
+	}
+	if err := r.ch.QueueBind(queueName, bindingKey, exchange, false, nil); err != nil {
+		return nil, fmt.Errorf("queue bind: %w", err)
+	}
+	deliveries, err := r.ch.Consume(
+		queueName,
+		"",    // consumer tag
+		true,  // auto-ack
+		true,  // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("consume: %w", err)
+	}
+	out := make(chan amqp.Delivery)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				out <- m
+			}
+		}
+	}()
+	return out, nil
+}
+
 func (r *RabbitMQ) IsAlive() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
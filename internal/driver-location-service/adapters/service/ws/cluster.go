@@ -0,0 +1,284 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	messagebrokerdto "ride-hail/internal/driver-location-service/core/domain/message_broker_dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const relayExchange = "ws_relay"
+
+// Cluster lets replicas behind a load balancer share their driver sockets. The registry in
+// Postgres tells which replica holds the session of a driver, messages for a driver on
+// another replica go to that replica's own queue on ws_relay and are sent from there.
+type Cluster struct {
+	manager  *WebSocketManager
+	registry driven.IConnectionRegistry
+	broker   driven.IDriverBroker
+	cfg      *config.Clusterconfig
+	log      logger.Logger
+
+	registryMu sync.Mutex // the registry has a connection of its own, one query at a time
+
+	mu     sync.RWMutex
+	remote map[string]model.DriverConnection // sessions on other replicas, refreshed every heartbeat
+}
+
+func NewCluster(manager *WebSocketManager, registry driven.IConnectionRegistry, broker driven.IDriverBroker, cfg *config.Clusterconfig, log logger.Logger) *Cluster {
+	cluster := &Cluster{
+		manager:  manager,
+		registry: registry,
+		broker:   broker,
+		cfg:      cfg,
+		log:      log,
+		remote:   make(map[string]model.DriverConnection),
+	}
+	manager.cluster = cluster
+	return cluster
+}
+
+// Start joins the cluster: the replica's queue is declared and the registry gets its
+// first heartbeat before any socket is accepted. The rest runs until ctx is done.
+func (c *Cluster) Start(ctx context.Context) error {
+	queue := c.queue(c.cfg.InstanceID)
+	relay, err := c.broker.ConsumeExclusive(ctx, relayExchange, queue, queue)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", queue, err)
+	}
+	if err := c.heartbeat(ctx); err != nil {
+		return err
+	}
+	go c.run(ctx, relay)
+	return nil
+}
+
+func (c *Cluster) run(ctx context.Context, relay <-chan amqp.Delivery) {
+	log := c.log.Action("Cluster")
+	ticker := time.NewTicker(time.Duration(max(c.cfg.HeartbeatSec, 1)) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.heartbeat(ctx); err != nil {
+				log.Error("Failed to refresh connection registry", err)
+			}
+		case delivery, ok := <-relay:
+			if !ok {
+				log.Warn("Relay queue closed", "instance_id", c.cfg.InstanceID)
+				return
+			}
+			c.handle(ctx, delivery.Body)
+		}
+	}
+}
+
+func (c *Cluster) heartbeat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.Heartbeat(ctx, c.cfg.InstanceID, c.manager.sessionStates(), c.ttl()); err != nil {
+		return err
+	}
+	connections, err := c.registry.GetConnections(ctx, c.ttl())
+	if err != nil {
+		return err
+	}
+
+	remote := make(map[string]model.DriverConnection, len(connections))
+	for _, connection := range connections {
+		if connection.InstanceId != c.cfg.InstanceID {
+			remote[connection.DriverId] = connection
+		}
+	}
+	c.mu.Lock()
+	c.remote = remote
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Cluster) handle(ctx context.Context, body []byte) {
+	log := c.log.Action("Cluster")
+	var relay messagebrokerdto.WSRelay
+	if err := json.Unmarshal(body, &relay); err != nil {
+		log.Error("Failed to unmarshal relay message", err)
+		return
+	}
+
+	switch relay.Kind {
+	case messagebrokerdto.RelayToDriver:
+		err := c.manager.sendLocal(relay.DriverId, relay.Message)
+		if !errors.Is(err, errNoSession) {
+			return
+		}
+		// the driver moved on since the sender looked, one more hop to where the registry says
+		connection, err := c.lookupRegistry(ctx, relay.DriverId)
+		if err != nil || connection.InstanceId == c.cfg.InstanceID || connection.InstanceId == relay.From {
+			log.Warn("Message for a driver without a session dropped", "driver_id", relay.DriverId)
+			return
+		}
+		relay.From = c.cfg.InstanceID
+		if err := c.publish(ctx, connection.InstanceId, relay); err != nil {
+			log.Error("Failed to relay message", err, "driver_id", relay.DriverId)
+		}
+	case messagebrokerdto.RelayFromDriver:
+		c.manager.FanIn <- dto.DriverMessage{DriverID: relay.DriverId, Message: relay.Message}
+	case messagebrokerdto.RelayTakeover:
+		c.manager.drop(relay.DriverId)
+		log.Info("Driver session taken over by another replica", "driver_id", relay.DriverId, "instance_id", relay.From)
+	default:
+		log.Warn("Unknown relay message", "kind", relay.Kind)
+	}
+}
+
+// send delivers a message to a driver whose session is on another replica
+func (c *Cluster) send(ctx context.Context, driverID string, message any) error {
+	connection, ok := c.lookup(driverID)
+	if !ok {
+		var err error
+		if connection, err = c.lookupRegistry(ctx, driverID); err != nil {
+			return fmt.Errorf("%w: %s", errNoSession, driverID)
+		}
+	}
+	if connection.InstanceId == c.cfg.InstanceID {
+		return fmt.Errorf("%w: %s", errNoSession, driverID)
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return c.publish(ctx, connection.InstanceId, messagebrokerdto.WSRelay{
+		Kind:     messagebrokerdto.RelayToDriver,
+		DriverId: driverID,
+		Message:  messageBytes,
+	})
+}
+
+func (c *Cluster) forward(ctx context.Context, instanceID string, msg dto.DriverMessage) error {
+	return c.publish(ctx, instanceID, messagebrokerdto.WSRelay{
+		Kind:     messagebrokerdto.RelayFromDriver,
+		DriverId: msg.DriverID,
+		Message:  msg.Message,
+	})
+}
+
+func (c *Cluster) isConnected(driverID string) bool {
+	connection, ok := c.lookup(driverID)
+	return ok && connection.Connected
+}
+
+func (c *Cluster) remoteDrivers() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var drivers []string
+	for driverID, connection := range c.remote {
+		if connection.Connected {
+			drivers = append(drivers, driverID)
+		}
+	}
+	return drivers
+}
+
+// registered moves the driver to this replica, the replica that held the session before
+// is told to drop it
+func (c *Cluster) registered(driverID string) {
+	log := c.log.Action("Cluster")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	previous, err := c.registry.Register(ctx, c.cfg.InstanceID, driverID)
+	c.registryMu.Unlock()
+	if err != nil {
+		log.Error("Failed to register driver connection", err, "driver_id", driverID)
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.remote, driverID)
+	c.mu.Unlock()
+
+	if previous != "" && previous != c.cfg.InstanceID {
+		takeover := messagebrokerdto.WSRelay{Kind: messagebrokerdto.RelayTakeover, DriverId: driverID}
+		if err := c.publish(ctx, previous, takeover); err != nil {
+			log.Error("Failed to take over driver session", err, "driver_id", driverID, "instance_id", previous)
+		}
+	}
+}
+
+func (c *Cluster) disconnected(driverID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.SetConnected(ctx, c.cfg.InstanceID, driverID, false); err != nil {
+		c.log.Action("Cluster").Error("Failed to mark driver disconnected", err, "driver_id", driverID)
+	}
+}
+
+func (c *Cluster) removed(driverID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.Remove(ctx, c.cfg.InstanceID, driverID); err != nil {
+		c.log.Action("Cluster").Error("Failed to remove driver connection", err, "driver_id", driverID)
+	}
+}
+
+func (c *Cluster) lookup(driverID string) (model.DriverConnection, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	connection, ok := c.remote[driverID]
+	return connection, ok
+}
+
+// lookupRegistry catches drivers who connected elsewhere since the last heartbeat
+func (c *Cluster) lookupRegistry(ctx context.Context, driverID string) (model.DriverConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	connection, err := c.registry.GetConnection(ctx, driverID, c.ttl())
+	if err != nil && !errors.Is(err, db.ErrConnectionNotFound) {
+		c.log.Action("Cluster").Error("Failed to look up driver connection", err, "driver_id", driverID)
+	}
+	return connection, err
+}
+
+func (c *Cluster) publish(ctx context.Context, instanceID string, relay messagebrokerdto.WSRelay) error {
+	if relay.From == "" {
+		relay.From = c.cfg.InstanceID
+	}
+	return c.broker.PublishJSON(ctx, relayExchange, c.queue(instanceID), relay)
+}
+
+func (c *Cluster) queue(instanceID string) string {
+	return "ws.instance." + instanceID
+}
+
+func (c *Cluster) ttl() time.Duration {
+	return time.Duration(c.cfg.TTLSec) * time.Second
+}
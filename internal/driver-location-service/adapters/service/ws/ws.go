@@ -2,124 +2,303 @@ package ws
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"ride-hail/internal/config"
 	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
 	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
 
 	"github.com/gorilla/websocket"
 )
 
+// outbox room on top of the replay buffer, a resumed connection gets the whole buffer at once
+const outboxHeadroom = 100
+
+var errNoSession = errors.New("driver not connected or not authenticated")
+
 type WebSocketManager struct {
-	connections map[string]*DriverConnection
+	connections map[string]*DriverConnection // connection id -> connection, before and after auth
+	sessions    map[string]*DriverSession    // driver id -> session its connections resume
 	FanIn       chan dto.DriverMessage
+	cfg         *config.Resumeconfig
+	cluster     *Cluster // nil when the replica runs alone
 	mu          sync.RWMutex
 }
 
 type DriverConnection struct {
-	DriverID   string
-	Conn       *websocket.Conn
-	fromDriver <-chan []byte // Сообщения ОТ драйвера К дистрибьютору
-	toDriver   chan<- []byte // Сообщения ОТ дистрибьютора К драйверу
-	Auth       bool
-	LastPing   time.Time
-	SessionID  string
-	mu         sync.Mutex
+	ConnectionID string
+	DriverID     string
+	Conn         *websocket.Conn
+	fromDriver   <-chan []byte // Сообщения ОТ драйвера К дистрибьютору
+	toDriver     chan<- []byte // Сообщения ОТ дистрибьютора К драйверу
+	Auth         bool
+	LastPing     time.Time
+}
+
+// DriverSession outlives the connections of the driver. Outbound messages are numbered and
+// the last BufferSize of them are kept, a connection presenting the resume token within
+// TTLSec of the disconnect gets the ones it missed and takes the session over.
+type DriverSession struct {
+	DriverID       string
+	SessionID      string
+	ResumeToken    string
+	current        *DriverConnection // nil while the driver is disconnected
+	lastSeq        uint64
+	buffer         []sequencedMessage
+	disconnectedAt time.Time
+	mu             sync.Mutex
 }
 
-func NewWebSocketManager() *WebSocketManager {
+type sequencedMessage struct {
+	seq     uint64
+	payload []byte
+}
+
+func NewWebSocketManager(cfg *config.Resumeconfig) *WebSocketManager {
 	return &WebSocketManager{
 		connections: make(map[string]*DriverConnection),
+		sessions:    make(map[string]*DriverSession),
 		FanIn:       make(chan dto.DriverMessage, 1000),
+		cfg:         cfg,
 	}
 }
 
-func (m *WebSocketManager) RegisterDriver(ctx context.Context, driverID string, incoming <-chan []byte, outgoing chan<- []byte) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if existing, exists := m.connections[driverID]; exists {
-		existing.Conn.Close()
+// Run drops sessions that were not resumed in time until ctx is done
+func (m *WebSocketManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(max(m.cfg.TTLSec, 1)) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			for driverID, session := range m.sessions {
+				session.mu.Lock()
+				expired := m.expired(session)
+				session.mu.Unlock()
+				if expired {
+					delete(m.sessions, driverID)
+					if m.cluster != nil {
+						go m.cluster.removed(driverID)
+					}
+				}
+			}
+			m.mu.Unlock()
+		}
 	}
+}
 
-	m.connections[driverID] = &DriverConnection{
-		DriverID:   driverID,
-		fromDriver: incoming,
-		toDriver:   outgoing,
-		Auth:       false,
-		LastPing:   time.Now(),
-		SessionID:  fmt.Sprintf("session_%s_%d", driverID, time.Now().Unix()),
+// OutboxSize is the capacity the outgoing channel of a connection needs
+func (m *WebSocketManager) OutboxSize() int {
+	return max(m.cfg.BufferSize, 0) + outboxHeadroom
+}
+
+// RegisterDriver adds a connection that is not authenticated yet, the current connection
+// of the driver is only replaced once this one authenticates
+func (m *WebSocketManager) RegisterDriver(ctx context.Context, driverID string, incoming <-chan []byte, outgoing chan<- []byte) (string, error) {
+	connectionID, err := newToken()
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connections[connectionID] = &DriverConnection{
+		ConnectionID: connectionID,
+		DriverID:     driverID,
+		fromDriver:   incoming,
+		toDriver:     outgoing,
+		Auth:         false,
+		LastPing:     time.Now(),
+	}
+	return connectionID, nil
 }
 
-func (m *WebSocketManager) UnregisterDriver(ctx context.Context, driverID string) {
+// UnregisterDriver removes the connection, its session stays resumable when it was the
+// current one and is left alone when another connection took it over
+func (m *WebSocketManager) UnregisterDriver(ctx context.Context, connectionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if conn, exists := m.connections[driverID]; exists {
+	conn, exists := m.connections[connectionID]
+	if !exists {
+		return
+	}
+	if conn.Conn != nil {
 		conn.Conn.Close()
-		delete(m.connections, driverID)
+	}
+	delete(m.connections, connectionID)
+
+	if session, exists := m.sessions[conn.DriverID]; exists {
+		session.mu.Lock()
+		if session.current == conn {
+			session.current = nil
+			session.disconnectedAt = time.Now()
+			if m.cluster != nil {
+				go m.cluster.disconnected(conn.DriverID)
+			}
+		}
+		session.mu.Unlock()
 	}
 }
 
+// Authenticate makes the connection the current one of the driver. With the resume token of
+// a live session the messages after lastSeq are replayed, otherwise a new session starts.
+// auth_success and the replay are queued before any new message.
+func (m *WebSocketManager) Authenticate(connectionID, resumeToken string, lastSeq uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conn, exists := m.connections[connectionID]
+	if !exists {
+		return fmt.Errorf("connection not registered: %s", connectionID)
+	}
+
+	session, resumed := m.sessions[conn.DriverID]
+	var previous *DriverConnection
+	if resumed {
+		session.mu.Lock()
+		previous = session.current
+		if m.expired(session) || resumeToken == "" || subtle.ConstantTimeCompare([]byte(resumeToken), []byte(session.ResumeToken)) != 1 {
+			session.current = nil
+			session.mu.Unlock()
+			resumed = false
+		} else {
+			defer session.mu.Unlock()
+		}
+	}
+	if !resumed {
+		token, err := newToken()
+		if err != nil {
+			return err
+		}
+		session = &DriverSession{
+			DriverID:    conn.DriverID,
+			SessionID:   fmt.Sprintf("session_%s_%d", conn.DriverID, time.Now().Unix()),
+			ResumeToken: token,
+		}
+		m.sessions[conn.DriverID] = session
+		session.mu.Lock()
+		defer session.mu.Unlock()
+	}
+
+	conn.Auth = true
+	conn.LastPing = time.Now()
+	session.current = conn
+
+	reply := websocketdto.AuthSuccessMessage{
+		WebSocketMessage: websocketdto.WebSocketMessage{Type: websocketdto.MessageTypeAuthSuccess},
+		SessionID:        session.SessionID,
+		ResumeToken:      session.ResumeToken,
+		Resumed:          resumed,
+		LastSeq:          session.lastSeq,
+	}
+	var replay [][]byte
+	if resumed && lastSeq < session.lastSeq {
+		for _, message := range session.buffer {
+			if message.seq > lastSeq {
+				replay = append(replay, message.payload)
+			}
+		}
+		reply.Replayed = len(replay)
+		reply.Gap = len(session.buffer) == 0 || session.buffer[0].seq > lastSeq+1
+	}
+
+	replyBytes, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	conn.enqueue(replyBytes)
+	for _, payload := range replay {
+		conn.enqueue(payload)
+	}
+
+	if previous != nil && previous != conn {
+		previous.takeOver()
+	}
+	if m.cluster != nil {
+		go m.cluster.registered(conn.DriverID)
+	}
+	return nil
+}
+
 func (m *WebSocketManager) IsDriverConnected(driverID string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	conn, exists := m.connections[driverID]
-	return exists && conn.Auth && time.Since(conn.LastPing) < 60*time.Second
+	if conn := m.current(driverID); conn != nil {
+		return time.Since(conn.LastPing) < 60*time.Second
+	}
+	return m.cluster != nil && m.cluster.isConnected(driverID)
 }
 
+// SendToDriver numbers the message and keeps it for replay. A driver within the resume
+// window gets it on reconnect, a driver whose session is on another replica gets it
+// through that replica, so only a driver without a live session is an error.
 func (m *WebSocketManager) SendToDriver(ctx context.Context, driverID string, message any) error {
+	err := m.sendLocal(driverID, message)
+	if errors.Is(err, errNoSession) && m.cluster != nil {
+		return m.cluster.send(ctx, driverID, message)
+	}
+	return err
+}
+
+func (m *WebSocketManager) sendLocal(driverID string, message any) error {
 	m.mu.RLock()
-	conn, exists := m.connections[driverID]
+	session, exists := m.sessions[driverID]
 	m.mu.RUnlock()
 
-	if !exists || !conn.Auth {
-		return fmt.Errorf("driver not connected or not authenticated: %s", driverID)
+	if !exists {
+		return fmt.Errorf("%w: %s", errNoSession, driverID)
 	}
 
-	messageBytes, err := json.Marshal(message)
-	fmt.Println("Sending to driver:", driverID, "message:", string(messageBytes))
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if m.expired(session) {
+		return fmt.Errorf("%w: %s", errNoSession, driverID)
+	}
+	messageBytes, err := withSeq(message, session.lastSeq+1)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
+	session.lastSeq++
+	if m.cfg.BufferSize > 0 {
+		session.buffer = append(session.buffer, sequencedMessage{seq: session.lastSeq, payload: messageBytes})
+		if len(session.buffer) > m.cfg.BufferSize {
+			session.buffer = session.buffer[len(session.buffer)-m.cfg.BufferSize:]
+		}
+	}
 
-	conn.mu.Lock()
-	defer conn.mu.Unlock()
-	conn.toDriver <- messageBytes
-	// return conn.Conn.WriteMessage(websocket.TextMessage, messageBytes)
+	if session.current != nil {
+		session.current.enqueue(messageBytes)
+	}
 	return nil
 }
 
-func (m *WebSocketManager) SetConnection(driverID string, conn *websocket.Conn) {
+func (m *WebSocketManager) SetConnection(connectionID string, conn *websocket.Conn) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if driverConn, exists := m.connections[driverID]; exists {
+	if driverConn, exists := m.connections[connectionID]; exists {
 		driverConn.Conn = conn
 	}
 }
 
-func (m *WebSocketManager) SetAuthenticated(driverID string, authenticated bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if conn, exists := m.connections[driverID]; exists {
-		conn.Auth = authenticated
-	}
-}
-
-func (m *WebSocketManager) UpdatePing(driverID string) {
+func (m *WebSocketManager) UpdatePing(connectionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if conn, exists := m.connections[driverID]; exists {
+	if conn, exists := m.connections[connectionID]; exists {
 		conn.LastPing = time.Now()
 	}
 }
@@ -128,8 +307,8 @@ func (m *WebSocketManager) GetConnectionStatus(driverID string) *websocketdto.Co
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	conn, exists := m.connections[driverID]
-	if !exists {
+	conn := m.current(driverID)
+	if conn == nil {
 		return &websocketdto.ConnectionStatus{
 			DriverID:  driverID,
 			Connected: false,
@@ -138,22 +317,35 @@ func (m *WebSocketManager) GetConnectionStatus(driverID string) *websocketdto.Co
 
 	return &websocketdto.ConnectionStatus{
 		DriverID:  driverID,
-		Connected: conn.Auth && time.Since(conn.LastPing) < 60*time.Second,
+		Connected: time.Since(conn.LastPing) < 60*time.Second,
 		LastPing:  conn.LastPing,
-		SessionID: conn.SessionID,
+		SessionID: m.sessions[driverID].SessionID,
 	}
 }
 
+// HasRemoteDrivers reports whether other replicas hold driver sessions. Their locations
+// reach Postgres but not the driver index of this replica.
+func (m *WebSocketManager) HasRemoteDrivers() bool {
+	return m.cluster != nil && len(m.cluster.remoteDrivers()) > 0
+}
+
 func (m *WebSocketManager) GetConnectedDrivers() []string {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	var drivers []string
-	for driverID, conn := range m.connections {
-		if conn.Auth && time.Since(conn.LastPing) < 60*time.Second {
+	for driverID := range m.sessions {
+		if conn := m.current(driverID); conn != nil && time.Since(conn.LastPing) < 60*time.Second {
 			drivers = append(drivers, driverID)
 		}
 	}
+	if m.cluster != nil {
+		for _, driverID := range m.cluster.remoteDrivers() {
+			if _, local := m.sessions[driverID]; !local {
+				drivers = append(drivers, driverID)
+			}
+		}
+	}
 	return drivers
 }
 
@@ -161,8 +353,8 @@ func (m *WebSocketManager) GetDriverMessages(driverID string) (<-chan []byte, er
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	conn, exists := m.connections[driverID]
-	if !exists {
+	conn := m.current(driverID)
+	if conn == nil {
 		return nil, fmt.Errorf("driver not connected: %s", driverID)
 	}
 
@@ -179,3 +371,115 @@ func (m *WebSocketManager) GetDriversCount(ctx context.Context) int {
 func (m *WebSocketManager) GetFanIn() <-chan dto.DriverMessage {
 	return m.FanIn
 }
+
+// InstanceID names this replica, empty when it runs alone
+func (m *WebSocketManager) InstanceID() string {
+	if m.cluster == nil {
+		return ""
+	}
+	return m.cluster.cfg.InstanceID
+}
+
+// ForwardToInstance hands a message of a driver connected here to the replica that waits for it
+func (m *WebSocketManager) ForwardToInstance(ctx context.Context, instanceID string, msg dto.DriverMessage) error {
+	if m.cluster == nil {
+		return fmt.Errorf("replica %s is unknown, running alone", instanceID)
+	}
+	return m.cluster.forward(ctx, instanceID, msg)
+}
+
+// drop ends the session of a driver who connected to another replica
+func (m *WebSocketManager) drop(driverID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[driverID]
+	if !exists {
+		return
+	}
+	delete(m.sessions, driverID)
+	session.mu.Lock()
+	current := session.current
+	session.current = nil
+	session.mu.Unlock()
+	if current != nil {
+		current.takeOver()
+	}
+}
+
+// sessionStates lists the sessions held here for the connection registry
+func (m *WebSocketManager) sessionStates() []model.DriverConnection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make([]model.DriverConnection, 0, len(m.sessions))
+	for driverID, session := range m.sessions {
+		session.mu.Lock()
+		if !m.expired(session) {
+			states = append(states, model.DriverConnection{DriverId: driverID, Connected: session.current != nil})
+		}
+		session.mu.Unlock()
+	}
+	return states
+}
+
+// current returns the authenticated connection of the driver, m.mu has to be held
+func (m *WebSocketManager) current(driverID string) *DriverConnection {
+	session, exists := m.sessions[driverID]
+	if !exists {
+		return nil
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.current
+}
+
+// expired tells if a disconnected session can no longer be resumed, session.mu has to be held
+func (m *WebSocketManager) expired(session *DriverSession) bool {
+	return session.current == nil && !session.disconnectedAt.IsZero() &&
+		time.Since(session.disconnectedAt) > time.Duration(m.cfg.TTLSec)*time.Second
+}
+
+// enqueue never blocks the sender, a connection that cannot keep up is closed and the
+// driver resumes with the buffered messages
+func (c *DriverConnection) enqueue(message []byte) {
+	select {
+	case c.toDriver <- message:
+	default:
+		if c.Conn != nil {
+			c.Conn.Close()
+		}
+	}
+}
+
+// takeOver closes a connection replaced by a newer one of the same driver
+func (c *DriverConnection) takeOver() {
+	if c.Conn == nil {
+		return
+	}
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session taken over by another connection")
+	c.Conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+	c.Conn.Close()
+}
+
+// withSeq adds the sequence number to the JSON object of the message
+func withSeq(message any, seq uint64) ([]byte, error) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(messageBytes, &fields); err != nil {
+		return nil, err
+	}
+	fields["seq"], _ = json.Marshal(seq)
+	return json.Marshal(fields)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
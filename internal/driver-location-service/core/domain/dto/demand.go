@@ -0,0 +1,24 @@
+package dto
+
+type DemandMap struct {
+	GeneratedAt   string             `json:"generated_at"`
+	CellSizeDeg   float64            `json:"cell_size_deg"`
+	WindowMinutes int                `json:"window_minutes"`
+	Cells         []DemandCell       `json:"cells"`
+	Suggestions   []RepositionAdvice `json:"suggestions"`
+}
+
+type DemandCell struct {
+	Latitude            float64 `json:"latitude"` // cell center
+	Longitude           float64 `json:"longitude"`
+	OpenRequests        int     `json:"open_requests"`
+	Demand              float64 `json:"demand"` // pickups over the window
+	Supply              int     `json:"supply"` // available drivers in and around the cell
+	ExpectedWaitMinutes float64 `json:"expected_wait_minutes"`
+}
+
+type RepositionAdvice struct {
+	DemandCell
+	DistanceKm float64 `json:"distance_km"`
+	Shortage   float64 `json:"shortage"`
+}
@@ -0,0 +1,19 @@
+package dto
+
+type SetDestination struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address"`
+}
+
+type DestinationResponse struct {
+	Active        bool    `json:"active"`
+	DestinationId string  `json:"destination_id,omitempty"`
+	Latitude      float64 `json:"latitude,omitempty"`
+	Longitude     float64 `json:"longitude,omitempty"`
+	Address       string  `json:"address,omitempty"`
+	ActivatedAt   string  `json:"activated_at,omitempty"`
+	UsesToday     int     `json:"uses_today"`
+	UsesPerDay    int     `json:"uses_per_day"`
+	MinProgress   float64 `json:"min_progress"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+type DriverDocument struct {
+	DocumentId   string `json:"document_id"`
+	DocumentType string `json:"document_type"`
+	Status       string `json:"status"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	SizeBytes    int64  `json:"size_bytes"`
+	ExpiresAt    string `json:"expires_at"`
+	Expired      bool   `json:"expired"`
+	UploadedAt   string `json:"uploaded_at"`
+	ReviewedAt   string `json:"reviewed_at,omitempty"`
+	ReviewNote   string `json:"review_note,omitempty"`
+}
+
+// DriverDocuments is the verification state of the driver, missing lists the required
+// types without an approved document and expired the ones whose approved document expired
+type DriverDocuments struct {
+	DriverId   string           `json:"driver_id"`
+	IsVerified bool             `json:"is_verified"`
+	CanDrive   bool             `json:"can_drive"`
+	Missing    []string         `json:"missing"`
+	Expired    []string         `json:"expired"`
+	Documents  []DriverDocument `json:"documents"`
+}
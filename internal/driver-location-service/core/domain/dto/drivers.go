@@ -4,15 +4,17 @@ import "time"
 
 // ONLINE MODE
 type DriverCoordinatesDTO struct {
-	Driver_id string  `json:"driver_id"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Driver_id  string  `json:"driver_id"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Vehicle_id string  `json:"vehicle_id"`
 }
 
 type DriverOnlineResponse struct {
-	Status     string `json:"status"`
-	Session_id string `json:"session_id"`
-	Message    string `json:"message"`
+	Status     string   `json:"status"`
+	Session_id string   `json:"session_id"`
+	Vehicle    *Vehicle `json:"vehicle,omitempty"`
+	Message    string   `json:"message"`
 }
 
 // OFFLINE MODE
@@ -36,6 +38,26 @@ type StartRide struct {
 	Driver_location DriverCoordinatesDTO `json:"driver_location"`
 }
 
+// CANCEL RIDE
+type CancelRide struct {
+	Ride_id string `json:"ride_id"` // optional, the active ride of the driver by default
+	Reason  string `json:"reason"`
+}
+
+type CancelRideResponse struct {
+	Ride_id      string `json:"ride_id"`
+	Status       string `json:"status"`
+	Cancelled_at string `json:"cancelled_at"`
+	Message      string `json:"message"`
+}
+
+type ArrivedResponse struct {
+	Ride_id    string `json:"ride_id"`
+	Status     string `json:"status"`
+	Arrived_at string `json:"arrived_at"`
+	Message    string `json:"message"`
+}
+
 type StartRideResponse struct {
 	Ride_id    string `json:"ride_id"`
 	Status     string `json:"status"`
@@ -55,6 +77,32 @@ type NewLocation struct {
 type NewLocationResponse struct {
 	Coordinate_id string    `json:"coordinate_id"`
 	Updated_at    time.Time `json:"updated_at"`
+	Latitude      float64   `json:"latitude"`  // smoothed
+	Longitude     float64   `json:"longitude"` // smoothed
+}
+
+// LocationStats counts the location updates of a driver since the service started
+type LocationStats struct {
+	DriverId string           `json:"driver_id"`
+	Received int64            `json:"received"`
+	Accepted int64            `json:"accepted"`
+	Rejected int64            `json:"rejected"`
+	Reasons  map[string]int64 `json:"rejected_by_reason"`
+}
+
+type LocationWriterMetrics struct {
+	QueueLength      int     `json:"queue_length"`
+	QueueCapacity    int     `json:"queue_capacity"`
+	Written          int64   `json:"written_total"`
+	Failed           int64   `json:"failed_total"`
+	Refused          int64   `json:"refused_total"`
+	Batches          int64   `json:"batches_total"`
+	AvgBatchSize     float64 `json:"avg_batch_size"`
+	AvgFlushMs       float64 `json:"avg_flush_ms"`
+	MaxFlushMs       float64 `json:"max_flush_ms"`
+	AvgWaitMs        float64 `json:"avg_wait_ms"`
+	MaxWaitMs        float64 `json:"max_wait_ms"`
+	ThroughputPerSec float64 `json:"throughput_per_sec"`
 }
 
 // Complete Ride
@@ -140,20 +188,26 @@ type RideStatusUpdate struct {
 
 // Driver Info
 type DriverInfo struct {
-	DriverId  string
-	Name      string `json:"name"`
-	Email     string
-	Vehicle   VehicleDetail `json:"vehicle"`
-	Rating    float64       `json:"rating"`
-	Latitude  float64
-	Longitude float64
-	Distance  float64
+	DriverId   string
+	Name       string `json:"name"`
+	Email      string
+	Vehicle    VehicleDetail `json:"vehicle"`
+	Rating     float64       `json:"rating"`
+	Latitude   float64
+	Longitude  float64
+	Distance   float64 // road distance to pickup, km
+	EtaMinutes int     // road duration to pickup
+	Score      float64 // driver quality in [0, 1]
+	Rank       float64 // proximity and quality, higher is better
 }
 type VehicleDetail struct {
+	Type  string `json:"type,omitempty"`
 	Make  string `json:"make"`
 	Model string `json:"model"`
+	Year  int    `json:"year,omitempty"`
 	Color string `json:"color"`
 	Plate string `json:"plate"`
+	Seats int    `json:"seats,omitempty"`
 }
 
 // Driver Match Response
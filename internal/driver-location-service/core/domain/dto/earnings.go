@@ -0,0 +1,22 @@
+package dto
+
+type EarningsStatement struct {
+	DriverId string         `json:"driver_id"`
+	Period   string         `json:"period"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Lines    []EarningsLine `json:"lines"`
+	Total    EarningsLine   `json:"total"`
+}
+
+type EarningsLine struct {
+	SessionId     string  `json:"session_id,omitempty"`
+	Start         string  `json:"start,omitempty"`
+	End           string  `json:"end,omitempty"`
+	DurationHours float64 `json:"duration_hours,omitempty"`
+	Rides         int     `json:"rides"`
+	GrossFare     float64 `json:"gross_fare"`
+	Commission    float64 `json:"commission"`
+	Tips          float64 `json:"tips"`
+	Net           float64 `json:"net"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type DriverHours struct {
+	DriverId        string  `json:"driver_id"`
+	Jurisdiction    string  `json:"jurisdiction"`
+	ShiftStartedAt  string  `json:"shift_started_at,omitempty"`
+	OnlineHours     float64 `json:"online_hours"`
+	DrivingHours    float64 `json:"driving_hours"`
+	MaxOnlineHours  float64 `json:"max_online_hours"`
+	MaxDrivingHours float64 `json:"max_driving_hours"`
+	BreakMinutes    int     `json:"break_minutes"`
+	MinutesLeft     float64 `json:"minutes_left"`
+	LimitReached    bool    `json:"limit_reached"`
+	BreakEndsAt     string  `json:"break_ends_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+type OfferStats struct {
+	DriverId       string         `json:"driver_id"`
+	WindowDays     int            `json:"window_days"`
+	Sent           int            `json:"offers_sent"`
+	Accepted       int            `json:"accepted"`
+	Declined       int            `json:"declined"`
+	Expired        int            `json:"expired"`
+	Withdrawn      int            `json:"withdrawn"`
+	AcceptanceRate float64        `json:"acceptance_rate"`
+	DeclineRate    float64        `json:"decline_rate"`
+	DeclineReasons map[string]int `json:"decline_reasons"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+// VehicleRequest is used for both create and update, on update nil fields are left unchanged
+type VehicleRequest struct {
+	VehicleType  *string   `json:"vehicle_type"`
+	Make         *string   `json:"make"`
+	Model        *string   `json:"model"`
+	Year         *int      `json:"year"`
+	Color        *string   `json:"color"`
+	Plate        *string   `json:"plate"`
+	Seats        *int      `json:"seats"`
+	Capabilities *[]string `json:"capabilities"`
+	OptInTypes   *[]string `json:"opt_in_types"`
+}
+
+type Vehicle struct {
+	VehicleId    string   `json:"vehicle_id"`
+	VehicleType  string   `json:"vehicle_type"`
+	Make         string   `json:"make"`
+	Model        string   `json:"model"`
+	Year         *int     `json:"year,omitempty"`
+	Color        string   `json:"color"`
+	Plate        string   `json:"plate"`
+	Seats        int      `json:"seats"`
+	Capabilities []string `json:"capabilities"`
+	OptInTypes   []string `json:"opt_in_types"`
+	RideTypes    []string `json:"ride_types"` // what the vehicle is matched for under the current rules
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+type Vehicles struct {
+	Vehicles   []Vehicle `json:"vehicles"`
+	TotalCount int       `json:"total_count"`
+}
@@ -25,3 +25,17 @@ type RideAcceptance struct {
 	DriverInfo              DriverInfo `json:"driver_info"`
 	EstimatedArrival        time.Time  `json:"estimated_arrival"`
 }
+
+// DriverStatusUpdateVersion is bumped on incompatible changes of DriverStatusUpdate
+const DriverStatusUpdateVersion = 1
+
+// Driver Status Update → driver_topic exchange → driver.status.{driver_id}
+type DriverStatusUpdate struct {
+	Version   int    `json:"version"`
+	EventId   string `json:"event_id"` // one per ride transition, safe to dedupe on
+	DriverId  string `json:"driver_id"`
+	Status    string `json:"status"`
+	RideId    string `json:"ride_id"`
+	Reason    string `json:"reason,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
@@ -0,0 +1,18 @@
+package messagebrokerdto
+
+import "encoding/json"
+
+// Kinds of WSRelay messages
+const (
+	RelayToDriver   = "to_driver"   // deliver Message to the driver's session
+	RelayFromDriver = "from_driver" // Message came from the driver, handle it here
+	RelayTakeover   = "takeover"    // the driver connected to another replica, drop the session
+)
+
+// WSRelay ← ws_relay exchange ← ws.instance.{instance_id}
+type WSRelay struct {
+	Kind     string          `json:"kind"`
+	From     string          `json:"from"` // sending replica
+	DriverId string          `json:"driver_id"`
+	Message  json.RawMessage `json:"message,omitempty"`
+}
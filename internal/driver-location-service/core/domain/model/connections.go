@@ -0,0 +1,8 @@
+package model
+
+// DriverConnection is the replica holding the WebSocket session of a driver
+type DriverConnection struct {
+	DriverId   string
+	InstanceId string
+	Connected  bool // false while the session waits to be resumed
+}
@@ -0,0 +1,10 @@
+package model
+
+// DemandCell counts the pickups of one grid cell, the three counts do not overlap
+type DemandCell struct {
+	Lat              int // cell index, floor(latitude / cell size)
+	Lng              int
+	OpenRequests     int // REQUESTED right now
+	RecentRequests   int // requested within the window, no longer REQUESTED
+	CompletedPickups int // completed within the window, requested before it
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// DriverDestination is the target of a driver in destination mode
+type DriverDestination struct {
+	DestinationId string
+	DriverId      string
+	Latitude      float64
+	Longitude     float64
+	Address       string
+	CreatedAt     time.Time
+}
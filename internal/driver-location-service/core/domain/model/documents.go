@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+const (
+	DocumentLicense             = "LICENSE"
+	DocumentInsurance           = "INSURANCE"
+	DocumentVehicleRegistration = "VEHICLE_REGISTRATION"
+
+	DocumentStatusPending  = "PENDING"
+	DocumentStatusApproved = "APPROVED"
+	DocumentStatusRejected = "REJECTED"
+)
+
+// RequiredDocuments have to be approved and unexpired for a driver to go online
+var RequiredDocuments = []string{DocumentLicense, DocumentInsurance, DocumentVehicleRegistration}
+
+type DriverDocument struct {
+	DocumentId   string
+	DriverId     string
+	DocumentType string
+	Status       string
+	FileKey      string
+	FileName     string
+	ContentType  string
+	SizeBytes    int64
+	ExpiresAt    time.Time
+	UploadedAt   time.Time
+	ReviewedAt   *time.Time
+	ReviewNote   string
+}
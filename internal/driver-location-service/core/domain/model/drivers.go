@@ -4,9 +4,10 @@ import "time"
 
 // Online Mode
 type DriverCoordinates struct {
-	Driver_id string
-	Latitude  float64
-	Longitude float64
+	Driver_id  string
+	Latitude   float64
+	Longitude  float64
+	Vehicle_id string // vehicle of the session, empty for the profile vehicle
 }
 
 // Offline Mode
@@ -50,9 +51,26 @@ type NewLocationResponse struct {
 	Updated_at    time.Time
 }
 
+// LocationWriterStats of the batched location writer since the service started
+type LocationWriterStats struct {
+	QueueLength      int
+	QueueCapacity    int
+	Written          int64
+	Failed           int64
+	Refused          int64 // queue stayed full, backpressure
+	Batches          int64
+	AvgBatchSize     float64
+	AvgFlushMs       float64
+	MaxFlushMs       float64
+	AvgWaitMs        float64 // from enqueue to commit
+	MaxWaitMs        float64
+	ThroughputPerSec float64 // over the last minute
+}
+
 // Complete Ride
 type RideCompleteForm struct {
 	Ride_id          string
+	Driver_id        string
 	FinalLocation    Location
 	ActualDistancekm float64
 	ActualDurationm  float64
@@ -71,6 +89,7 @@ type RideCompleteResponse struct {
 	CompletedAt   string
 	DriverEarning float64
 	Message       string
+	Completed     bool // false when the ride was completed before
 }
 
 // DriverInfo
@@ -85,6 +104,20 @@ type DriverInfo struct {
 	Distance  float64
 }
 
+// LiveDriver is a driver with its current position, as kept in the live index
+type LiveDriver struct {
+	DriverId    string
+	Name        string
+	Email       string
+	Vehicle     []byte
+	VehicleType string   // of the active vehicle
+	OptInTypes  []string // other ride types the active vehicle takes
+	Status      string
+	Rating      float64
+	Latitude    float64
+	Longitude   float64
+}
+
 // RideDetails for WebSocket
 type RideDetails struct {
 	Ride_id        string
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	EarningsPeriodDay     = "day"
+	EarningsPeriodWeek    = "week"
+	EarningsPeriodSession = "session"
+)
+
+// RideEarning is what the driver made on one completed ride
+type RideEarning struct {
+	RideId         string
+	SessionId      *string
+	GrossFare      float64
+	CommissionRate float64
+	Commission     float64
+	Tip            float64
+	Net            float64
+	EarnedAt       time.Time
+}
+
+// EarningsLine sums the earnings of one day, week or session
+type EarningsLine struct {
+	SessionId  *string // session statements only
+	Start      time.Time
+	End        *time.Time // nil for a session that is still open
+	Rides      int
+	GrossFare  float64
+	Commission float64
+	Tips       float64
+	Net        float64
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// HistoryPartition is a daily partition of location_history
+type HistoryPartition struct {
+	Name string
+	Day  time.Time
+}
+
+type TrackPoint struct {
+	Latitude   float64
+	Longitude  float64
+	RecordedAt time.Time
+}
+
+// RideTrack is the downsampled path of a completed ride
+type RideTrack struct {
+	RideId         string
+	Polyline       string
+	PointsCount    int
+	RawPointsCount int
+	DistanceKm     float64
+	StartedAt      *time.Time
+	EndedAt        *time.Time
+}
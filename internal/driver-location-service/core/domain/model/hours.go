@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const DefaultJurisdiction = "DEFAULT"
+
+// ShiftSession is a driver session, EndedAt is now for the open one
+type ShiftSession struct {
+	DriverId     string
+	Jurisdiction string
+	StartedAt    time.Time
+	EndedAt      time.Time
+}
+
+type DriverBreak struct {
+	BreakId      string
+	DriverId     string
+	Reason       string
+	OnlineHours  float64
+	DrivingHours float64
+	StartedAt    time.Time
+	EndsAt       time.Time
+}
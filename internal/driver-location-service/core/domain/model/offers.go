@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	OfferStatusSent      = "SENT"
+	OfferStatusAccepted  = "ACCEPTED"
+	OfferStatusDeclined  = "DECLINED"
+	OfferStatusExpired   = "EXPIRED"
+	OfferStatusWithdrawn = "WITHDRAWN"
+	OfferStatusFailed    = "FAILED" // could not be delivered to the driver
+)
+
+type RideOffer struct {
+	OfferId       string
+	RideId        string
+	DriverId      string
+	Status        string
+	Strategy      string
+	InstanceId    string // replica waiting for the answer
+	DeclineReason string
+	SentAt        time.Time
+	ExpiresAt     time.Time
+	RespondedAt   *time.Time
+}
+
+type OfferStats struct {
+	Sent           int
+	Accepted       int
+	Declined       int
+	Expired        int
+	Withdrawn      int
+	DeclineReasons map[string]int
+}
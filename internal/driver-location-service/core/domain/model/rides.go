@@ -39,3 +39,27 @@ type Coordinates struct {
 	DurationMinutes float64
 	IsCurrent       bool
 }
+
+const (
+	RideStatusMatched    = "MATCHED"
+	RideStatusEnRoute    = "EN_ROUTE"
+	RideStatusArrived    = "ARRIVED"
+	RideStatusInProgress = "IN_PROGRESS"
+	RideStatusCompleted  = "COMPLETED"
+	RideStatusCancelled  = "CANCELLED"
+)
+
+// ActiveRide is the ride the driver has not finished yet
+type ActiveRide struct {
+	Ride_id string
+	Status  string
+}
+
+// PickupRide is the ride a driver is heading to, before the passenger is picked up
+type PickupRide struct {
+	Ride_id         string
+	Status          string
+	PickupLatitude  float64
+	PickupLongitude float64
+	ArrivedAt       *time.Time
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type DriverScore struct {
+	DriverId         string
+	VehicleType      string
+	AcceptanceRate   float64
+	CancellationRate float64
+	CompletionRate   float64
+	Rating           float64
+	OffersCount      int
+	RidesCount       int
+	WindowDays       int
+	ComputedAt       *time.Time // nil until the first refresh
+}
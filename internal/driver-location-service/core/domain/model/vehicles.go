@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type Vehicle struct {
+	VehicleId    string
+	DriverId     string
+	VehicleType  string
+	Make         string
+	Model        string
+	Year         *int
+	Color        string
+	Plate        string
+	Seats        int
+	Capabilities []string
+	OptInTypes   []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+const (
+	VehicleTypeEconomy = "ECONOMY"
+	VehicleTypePremium = "PREMIUM"
+	VehicleTypeXL      = "XL"
+)
+
+var VehicleTypes = []string{VehicleTypeEconomy, VehicleTypePremium, VehicleTypeXL}
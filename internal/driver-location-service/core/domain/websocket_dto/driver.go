@@ -1,17 +1,26 @@
 package websocketdto
 
-import "time"
+import (
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
 
 // WebSocket message types
 const (
 	MessageTypeAuth           = "auth"
+	MessageTypeAuthSuccess    = "auth_success"
 	MessageTypeRideOffer      = "ride_offer"
 	MessageTypeRideResponse   = "ride_response"
 	MessageTypeLocationUpdate = "location_update"
 	MessageTypeRideDetails    = "ride_details"
+	MessageTypeOfferWithdrawn = "offer_withdrawn"
 	MessageTypePing           = "ping"
 	MessageTypePong           = "pong"
 	MessageTypeError          = "error"
+	MessageTypeDemandMap      = "demand_map"
+	MessageTypeHoursWarning   = "hours_warning"
+	MessageTypeHoursLimit     = "hours_limit_reached"
 )
 
 // Base message structure
@@ -19,10 +28,24 @@ type WebSocketMessage struct {
 	Type string `json:"type"`
 }
 
-// Authentication
+// Authentication, resume_token and last_seq of the previous connection resume its session
 type AuthMessage struct {
 	WebSocketMessage
-	Token string `json:"token"`
+	Token       string `json:"token"`
+	ResumeToken string `json:"resume_token,omitempty"`
+	LastSeq     uint64 `json:"last_seq,omitempty"`
+}
+
+// Reply to auth. Outbound messages carry a seq, on a resumed session the messages after
+// last_seq follow this one, gap means some of them were no longer kept.
+type AuthSuccessMessage struct {
+	WebSocketMessage
+	SessionID   string `json:"session_id"`
+	ResumeToken string `json:"resume_token"`
+	Resumed     bool   `json:"resumed"`
+	Replayed    int    `json:"replayed"`
+	Gap         bool   `json:"gap,omitempty"`
+	LastSeq     uint64 `json:"last_seq"`
 }
 
 // Ride offer to driver
@@ -46,9 +69,18 @@ type RideResponseMessage struct {
 	OfferID         string   `json:"offer_id"`
 	RideID          string   `json:"ride_id"`
 	Accepted        bool     `json:"accepted"`
+	DeclineReason   string   `json:"decline_reason,omitempty"`
 	CurrentLocation Location `json:"current_location,omitempty"`
 }
 
+// Offer taken back, e.g. another driver accepted the same ride first
+type OfferWithdrawnMessage struct {
+	WebSocketMessage
+	OfferID string `json:"offer_id"`
+	RideID  string `json:"ride_id"`
+	Reason  string `json:"reason"`
+}
+
 // Location update from driver
 type LocationUpdateMessage struct {
 	WebSocketMessage
@@ -90,3 +122,16 @@ type ConnectionStatus struct {
 	LastPing  time.Time `json:"last_ping,omitempty"`
 	SessionID string    `json:"session_id,omitempty"`
 }
+
+// Demand around an idle driver, pushed periodically
+type DemandMapMessage struct {
+	WebSocketMessage
+	dto.DemandMap
+}
+
+// Driving time of the shift, sent ahead of the limit and when it is reached
+type HoursMessage struct {
+	WebSocketMessage
+	dto.DriverHours
+	Message string `json:"message"`
+}
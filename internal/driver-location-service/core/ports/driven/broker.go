@@ -21,6 +21,8 @@ type IDriverBroker interface {
 	// Consume подписывается на очередь с указанным биндингом.
 	// Возвращает канал Deliveries (amqp.Delivery), из которого читает consumer.
 	Consume(ctx context.Context, queueName, bindingKey string, opts ConsumeOptions) (<-chan amqp.Delivery, error)
+	// ConsumeExclusive объявляет очередь только этого соединения на exchange, для сообщений одной реплике.
+	ConsumeExclusive(ctx context.Context, exchange, queueName, bindingKey string) (<-chan amqp.Delivery, error)
 	// IsAlive проверяет состояние соединения.
 	IsAlive() bool
 
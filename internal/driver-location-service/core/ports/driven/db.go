@@ -2,6 +2,7 @@ package driven
 
 import (
 	"context"
+	"time"
 
 	"ride-hail/internal/driver-location-service/core/domain/model"
 )
@@ -9,14 +10,92 @@ import (
 type IDriverRepository interface {
 	GoOnline(ctx context.Context, coord model.DriverCoordinates) (string, error)
 	GoOffline(ctx context.Context, driver_id string) (model.DriverOfflineResponse, error)
-	UpdateLocation(ctx context.Context, driver_id string, newLocation model.NewLocation) (model.NewLocationResponse, error)
 	StartRide(ctx context.Context, requestData model.StartRide) (model.StartRideResponse, error)
-	CompleteRide(ctx context.Context, requestData model.RideCompleteForm) (model.RideCompleteResponse, error)
-	FindDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string) ([]model.DriverInfo, error)
-	CalculateRideDetails(ctx context.Context, driverLocation model.Location, passagerLocation model.Location) (float64, error)
+	CompleteRide(ctx context.Context, requestData model.RideCompleteForm, commissionRate float64) (model.RideCompleteResponse, error)
+	FindDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string, optInFrom []string) ([]model.DriverInfo, error)
 	UpdateDriverStatus(ctx context.Context, driver_id string, status string) error
+	ClaimDriver(ctx context.Context, driver_id, ride_id string) (bool, error)
 	CheckDriverById(ctx context.Context, driver_id string) (bool, error)
+	GetDriverStatuses(ctx context.Context, driver_ids []string) (map[string]string, error)
 	GetDriverIdByRideId(ctx context.Context, ride_id string) (string, error)
 	GetRideIdByDriverId(ctx context.Context, driver_id string) (string, error)
 	GetRideDetailsByRideId(ctx context.Context, ride_id string) (model.RideDetails, error)
+	GetLiveDrivers(ctx context.Context) ([]model.LiveDriver, error)
+	GetLiveDriver(ctx context.Context, driver_id string) (model.LiveDriver, error)
+	GetPickupRide(ctx context.Context, driver_id string) (model.PickupRide, error)
+	AdvanceRideStatus(ctx context.Context, ride_id string, from []string, to string, publish func(at time.Time) error) (time.Time, bool, error)
+	GetActiveRide(ctx context.Context, driver_id string) (model.ActiveRide, error)
+	CancelRide(ctx context.Context, ride_id, driver_id, reason string) (time.Time, error)
+}
+
+type ILocationWriter interface {
+	Write(ctx context.Context, driver_id string, newLocation model.NewLocation) (model.NewLocationResponse, error)
+	Stats() model.LocationWriterStats
+}
+
+type IOfferRepository interface {
+	CreateOffer(ctx context.Context, offer model.RideOffer) error
+	CloseOffer(ctx context.Context, offer_id string, status string, reason string) error
+	GetOffer(ctx context.Context, offer_id string) (model.RideOffer, error)
+	GetOfferStats(ctx context.Context, driver_id string, days int) (model.OfferStats, error)
+}
+
+type IScoreRepository interface {
+	RefreshScores(ctx context.Context, window_days, short_window_days int, short_weight float64) (int64, error)
+	GetScores(ctx context.Context, driver_ids []string) (map[string]model.DriverScore, error)
+	GetScore(ctx context.Context, driver_id string) (model.DriverScore, error)
+}
+
+type IEarningsRepository interface {
+	RecordRide(ctx context.Context, ride_id string, commissionRate float64) (model.RideEarning, bool, error)
+	GetStatement(ctx context.Context, driver_id, period string, from, to time.Time) ([]model.EarningsLine, error)
+}
+
+type IDemandRepository interface {
+	GetDemand(ctx context.Context, since time.Time, cellSizeDeg float64) ([]model.DemandCell, error)
+}
+
+type IDestinationRepository interface {
+	Activate(ctx context.Context, destination model.DriverDestination, usesPerDay int) (model.DriverDestination, error)
+	Deactivate(ctx context.Context, driver_id string) (bool, error)
+	GetActive(ctx context.Context, driver_id string) (model.DriverDestination, error)
+	GetActiveFor(ctx context.Context, driver_ids []string) (map[string]model.DriverDestination, error)
+	UsesToday(ctx context.Context, driver_id string) (int, error)
+}
+
+type IHoursRepository interface {
+	GetOnlineSessions(ctx context.Context, since time.Time) ([]model.ShiftSession, error)
+	GetDriverSessions(ctx context.Context, driver_id string, since time.Time) ([]model.ShiftSession, error)
+	GetJurisdiction(ctx context.Context, driver_id string) (string, error)
+	GetDrivingSeconds(ctx context.Context, shiftStarts map[string]time.Time) (map[string]float64, error)
+	StartBreak(ctx context.Context, brk model.DriverBreak) (model.DriverBreak, error)
+	GetActiveBreak(ctx context.Context, driver_id string) (model.DriverBreak, error)
+	TryLeadership(ctx context.Context) (bool, error)
+}
+
+type IDocumentRepository interface {
+	Create(ctx context.Context, document model.DriverDocument) (model.DriverDocument, error)
+	List(ctx context.Context, driver_id string) ([]model.DriverDocument, error)
+	IsVerified(ctx context.Context, driver_id string) (bool, error)
+	GetCleared(ctx context.Context, driver_ids []string, required []string) (map[string]bool, error)
+}
+
+type IVehicleRepository interface {
+	Create(ctx context.Context, vehicle model.Vehicle) (model.Vehicle, error)
+	List(ctx context.Context, driver_id string) ([]model.Vehicle, error)
+	Get(ctx context.Context, driver_id, vehicle_id string) (model.Vehicle, error)
+	GetLastUsed(ctx context.Context, driver_id string) (model.Vehicle, error)
+	Update(ctx context.Context, vehicle model.Vehicle) (model.Vehicle, error)
+	Remove(ctx context.Context, driver_id, vehicle_id string) error
+}
+
+type IHistoryRepository interface {
+	EnsurePartition(ctx context.Context, day time.Time) (bool, error)
+	ListPartitions(ctx context.Context) ([]model.HistoryPartition, error)
+	DropPartition(ctx context.Context, name string) error
+	DeleteNonRidePoints(ctx context.Context, before time.Time) (int64, error)
+	DeleteDefaultPoints(ctx context.Context, before time.Time) (int64, error)
+	RidesWithoutTrack(ctx context.Context, completedBefore time.Time, limit int) ([]string, error)
+	GetRidePoints(ctx context.Context, ride_id string) ([]model.TrackPoint, error)
+	SaveTrack(ctx context.Context, track model.RideTrack) error
 }
@@ -2,17 +2,32 @@ package driven
 
 import (
 	"context"
+	"time"
 
 	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
 )
 
 type WSConnectionMeneger interface {
-	RegisterDriver(ctx context.Context, driverID string, incoming <-chan []byte, outgoing chan<- []byte) error
-	UnregisterDriver(ctx context.Context, driverID string)
+	RegisterDriver(ctx context.Context, driverID string, incoming <-chan []byte, outgoing chan<- []byte) (string, error)
+	UnregisterDriver(ctx context.Context, connectionID string)
+	Authenticate(connectionID, resumeToken string, lastSeq uint64) error
 	IsDriverConnected(driverID string) bool
 	SendToDriver(ctx context.Context, driverID string, message any) error
 	GetDriversCount(ctx context.Context) int
 	GetDriverMessages(driverID string) (<-chan []byte, error)
 	GetConnectedDrivers() []string
 	GetFanIn() <-chan dto.DriverMessage
+	InstanceID() string
+	ForwardToInstance(ctx context.Context, instanceID string, msg dto.DriverMessage) error
+}
+
+// IConnectionRegistry knows which replica holds the WebSocket session of a driver
+type IConnectionRegistry interface {
+	Heartbeat(ctx context.Context, instance_id string, sessions []model.DriverConnection, ttl time.Duration) error
+	Register(ctx context.Context, instance_id, driver_id string) (string, error)
+	SetConnected(ctx context.Context, instance_id, driver_id string, connected bool) error
+	Remove(ctx context.Context, instance_id, driver_id string) error
+	GetConnections(ctx context.Context, ttl time.Duration) ([]model.DriverConnection, error)
+	GetConnection(ctx context.Context, driver_id string, ttl time.Duration) (model.DriverConnection, error)
 }
@@ -0,0 +1,13 @@
+package driver
+
+import (
+	"context"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+type IDestinationService interface {
+	SetDestination(ctx context.Context, driver_id string, destination dto.SetDestination) (dto.DestinationResponse, error)
+	ClearDestination(ctx context.Context, driver_id string) (dto.DestinationResponse, error)
+	GetDestination(ctx context.Context, driver_id string) (dto.DestinationResponse, error)
+}
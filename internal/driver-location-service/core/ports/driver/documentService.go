@@ -0,0 +1,14 @@
+package driver
+
+import (
+	"context"
+	"io"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+type IDocumentService interface {
+	Upload(ctx context.Context, driver_id, documentType, expiresAt, fileName string, file io.Reader) (dto.DriverDocument, error)
+	GetDocuments(ctx context.Context, driver_id string) (dto.DriverDocuments, error)
+	MaxUploadBytes() int64
+}
@@ -11,13 +11,20 @@ type IDriverService interface {
 	GoOnline(ctx context.Context, coord dto.DriverCoordinatesDTO) (dto.DriverOnlineResponse, error)
 	GoOffline(ctx context.Context, driver_id string) (dto.DriverOfflineRespones, error)
 	UpdateLocation(ctx context.Context, request dto.NewLocation, driver_id string) (dto.NewLocationResponse, error)
+	GetLocationStats(ctx context.Context, driver_id string) dto.LocationStats
+	GetLocationWriterMetrics(ctx context.Context) dto.LocationWriterMetrics
+	MarkArrived(ctx context.Context, driver_id string) (dto.ArrivedResponse, error)
+	CancelRide(ctx context.Context, driver_id string, request dto.CancelRide) (dto.CancelRideResponse, error)
 	StartRide(ctx context.Context, requestMessage dto.StartRide) (dto.StartRideResponse, error)
-	CompleteRide(ctx context.Context, request dto.RideCompleteForm) (dto.RideCompleteResponse, error)
-	FindAppropriateDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string) ([]dto.DriverInfo, error)
+	CompleteRide(ctx context.Context, driver_id string, request dto.RideCompleteForm) (dto.RideCompleteResponse, error)
+	FindAppropriateDrivers(ctx context.Context, longtitude, latitude, destLongtitude, destLatitude float64, vehicleType string) ([]dto.DriverInfo, error)
+	StillAvailable(ctx context.Context, drivers []dto.DriverInfo) ([]dto.DriverInfo, error)
 	CalculateRideDetails(ctx context.Context, driverLocation dto.Location, passagerLocation dto.Location) (float64, int, error)
 	UpdateDriverStatus(ctx context.Context, driver_id string, status string) error
+	ClaimDriver(ctx context.Context, driver_id, ride_id string) (bool, error)
 	CheckDriverById(ctx context.Context, driver_id string) (bool, error)
 	GetDriverIdByRideId(ctx context.Context, ride_id string) (string, error)
 	GetRideIdByDriverId(ctx context.Context, driver_id string) (string, error)
+	ActiveRideId(ctx context.Context, driver_id string) (string, error)
 	GetRideDetailsByRideId(ctx context.Context, ride_id string) (websocketdto.RideDetailsMessage, error)
 }
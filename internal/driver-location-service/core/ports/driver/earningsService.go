@@ -0,0 +1,15 @@
+package driver
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+)
+
+type IEarningsService interface {
+	RecordRide(ctx context.Context, ride_id string) (model.RideEarning, bool, error)
+	DriverEarnings(fare float64) float64
+	GetStatement(ctx context.Context, driver_id, period string, from, to time.Time) (dto.EarningsStatement, error)
+}
@@ -0,0 +1,11 @@
+package driver
+
+import (
+	"context"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+type IHoursService interface {
+	GetHours(ctx context.Context, driver_id string) (dto.DriverHours, error)
+}
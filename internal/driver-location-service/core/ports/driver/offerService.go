@@ -0,0 +1,16 @@
+package driver
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+)
+
+type IOfferService interface {
+	OfferSent(ctx context.Context, offer_id, ride_id, driver_id, strategy, instance_id string, expiresAt time.Time) error
+	CloseOffer(ctx context.Context, offer_id string, status string, reason string) error
+	GetOffer(ctx context.Context, offer_id string) (model.RideOffer, error)
+	GetOfferStats(ctx context.Context, driver_id string, days int) (dto.OfferStats, error)
+}
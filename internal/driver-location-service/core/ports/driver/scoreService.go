@@ -0,0 +1,13 @@
+package driver
+
+import (
+	"context"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+type IScoreService interface {
+	Run(ctx context.Context)
+	Rank(ctx context.Context, vehicleType string, drivers []dto.DriverInfo) []dto.DriverInfo
+	GetDriverScore(ctx context.Context, driver_id string) (dto.DriverScore, error)
+}
@@ -0,0 +1,14 @@
+package driver
+
+import (
+	"context"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+type IVehicleService interface {
+	CreateVehicle(ctx context.Context, driver_id string, req dto.VehicleRequest) (dto.Vehicle, error)
+	ListVehicles(ctx context.Context, driver_id string) (dto.Vehicles, error)
+	UpdateVehicle(ctx context.Context, driver_id, vehicle_id string, req dto.VehicleRequest) (dto.Vehicle, error)
+	RemoveVehicle(ctx context.Context, driver_id, vehicle_id string) error
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+// ArrivalDetector drives the pickup part of a ride from the driver locations: the first
+// location after acceptance moves the ride to EN_ROUTE, staying within the radius of the
+// pickup for the dwell time moves it to ARRIVED. Every transition is published through
+// DriverEvents.
+type ArrivalDetector struct {
+	repo   driven.IDriverRepository
+	index  *DriverIndex
+	events *DriverEvents
+	cfg    *config.Arrivalconfig
+	log    logger.Logger
+
+	mu     sync.Mutex
+	inside map[string]time.Time // driver -> first point within the radius
+}
+
+func NewArrivalDetector(repo driven.IDriverRepository, index *DriverIndex, events *DriverEvents, cfg *config.Arrivalconfig, log logger.Logger) *ArrivalDetector {
+	return &ArrivalDetector{
+		repo:   repo,
+		index:  index,
+		events: events,
+		cfg:    cfg,
+		log:    log,
+		inside: make(map[string]time.Time),
+	}
+}
+
+// Observe checks an accepted location of the driver against the ride being picked up
+func (ad *ArrivalDetector) Observe(ctx context.Context, driver_id string, latitude, longitude float64, at time.Time) {
+	log := ad.log.Action("ArrivalDetector")
+
+	// an AVAILABLE driver has no ride to pick up, do not query for every idle update
+	if driver, ok := ad.index.Get(driver_id); ok && driver.Status == "AVAILABLE" {
+		ad.Forget(driver_id)
+		return
+	}
+
+	ride, err := ad.repo.GetPickupRide(ctx, driver_id)
+	if errors.Is(err, db.ErrRideNotFound) {
+		ad.Forget(driver_id)
+		return
+	} else if err != nil {
+		log.Error("Failed to get pickup ride", err, "driver_id", driver_id)
+		return
+	}
+
+	if ride.Status == model.RideStatusArrived {
+		ad.Forget(driver_id)
+		return
+	}
+	if ride.Status == model.RideStatusMatched {
+		if _, err := ad.advance(ctx, driver_id, ride.Ride_id, []string{model.RideStatusMatched}, model.RideStatusEnRoute); err != nil {
+			log.Error("Failed to move ride to EN_ROUTE", err, "ride_id", ride.Ride_id)
+			return
+		}
+	}
+
+	meters := geo.HaversineKm(geo.Point{Lat: latitude, Lng: longitude}, geo.Point{Lat: ride.PickupLatitude, Lng: ride.PickupLongitude}) * 1000
+	if meters > ad.cfg.RadiusMeters {
+		ad.Forget(driver_id)
+		return
+	}
+
+	ad.mu.Lock()
+	since, ok := ad.inside[driver_id]
+	if !ok {
+		since = at
+		ad.inside[driver_id] = at
+	}
+	ad.mu.Unlock()
+	if at.Sub(since).Seconds() < ad.cfg.DwellSeconds {
+		return
+	}
+
+	if _, err := ad.advance(ctx, driver_id, ride.Ride_id, []string{model.RideStatusMatched, model.RideStatusEnRoute}, model.RideStatusArrived); err != nil {
+		log.Error("Failed to move ride to ARRIVED", err, "ride_id", ride.Ride_id)
+		return
+	}
+	ad.Forget(driver_id)
+}
+
+// MarkArrived is the manual override, the ride becomes ARRIVED wherever the driver is
+func (ad *ArrivalDetector) MarkArrived(ctx context.Context, driver_id string) (dto.ArrivedResponse, error) {
+	ride, err := ad.repo.GetPickupRide(ctx, driver_id)
+	if err != nil {
+		return dto.ArrivedResponse{}, err
+	}
+	ad.Forget(driver_id)
+
+	response := dto.ArrivedResponse{
+		Ride_id: ride.Ride_id,
+		Status:  model.RideStatusArrived,
+		Message: "Passenger has been notified of your arrival",
+	}
+	if ride.Status == model.RideStatusArrived {
+		if ride.ArrivedAt != nil {
+			response.Arrived_at = ride.ArrivedAt.Format(time.RFC3339)
+		}
+		return response, nil
+	}
+
+	arrivedAt, err := ad.advance(ctx, driver_id, ride.Ride_id, []string{model.RideStatusMatched, model.RideStatusEnRoute}, model.RideStatusArrived)
+	if err != nil {
+		return dto.ArrivedResponse{}, err
+	}
+	response.Arrived_at = arrivedAt.Format(time.RFC3339)
+	return response, nil
+}
+
+// Forget drops the dwell timer of the driver
+func (ad *ArrivalDetector) Forget(driver_id string) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	delete(ad.inside, driver_id)
+}
+
+// advance moves the ride and publishes the transition before it is committed, a failed
+// publish leaves the ride where it was so the next update tries again. A ride already moved
+// on by another update is not an error and is not published twice.
+func (ad *ArrivalDetector) advance(ctx context.Context, driver_id, ride_id string, from []string, to string) (time.Time, error) {
+	at, moved, err := ad.repo.AdvanceRideStatus(ctx, ride_id, from, to, func(at time.Time) error {
+		return ad.events.Publish(ctx, driver_id, ride_id, to, "", at)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !moved {
+		return time.Now(), nil
+	}
+	return at, nil
+}
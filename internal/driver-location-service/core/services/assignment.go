@@ -0,0 +1,96 @@
+package services
+
+import "math"
+
+// unassignable marks a ride/driver pair that must not be matched
+const unassignable = 1e9
+
+// solveAssignment solves the min-cost assignment problem (Hungarian algorithm, O(n^3)).
+// cost[i][j] is the cost of giving column j to row i. The result holds the column
+// of every row, or -1 when the row got nothing or only an unassignable column.
+func solveAssignment(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+
+	// square matrix, padded cells are unassignable
+	n := max(rows, cols)
+	a := make([][]float64, n+1)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= n; j++ {
+			if i <= rows && j <= cols {
+				a[i][j] = cost[i-1][j-1]
+			} else {
+				a[i][j] = unassignable
+			}
+		}
+	}
+
+	// potentials and matching, 1-based, p[j] = row matched to column j
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)
+	way := make([]int, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, n+1)
+		used := make([]bool, n+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				cur := a[i0][j] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	res := make([]int, rows)
+	for i := range res {
+		res[i] = -1
+	}
+	for j := 1; j <= n; j++ {
+		i := p[j]
+		if i >= 1 && i <= rows && j <= cols && a[i][j] < unassignable {
+			res[i-1] = j - 1
+		}
+	}
+	return res
+}
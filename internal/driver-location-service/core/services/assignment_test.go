@@ -0,0 +1,120 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSolveAssignment(t *testing.T) {
+	const u = unassignable
+
+	tests := []struct {
+		name string
+		cost [][]float64
+		want []int
+	}{
+		{name: "empty", cost: nil, want: nil},
+		{name: "single", cost: [][]float64{{3}}, want: []int{0}},
+		{
+			name: "square",
+			cost: [][]float64{
+				{4, 1, 3},
+				{2, 0, 5},
+				{3, 2, 2},
+			},
+			want: []int{1, 0, 2},
+		},
+		{
+			name: "more rides than drivers",
+			cost: [][]float64{
+				{1, 10},
+				{10, 1},
+				{5, 5},
+			},
+			want: []int{0, 1, -1},
+		},
+		{
+			name: "more drivers than rides",
+			cost: [][]float64{
+				{5, 1, 9},
+				{2, 8, 7},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "unassignable pair is avoided",
+			cost: [][]float64{
+				{1, 2},
+				{u, 3},
+			},
+			want: []int{0, 1},
+		},
+		{
+			name: "all unassignable row",
+			cost: [][]float64{
+				{u, u},
+				{1, 2},
+				{2, 1},
+			},
+			want: []int{-1, 0, 1},
+		},
+		{
+			name: "one assignable column for two rows",
+			cost: [][]float64{
+				{1, u},
+				{2, u},
+			},
+			want: []int{0, -1},
+		},
+		{
+			name: "all unassignable",
+			cost: [][]float64{
+				{u, u},
+				{u, u},
+				{u, u},
+			},
+			want: []int{-1, -1, -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := solveAssignment(tt.cost)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("solveAssignment = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveAssignmentUsesColumnsOnce(t *testing.T) {
+	// every row prefers column 0, only one of them can have it
+	cost := [][]float64{
+		{1, 7, 8, 9},
+		{1, 6, 8, 9},
+		{1, 7, 5, 9},
+		{1, 7, 8, 4},
+		{1, 7, 8, 9},
+	}
+	got := solveAssignment(cost)
+
+	seen := make(map[int]bool)
+	assigned, total := 0, 0.0
+	for row, col := range got {
+		if col < 0 {
+			continue
+		}
+		if seen[col] {
+			t.Fatalf("column %d assigned twice in %v", col, got)
+		}
+		seen[col] = true
+		assigned++
+		total += cost[row][col]
+	}
+	if assigned != 4 {
+		t.Errorf("assigned %d rows in %v, want 4", assigned, got)
+	}
+	if total != 16 {
+		t.Errorf("total cost %v of %v, want 16", total, got)
+	}
+}
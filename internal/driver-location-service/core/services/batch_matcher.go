@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	MatchingModeGreedy = "greedy"
+	MatchingModeBatch  = "batch"
+
+	// every rating star below 5 costs as much as this many minutes of passenger wait
+	ratingPenaltyMinutes = 2.0
+	// used when the router could not give a pickup ETA, 30 km/h
+	minutesPerKm = 2.0
+)
+
+type batchRide struct {
+	req        dto.RideDetails
+	delivery   amqp.Delivery
+	candidates []dto.DriverInfo
+}
+
+// BatchMatcher collects ride requests over a short window and assigns drivers
+// to all of them at once, minimizing the total pickup wait
+type BatchMatcher struct {
+	window      time.Duration
+	minRides    int
+	maxRequeues int
+	dispatch    func(drivers []dto.DriverInfo, req dto.RideDetails, delivery amqp.Delivery)
+	log         logger.Logger
+
+	mu       sync.Mutex
+	pending  []batchRide
+	requeues map[string]int // ride -> windows it lost all its candidates in, only touched by flush
+}
+
+func NewBatchMatcher(cfg *config.Matchingconfig, dispatch func([]dto.DriverInfo, dto.RideDetails, amqp.Delivery), log logger.Logger) *BatchMatcher {
+	window := time.Duration(cfg.BatchWindowMs) * time.Millisecond
+	if window <= 0 {
+		window = 2 * time.Second
+	}
+	return &BatchMatcher{
+		window:      window,
+		minRides:    cfg.BatchMinRides,
+		maxRequeues: cfg.MaxRequeues,
+		dispatch:    dispatch,
+		log:         log,
+		requeues:    make(map[string]int),
+	}
+}
+
+func (bm *BatchMatcher) Submit(req dto.RideDetails, delivery amqp.Delivery, candidates []dto.DriverInfo) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.pending = append(bm.pending, batchRide{req: req, delivery: delivery, candidates: candidates})
+}
+
+// Run flushes the collected rides every window until ctx is done
+func (bm *BatchMatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(bm.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			bm.mu.Lock()
+			rides := bm.pending
+			bm.pending = nil
+			bm.mu.Unlock()
+
+			if len(rides) > 0 {
+				bm.flush(rides)
+			}
+		}
+	}
+}
+
+func (bm *BatchMatcher) flush(rides []batchRide) {
+	log := bm.log.Action("BatchMatcher")
+
+	// rides nobody can take get the no driver outcome right away
+	contested := rides[:0]
+	for _, ride := range rides {
+		if len(ride.candidates) == 0 {
+			bm.send(ride, nil)
+			continue
+		}
+		contested = append(contested, ride)
+	}
+	rides = contested
+
+	// low load, nothing to optimize
+	if len(rides) < bm.minRides {
+		for _, ride := range rides {
+			bm.send(ride, ride.candidates)
+		}
+		return
+	}
+
+	// every driver that is a candidate for at least one ride
+	driverIdx := make(map[string]int)
+	for _, ride := range rides {
+		for _, driver := range ride.candidates {
+			if _, ok := driverIdx[driver.DriverId]; !ok {
+				driverIdx[driver.DriverId] = len(driverIdx)
+			}
+		}
+	}
+
+	// drivers missing from a ride's candidates have the wrong vehicle type or are too far
+	cost := make([][]float64, len(rides))
+	for i, ride := range rides {
+		cost[i] = make([]float64, len(driverIdx))
+		for j := range cost[i] {
+			cost[i][j] = unassignable
+		}
+		for _, driver := range ride.candidates {
+			cost[i][driverIdx[driver.DriverId]] = matchCost(driver)
+		}
+	}
+
+	assignment := solveAssignment(cost)
+
+	assignedTo := make(map[string]int)
+	for i, ride := range rides {
+		if j := assignment[i]; j >= 0 {
+			for _, driver := range ride.candidates {
+				if driverIdx[driver.DriverId] == j {
+					assignedTo[driver.DriverId] = i
+				}
+			}
+		}
+	}
+
+	matched := 0
+	for i, ride := range rides {
+		// the assigned driver first, then candidates nobody else got
+		var ordered []dto.DriverInfo
+		var rest []dto.DriverInfo
+		for _, driver := range ride.candidates {
+			owner, taken := assignedTo[driver.DriverId]
+			switch {
+			case taken && owner == i:
+				ordered = append(ordered, driver)
+			case !taken:
+				rest = append(rest, driver)
+			}
+		}
+		if len(ordered) > 0 {
+			matched++
+		}
+		ordered = append(ordered, rest...)
+
+		if len(ordered) == 0 {
+			// every candidate went to another ride, retry in the next window a few times,
+			// then the ride is offered to its candidates like in greedy mode
+			if bm.requeues[ride.req.Ride_id] < bm.maxRequeues {
+				bm.requeues[ride.req.Ride_id]++
+				ride.delivery.Nack(false, true)
+				continue
+			}
+			ordered = ride.candidates
+		}
+		bm.send(ride, ordered)
+	}
+	log.Info("batch matched", "rides", len(rides), "drivers", len(driverIdx), "assigned", matched)
+}
+
+func (bm *BatchMatcher) send(ride batchRide, drivers []dto.DriverInfo) {
+	delete(bm.requeues, ride.req.Ride_id)
+	go bm.dispatch(drivers, ride.req, ride.delivery)
+}
+
+// matchCost is the expected passenger wait in minutes, lower rated drivers cost more
+func matchCost(driver dto.DriverInfo) float64 {
+	eta := float64(driver.EtaMinutes)
+	if driver.EtaMinutes == 0 {
+		eta = driver.Distance * minutesPerKm
+	}
+	return eta + (5-driver.Rating)*ratingPenaltyMinutes
+}
@@ -3,15 +3,19 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"ride-hail/internal/config"
 	"ride-hail/internal/driver-location-service/core/ports/driver"
 	"ride-hail/internal/logger"
 
 	dto "ride-hail/internal/driver-location-service/core/domain/dto"
 	messagebrokerdto "ride-hail/internal/driver-location-service/core/domain/message_broker_dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
 	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
 	driven "ride-hail/internal/driver-location-service/core/ports/driven"
 
@@ -29,6 +33,13 @@ type Distributor struct {
 	driverMessages chan DriverMessage
 	pendingOffers  map[string]*PendingOffer
 	pendingMu      sync.RWMutex
+	// Batch matching, nil in greedy mode
+	batcher *BatchMatcher
+	// Dispatch strategy per vehicle type
+	strategies   map[string]DispatchStrategy
+	dispatchCfg  *config.Dispatchconfig
+	offerService driver.IOfferService
+	earnings     driver.IEarningsService // payout shown in offers, as it is booked
 	// Tools
 	broker driven.IDriverBroker
 	ctx    context.Context
@@ -55,6 +66,10 @@ func NewDistributor(
 	wsManager driven.WSConnectionMeneger,
 	broker driven.IDriverBroker,
 	driverService driver.IDriverService,
+	offerService driver.IOfferService,
+	earnings driver.IEarningsService,
+	matchingCfg *config.Matchingconfig,
+	dispatchCfg *config.Dispatchconfig,
 	log logger.Logger,
 ) *Distributor {
 	distributor := &Distributor{
@@ -65,9 +80,19 @@ func NewDistributor(
 		driverService:  driverService,
 		driverMessages: make(chan DriverMessage, 1000),
 		pendingOffers:  make(map[string]*PendingOffer),
+		strategies:     make(map[string]DispatchStrategy),
+		dispatchCfg:    dispatchCfg,
+		offerService:   offerService,
+		earnings:       earnings,
 		ctx:            ctx,
 		log:            log,
 	}
+	for vehicleType, name := range dispatchCfg.Strategies {
+		distributor.strategies[vehicleType] = NewDispatchStrategy(name, dispatchCfg)
+	}
+	if matchingCfg.Mode == MatchingModeBatch {
+		distributor.batcher = NewBatchMatcher(matchingCfg, distributor.sendRideOffers, log)
+	}
 
 	// go (*distributor).MessageDistributor()
 	return distributor
@@ -76,6 +101,9 @@ func NewDistributor(
 func (d *Distributor) MessageDistributor() error {
 	log := d.log.Action("MessageDistributor")
 	log.Info("Starting message distributor...")
+	if d.batcher != nil {
+		go d.batcher.Run(d.ctx)
+	}
 	for {
 		select {
 		case requestDelivery := <-d.rideOffers:
@@ -115,21 +143,107 @@ func (d *Distributor) handleDriverConnection(driverID string, incoming <-chan []
 	}
 }
 
-// IMPLEMENT
 func (d *Distributor) handleDriverMessage(msg dto.DriverMessage) {
 	log := d.log.Action("handleDriverMessage")
+	var baseMsg websocketdto.WebSocketMessage
+	if err := json.Unmarshal(msg.Message, &baseMsg); err != nil {
+		log.Error("Failed to unmarshal message:", err)
+		return
+	}
+	switch baseMsg.Type {
+	case websocketdto.MessageTypeRideResponse:
+		d.handleRideResponse(msg)
+	case websocketdto.MessageTypeLocationUpdate:
+		d.handleDriverLocation(msg)
+	default:
+		log.Warn("Unhandled message type from driver", "driver_id", msg.DriverID, "type", baseMsg.Type)
+	}
+}
+
+// handleRideResponse hands the response to the wave waiting for this offer.
+// Responses to unknown, closed or someone else's offers are rejected.
+func (d *Distributor) handleRideResponse(msg dto.DriverMessage) {
+	log := d.log.Action("handleRideResponse")
+	var response websocketdto.RideResponseMessage
+	if err := json.Unmarshal(msg.Message, &response); err != nil {
+		log.Error("Failed to unmarshal ride response:", err)
+		return
+	}
+
+	d.pendingMu.Lock()
+	pending, ok := d.pendingOffers[response.OfferID]
+	if ok && pending.DriverID == msg.DriverID && pending.RideID == response.RideID && time.Now().Before(pending.ExpiresAt) {
+		delete(d.pendingOffers, response.OfferID)
+		d.pendingMu.Unlock()
+
+		select {
+		case pending.ResponseChan <- response:
+		default:
+			log.Warn("Offer wave is gone, dropping response", "offer_id", response.OfferID)
+		}
+		return
+	}
+	d.pendingMu.Unlock()
+
+	var (
+		offer    model.RideOffer
+		offerErr error
+	)
+	if !ok {
+		// the driver is connected here, the offer may be waited for by another replica
+		offer, offerErr = d.offerService.GetOffer(context.Background(), response.OfferID)
+		if offerErr == nil && offer.Status == model.OfferStatusSent && offer.DriverId == msg.DriverID &&
+			offer.InstanceId != "" && offer.InstanceId != d.wsManager.InstanceID() {
+			err := d.wsManager.ForwardToInstance(context.Background(), offer.InstanceId, msg)
+			if err == nil {
+				return
+			}
+			log.Error("Failed to forward ride response", err, "offer_id", response.OfferID, "instance_id", offer.InstanceId)
+		}
+	}
+
+	code := "offer_expired"
+	switch {
+	case ok && (pending.DriverID != msg.DriverID || pending.RideID != response.RideID):
+		code = "offer_mismatch"
+	case !ok:
+		switch {
+		case offerErr != nil:
+			code = "offer_not_found"
+		case offer.DriverId != msg.DriverID:
+			code = "offer_mismatch"
+		case offer.Status != model.OfferStatusSent:
+			code = "offer_" + strings.ToLower(offer.Status)
+		}
+	}
+	log.Warn("Rejected ride response", "driver_id", msg.DriverID, "offer_id", response.OfferID, "reason", code)
+	d.wsManager.SendToDriver(context.Background(), msg.DriverID, websocketdto.ErrorMessage{
+		WebSocketMessage: websocketdto.WebSocketMessage{Type: websocketdto.MessageTypeError},
+		ErrorCode:        code,
+		ErrorMessage:     "ride response rejected",
+	})
+}
+
+func (d *Distributor) handleDriverLocation(msg dto.DriverMessage) {
+	log := d.log.Action("handleDriverLocation")
 	var LocationUpdate websocketdto.LocationUpdateMessage
 	if err := json.Unmarshal(msg.Message, &LocationUpdate); err != nil {
 		log.Error("Failed to unmarshal message:", err)
 		return
 	}
-	d.driverService.UpdateLocation(context.Background(), dto.NewLocation{
+	location, err := d.driverService.UpdateLocation(context.Background(), dto.NewLocation{
 		Latitude:        LocationUpdate.Latitude,
 		Longitude:       LocationUpdate.Longitude,
 		Accuracy_meters: LocationUpdate.AccuracyMeters,
 		Speed_kmh:       LocationUpdate.SpeedKmh,
 		Heading_Degrees: LocationUpdate.HeadingDegrees,
 	}, msg.DriverID)
+	if errors.Is(err, ErrLocationThrottled) {
+		return
+	} else if err != nil {
+		log.Warn("Location update dropped", "driver_id", msg.DriverID, "err", err)
+		return
+	}
 	ride_id, err := d.driverService.GetRideIdByDriverId(context.Background(), msg.DriverID)
 	if err != nil {
 		log.Error("Failed to get ride id from db:", err)
@@ -139,8 +253,8 @@ func (d *Distributor) handleDriverMessage(msg dto.DriverMessage) {
 		DriverID: msg.DriverID,
 		RideID:   ride_id,
 		Location: messagebrokerdto.Location{
-			Lng: LocationUpdate.Longitude,
-			Lat: LocationUpdate.Latitude,
+			Lng: location.Longitude,
+			Lat: location.Latitude,
 		},
 		SpeedKmh:       LocationUpdate.SpeedKmh,
 		HeadingDegrees: LocationUpdate.HeadingDegrees,
@@ -171,6 +285,8 @@ func (d *Distributor) handleRideRequest(requestDelivery amqp.Delivery) {
 	ctx := context.Background()
 	allDrivers, err := d.driverService.FindAppropriateDrivers(ctx,
 		req.Pickup_location.Lng,
+		req.Pickup_location.Lat,
+		req.Destination_location.Lng,
 		req.Destination_location.Lat,
 		req.Ride_type,
 	)
@@ -187,19 +303,71 @@ func (d *Distributor) handleRideRequest(requestDelivery amqp.Delivery) {
 		}
 	}
 	log.Info(fmt.Sprintf("Found %d connected drivers for ride %s", len(connectedDrivers), req.Ride_id))
+	if d.batcher != nil {
+		d.batcher.Submit(req, requestDelivery, connectedDrivers)
+		return
+	}
 	go d.sendRideOffers(connectedDrivers, req, requestDelivery)
 }
 
 func (d *Distributor) sendRideOffers(drivers []dto.DriverInfo, rideDetails dto.RideDetails, requestDelivery amqp.Delivery) {
 	log := d.log.Action("sendRideOffers")
-	_ = log
 
+	// pickup -> destination, same for every offer
+	_, rideMinutes, err := d.driverService.CalculateRideDetails(context.Background(),
+		dto.Location{Latitude: rideDetails.Pickup_location.Lat, Longitude: rideDetails.Pickup_location.Lng},
+		dto.Location{Latitude: rideDetails.Destination_location.Lat, Longitude: rideDetails.Destination_location.Lng},
+	)
+	if err != nil {
+		log.Error("Failed to estimate ride duration", err, rideDetails.Ride_id)
+	}
+
+	strategy, ok := d.strategies[rideDetails.Ride_type]
+	if !ok {
+		strategy = NewDispatchStrategy(DispatchSequential, d.dispatchCfg)
+	}
+	log.Info("Dispatching ride", "ride-id", rideDetails.Ride_id, "strategy", strategy.Name(), "drivers", len(drivers))
+
+	wave := func(ctx context.Context, group []dto.DriverInfo, timeout time.Duration) (offerResult, bool) {
+		return d.offerWave(ctx, group, rideDetails, rideMinutes, strategy.Name(), timeout)
+	}
+	res, accepted := strategy.Dispatch(d.ctx, drivers, wave)
+	if !accepted {
+		log.Info("No driver accepted the ride", "ride-id", rideDetails.Ride_id)
+		requestDelivery.Nack(false, true)
+		return
+	}
+	d.handleDriverAcceptance(res.response, rideDetails, requestDelivery, res.driver)
+}
+
+// offerWave sends the ride to every driver of the wave and returns the first acceptance.
+// Drivers busy by now according to Postgres are skipped, the candidates come from the index
+// of this replica. Every offer is registered in pendingOffers until answered, and persisted
+// with its outcome: the winner ACCEPTED, the others WITHDRAWN, no answer EXPIRED.
+func (d *Distributor) offerWave(ctx context.Context, drivers []dto.DriverInfo, rideDetails dto.RideDetails, rideMinutes int, strategy string, timeout time.Duration) (offerResult, bool) {
+	log := d.log.Action("offerWave")
+
+	if available, err := d.driverService.StillAvailable(ctx, drivers); err != nil {
+		log.Error("Failed to check driver statuses, offering to the wave as is", err, rideDetails.Ride_id)
+	} else {
+		if skipped := len(drivers) - len(available); skipped > 0 {
+			log.Info("Drivers no longer available skipped", "ride-id", rideDetails.Ride_id, "skipped", skipped)
+		}
+		drivers = available
+	}
+
+	waveCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	expiresAt := time.Now().Add(timeout)
+	responses := make(chan websocketdto.RideResponseMessage, len(drivers))
+	offers := make(map[string]dto.DriverInfo) // offer id -> driver
 	for _, driver := range drivers {
 		offer := websocketdto.RideOfferMessage{
 			WebSocketMessage: websocketdto.WebSocketMessage{
 				Type: websocketdto.MessageTypeRideOffer,
 			},
-			OfferID:    fmt.Sprintf("offer_%s_%s", rideDetails.Ride_id, driver.DriverId),
+			OfferID:    fmt.Sprintf("offer_%s_%s_%d", rideDetails.Ride_id, driver.DriverId, time.Now().UnixNano()),
 			RideID:     rideDetails.Ride_id,
 			RideNumber: rideDetails.Ride_number,
 			PickupLocation: websocketdto.Location{
@@ -213,37 +381,102 @@ func (d *Distributor) sendRideOffers(drivers []dto.DriverInfo, rideDetails dto.R
 				Address:   rideDetails.Destination_location.Address,
 			},
 			EstimatedFare:                rideDetails.Estimated_fare,
-			DriverEarnings:               rideDetails.Estimated_fare * 0.8,
+			DriverEarnings:               d.earnings.DriverEarnings(rideDetails.Estimated_fare),
 			DistanceToPickupKm:           driver.Distance,
-			EstimatedRideDurationMinutes: int(driver.Distance / 0.75),
-			ExpiresAt:                    time.Now().Add(30 * time.Second),
+			EstimatedRideDurationMinutes: rideMinutes,
+			ExpiresAt:                    expiresAt,
+		}
+
+		d.pendingMu.Lock()
+		d.pendingOffers[offer.OfferID] = &PendingOffer{
+			RideID:       rideDetails.Ride_id,
+			DriverID:     driver.DriverId,
+			OfferID:      offer.OfferID,
+			ExpiresAt:    expiresAt,
+			ResponseChan: responses,
 		}
-		log.Info("Sending message to driver:", offer)
-		d.wsManager.SendToDriver(context.Background(), driver.DriverId, offer)
-		driverResponse, err := d.wsManager.GetDriverMessages(driver.DriverId)
-		if err != nil {
-			log.Error("Failed to get messages for driver", err, driver.DriverId)
+		d.pendingMu.Unlock()
+
+		// persisted before it is sent, an answer relayed through another replica is looked up
+		// in Postgres
+		if err := d.offerService.OfferSent(context.Background(), offer.OfferID, rideDetails.Ride_id, driver.DriverId, strategy, d.wsManager.InstanceID(), expiresAt); err != nil {
+			log.Error("Failed to persist offer", err, offer.OfferID)
+			d.dropPendingOffer(offer.OfferID)
+			continue
+		}
+		if err := d.wsManager.SendToDriver(context.Background(), driver.DriverId, offer); err != nil {
+			log.Error("Failed to send offer to driver", err, driver.DriverId)
+			d.dropPendingOffer(offer.OfferID)
+			d.closeOffer(offer.OfferID, model.OfferStatusFailed, "")
 			continue
 		}
+		offers[offer.OfferID] = driver
+	}
+
+	var (
+		res      offerResult
+		accepted bool
+	)
+wait:
+	for pending := len(offers); pending > 0 && !accepted; pending-- {
 		select {
-		case data := <-driverResponse:
-			var response websocketdto.RideResponseMessage
-			if err := json.Unmarshal(data, &response); err != nil {
-				log.Error("Failed to unmarshal driver response:", err, driver.DriverId)
-				requestDelivery.Nack(false, true)
-				continue
-			}
+		case response := <-responses:
 			if response.Accepted {
-				d.handleDriverAcceptance(response, rideDetails, requestDelivery, driver)
+				// the driver may hold an offer of another replica too, the first claim wins
+				driver := offers[response.OfferID]
+				claimed, err := d.driverService.ClaimDriver(context.Background(), driver.DriverId, rideDetails.Ride_id)
+				if err != nil {
+					log.Error("Failed to claim the driver", err, driver.DriverId)
+				}
+				if !claimed {
+					d.closeOffer(response.OfferID, model.OfferStatusWithdrawn, "driver_no_longer_available")
+					d.wsManager.SendToDriver(context.Background(), driver.DriverId, websocketdto.OfferWithdrawnMessage{
+						WebSocketMessage: websocketdto.WebSocketMessage{Type: websocketdto.MessageTypeOfferWithdrawn},
+						OfferID:          response.OfferID,
+						RideID:           rideDetails.Ride_id,
+						Reason:           "driver_no_longer_available",
+					})
+					delete(offers, response.OfferID)
+					continue
+				}
+				res, accepted = offerResult{driver: driver, response: response}, true
+				d.closeOffer(response.OfferID, model.OfferStatusAccepted, "")
 			} else {
-				requestDelivery.Nack(false, true)
+				d.closeOffer(response.OfferID, model.OfferStatusDeclined, response.DeclineReason)
 			}
-			return
-		case <-time.After(30 * time.Second):
-			log.Info("No driver accepted the ride within timeout")
-			requestDelivery.Nack(false, true)
+			delete(offers, response.OfferID)
+		case <-waveCtx.Done():
+			break wait
+		}
+	}
+
+	// whatever is left was not answered
+	for offerID, driver := range offers {
+		d.dropPendingOffer(offerID)
+		if !accepted {
+			d.closeOffer(offerID, model.OfferStatusExpired, "")
 			continue
 		}
+		d.closeOffer(offerID, model.OfferStatusWithdrawn, "")
+		d.wsManager.SendToDriver(context.Background(), driver.DriverId, websocketdto.OfferWithdrawnMessage{
+			WebSocketMessage: websocketdto.WebSocketMessage{Type: websocketdto.MessageTypeOfferWithdrawn},
+			OfferID:          offerID,
+			RideID:           rideDetails.Ride_id,
+			Reason:           "accepted_by_another_driver",
+		})
+	}
+	return res, accepted
+}
+
+func (d *Distributor) dropPendingOffer(offerID string) {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+	delete(d.pendingOffers, offerID)
+}
+
+func (d *Distributor) closeOffer(offerID, status, reason string) {
+	if err := d.offerService.CloseOffer(context.Background(), offerID, status, reason); err != nil {
+		d.log.Action("closeOffer").Error("Failed to update offer", err, offerID)
 	}
 }
 
@@ -262,9 +495,19 @@ func (d *Distributor) handleLocationUpdate(driverID string, update websocketdto.
 		log.Error("Failed to update driver location", err)
 		return
 	}
+
+	// ride-service tracks rides, locations of drivers without one are not published
+	rideID, err := d.driverService.ActiveRideId(ctx, driverID)
+	if err != nil {
+		log.Error("Failed to look up the active ride", err, driverID)
+		return
+	}
+	if rideID == "" {
+		return
+	}
 	locationUpdate := messagebrokerdto.LocationUpdate{
 		DriverID: driverID,
-		RideID:   "asdasda",
+		RideID:   rideID,
 		Location: messagebrokerdto.Location{
 			Lng: update.Longitude,
 			Lat: update.Latitude,
@@ -286,7 +529,7 @@ func (d *Distributor) handleDriverAcceptance(response websocketdto.RideResponseM
 		Ride_id:                   rideDetails.Ride_id,
 		Driver_id:                 driver.DriverId,
 		Accepted:                  true,
-		Estimated_arrival_minutes: 5,
+		Estimated_arrival_minutes: driver.EtaMinutes,
 		Driver_location: dto.Location{
 			Latitude:  response.CurrentLocation.Latitude,
 			Longitude: response.CurrentLocation.Longitude,
@@ -297,8 +540,7 @@ func (d *Distributor) handleDriverAcceptance(response websocketdto.RideResponseM
 			Rating:  driver.Rating,
 		},
 	}
-	d.driverService.UpdateDriverStatus(context.Background(), driverMatch.Driver_id, "BUSY")
-
+	// the driver was claimed BUSY when the offer was accepted
 	requestDelivery.Ack(false)
 	d.broker.PublishJSON(d.ctx, "driver_topic", fmt.Sprintf("driver.response.%s", driver.DriverId), driverMatch)
 
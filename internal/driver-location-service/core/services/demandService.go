@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+var ErrDriverNotOnline = errors.New("driver is not online")
+
+type demandCell struct {
+	cell   gridCell
+	open   int
+	demand float64
+}
+
+// DemandService keeps a heatmap of pickups per grid cell, recomputed every refresh
+// interval, and compares it with the available drivers of the index. Idle connected
+// drivers get the map around them pushed as demand_map. Only the refresh queries
+// Postgres, the repository is on a connection of its own.
+type DemandService struct {
+	repositories driven.IDemandRepository
+	index        *DriverIndex
+	cfg          *config.Demandconfig
+	log          logger.Logger
+
+	mu          sync.RWMutex
+	cells       []demandCell
+	generatedAt time.Time
+}
+
+func NewDemandService(repositories driven.IDemandRepository, index *DriverIndex, cfg *config.Demandconfig, log logger.Logger) *DemandService {
+	return &DemandService{
+		repositories: repositories,
+		index:        index,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+// Run refreshes the heatmap and pushes it to idle drivers until ctx is done
+func (ds *DemandService) Run(ctx context.Context, wsManager driven.WSConnectionMeneger) {
+	log := ds.log.Action("DemandService")
+	interval := time.Duration(max(ds.cfg.RefreshSec, 1)) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ds.Refresh(ctx); err != nil {
+			log.Error("Failed to refresh demand map", err)
+		} else {
+			ds.push(ctx, wsManager)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the pickups per cell from Postgres
+func (ds *DemandService) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	since := time.Now().Add(-time.Duration(ds.cfg.WindowMinutes) * time.Minute)
+	rows, err := ds.repositories.GetDemand(ctx, since, ds.cfg.CellSizeDeg)
+	if err != nil {
+		return err
+	}
+
+	cells := make([]demandCell, 0, len(rows))
+	for _, row := range rows {
+		cells = append(cells, demandCell{
+			cell:   gridCell{lat: row.Lat, lng: row.Lng},
+			open:   row.OpenRequests,
+			demand: float64(row.OpenRequests + row.RecentRequests + row.CompletedPickups),
+		})
+	}
+
+	ds.mu.Lock()
+	ds.cells = cells
+	ds.generatedAt = time.Now()
+	ds.mu.Unlock()
+	return nil
+}
+
+// GetDemandMap returns the cells around the driver and the nearest under-supplied ones
+func (ds *DemandService) GetDemandMap(ctx context.Context, driver_id string) (dto.DemandMap, error) {
+	driver, ok := ds.index.Get(driver_id)
+	if !ok {
+		return dto.DemandMap{}, ErrDriverNotOnline
+	}
+	return ds.demandAround(driver.Latitude, driver.Longitude, ds.supply()), nil
+}
+
+func (ds *DemandService) push(ctx context.Context, wsManager driven.WSConnectionMeneger) {
+	available := ds.index.Available()
+	if len(available) == 0 {
+		return
+	}
+
+	supply := supplyOf(available, ds.cfg.CellSizeDeg)
+	for _, driver := range available {
+		if !wsManager.IsDriverConnected(driver.DriverId) {
+			continue
+		}
+		message := websocketdto.DemandMapMessage{
+			WebSocketMessage: websocketdto.WebSocketMessage{Type: websocketdto.MessageTypeDemandMap},
+			DemandMap:        ds.demandAround(driver.Latitude, driver.Longitude, supply),
+		}
+		if err := wsManager.SendToDriver(ctx, driver.DriverId, message); err != nil {
+			ds.log.Action("DemandService").Warn("Failed to push demand map", "driver_id", driver.DriverId, "err", err)
+		}
+	}
+}
+
+func (ds *DemandService) supply() map[gridCell]int {
+	return supplyOf(ds.index.Available(), ds.cfg.CellSizeDeg)
+}
+
+func supplyOf(drivers []model.LiveDriver, cellSizeDeg float64) map[gridCell]int {
+	supply := make(map[gridCell]int)
+	for _, driver := range drivers {
+		supply[demandCellOf(driver.Latitude, driver.Longitude, cellSizeDeg)]++
+	}
+	return supply
+}
+
+// demandAround builds the map for a driver at the position. Supply of a cell counts the
+// drivers in it and in the 8 cells around, they are a few minutes away.
+func (ds *DemandService) demandAround(latitude, longitude float64, supply map[gridCell]int) dto.DemandMap {
+	ds.mu.RLock()
+	cells := ds.cells
+	generatedAt := ds.generatedAt
+	ds.mu.RUnlock()
+
+	result := dto.DemandMap{
+		GeneratedAt:   generatedAt.UTC().Format(time.RFC3339),
+		CellSizeDeg:   ds.cfg.CellSizeDeg,
+		WindowMinutes: ds.cfg.WindowMinutes,
+		Cells:         []dto.DemandCell{},
+		Suggestions:   []dto.RepositionAdvice{},
+	}
+	here := geo.Point{Lat: latitude, Lng: longitude}
+	for _, c := range cells {
+		center := geo.Point{
+			Lat: (float64(c.cell.lat) + 0.5) * ds.cfg.CellSizeDeg,
+			Lng: (float64(c.cell.lng) + 0.5) * ds.cfg.CellSizeDeg,
+		}
+		distance := geo.HaversineKm(here, center)
+		if distance > ds.cfg.RadiusKm {
+			continue
+		}
+
+		nearby := 0
+		for dLat := -1; dLat <= 1; dLat++ {
+			for dLng := -1; dLng <= 1; dLng++ {
+				nearby += supply[gridCell{lat: c.cell.lat + dLat, lng: c.cell.lng + dLng}]
+			}
+		}
+		cell := dto.DemandCell{
+			Latitude:            center.Lat,
+			Longitude:           center.Lng,
+			OpenRequests:        c.open,
+			Demand:              c.demand,
+			Supply:              nearby,
+			ExpectedWaitMinutes: ds.expectedWait(c.demand, nearby),
+		}
+		result.Cells = append(result.Cells, cell)
+
+		if shortage := c.demand - float64(nearby); shortage >= ds.cfg.MinShortage {
+			result.Suggestions = append(result.Suggestions, dto.RepositionAdvice{
+				DemandCell: cell,
+				DistanceKm: math.Round(distance*100) / 100,
+				Shortage:   shortage,
+			})
+		}
+	}
+
+	sort.Slice(result.Suggestions, func(i, j int) bool {
+		return result.Suggestions[i].DistanceKm < result.Suggestions[j].DistanceKm
+	})
+	if len(result.Suggestions) > ds.cfg.MaxSuggestions {
+		result.Suggestions = result.Suggestions[:max(ds.cfg.MaxSuggestions, 0)]
+	}
+	return result
+}
+
+// expectedWait is the time until the next pickup of the cell for a driver joining the
+// drivers already around, requests are assumed to arrive evenly over the window
+func (ds *DemandService) expectedWait(demand float64, supply int) float64 {
+	if demand <= 0 || ds.cfg.WindowMinutes <= 0 {
+		return float64(ds.cfg.WindowMinutes)
+	}
+	perMinute := demand / float64(ds.cfg.WindowMinutes)
+	return math.Round(float64(supply+1)/perMinute*10) / 10
+}
+
+func demandCellOf(latitude, longitude, cellSizeDeg float64) gridCell {
+	return gridCell{
+		lat: int(math.Floor(latitude / cellSizeDeg)),
+		lng: int(math.Floor(longitude / cellSizeDeg)),
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+var ErrInvalidDestination = errors.New("destination coordinates are out of range")
+
+// DestinationService keeps the destination mode of drivers heading somewhere, a driver in
+// destination mode is only offered rides that bring them closer to the target
+type DestinationService struct {
+	repositories driven.IDestinationRepository
+	cfg          *config.Destinationconfig
+	log          logger.Logger
+}
+
+func NewDestinationService(repositories driven.IDestinationRepository, cfg *config.Destinationconfig, log logger.Logger) *DestinationService {
+	return &DestinationService{repositories: repositories, cfg: cfg, log: log}
+}
+
+func (ds *DestinationService) SetDestination(ctx context.Context, driver_id string, destination dto.SetDestination) (dto.DestinationResponse, error) {
+	if destination.Latitude < -90 || destination.Latitude > 90 || destination.Longitude < -180 || destination.Longitude > 180 {
+		return dto.DestinationResponse{}, ErrInvalidDestination
+	}
+
+	active, err := ds.repositories.Activate(ctx, model.DriverDestination{
+		DriverId:  driver_id,
+		Latitude:  destination.Latitude,
+		Longitude: destination.Longitude,
+		Address:   destination.Address,
+	}, ds.cfg.UsesPerDay)
+	if err != nil {
+		return dto.DestinationResponse{}, err
+	}
+	ds.log.Action("SetDestination").Info("Destination mode on", "driver_id", driver_id, "destination_id", active.DestinationId)
+	return ds.GetDestination(ctx, driver_id)
+}
+
+func (ds *DestinationService) ClearDestination(ctx context.Context, driver_id string) (dto.DestinationResponse, error) {
+	cleared, err := ds.repositories.Deactivate(ctx, driver_id)
+	if err != nil {
+		return dto.DestinationResponse{}, err
+	}
+	if !cleared {
+		return dto.DestinationResponse{}, db.ErrDestinationNotSet
+	}
+	return ds.GetDestination(ctx, driver_id)
+}
+
+func (ds *DestinationService) GetDestination(ctx context.Context, driver_id string) (dto.DestinationResponse, error) {
+	used, err := ds.repositories.UsesToday(ctx, driver_id)
+	if err != nil {
+		return dto.DestinationResponse{}, err
+	}
+	response := dto.DestinationResponse{
+		UsesToday:   used,
+		UsesPerDay:  ds.cfg.UsesPerDay,
+		MinProgress: ds.cfg.MinProgress,
+	}
+
+	destination, err := ds.repositories.GetActive(ctx, driver_id)
+	if errors.Is(err, db.ErrDestinationNotSet) {
+		return response, nil
+	} else if err != nil {
+		return dto.DestinationResponse{}, err
+	}
+	response.Active = true
+	response.DestinationId = destination.DestinationId
+	response.Latitude = destination.Latitude
+	response.Longitude = destination.Longitude
+	response.Address = destination.Address
+	response.ActivatedAt = destination.CreatedAt.UTC().Format(time.RFC3339)
+	return response, nil
+}
+
+// Filter drops the drivers in destination mode for whom the ride does not cut the
+// distance to their target by at least MinProgress. Drivers without a destination stay.
+func (ds *DestinationService) Filter(ctx context.Context, drivers []model.DriverInfo, rideDestination geo.Point) []model.DriverInfo {
+	if len(drivers) == 0 {
+		return drivers
+	}
+	ids := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		ids = append(ids, driver.DriverId)
+	}
+	destinations, err := ds.repositories.GetActiveFor(ctx, ids)
+	if err != nil {
+		// matching goes on without the filter rather than not at all
+		ds.log.Action("DestinationFilter").Error("Failed to get driver destinations", err)
+		return drivers
+	}
+	if len(destinations) == 0 {
+		return drivers
+	}
+
+	progress := min(max(ds.cfg.MinProgress, 0), 1)
+	filtered := drivers[:0:0]
+	for _, driver := range drivers {
+		destination, ok := destinations[driver.DriverId]
+		if !ok {
+			filtered = append(filtered, driver)
+			continue
+		}
+		target := geo.Point{Lat: destination.Latitude, Lng: destination.Longitude}
+		now := geo.HaversineKm(geo.Point{Lat: driver.Latitude, Lng: driver.Longitude}, target)
+		after := geo.HaversineKm(rideDestination, target)
+		if after <= now*(1-progress) {
+			filtered = append(filtered, driver)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
+)
+
+const (
+	DispatchSequential = "sequential"
+	DispatchBroadcast  = "broadcast"
+	DispatchCascade    = "cascade"
+)
+
+// offerResult is the driver that accepted an offer and their response
+type offerResult struct {
+	driver   dto.DriverInfo
+	response websocketdto.RideResponseMessage
+}
+
+// offerWave sends offers to all drivers at once and waits until one of them accepts,
+// everybody declines or the timeout passes. The first accept wins.
+type offerWave func(ctx context.Context, drivers []dto.DriverInfo, timeout time.Duration) (offerResult, bool)
+
+// DispatchStrategy decides in which order and groups a ride is offered to the candidates
+type DispatchStrategy interface {
+	Name() string
+	Dispatch(ctx context.Context, drivers []dto.DriverInfo, wave offerWave) (offerResult, bool)
+}
+
+// NewDispatchStrategy returns the strategy by name, sequential when the name is unknown
+func NewDispatchStrategy(name string, cfg *config.Dispatchconfig) DispatchStrategy {
+	timeout := time.Duration(cfg.OfferTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+
+	switch name {
+	case DispatchBroadcast:
+		return &BroadcastDispatch{Size: cfg.BroadcastSize, Timeout: timeout}
+	case DispatchCascade:
+		return &CascadeDispatch{Waves: cfg.CascadeWaves, Timeout: timeout}
+	default:
+		return &SequentialDispatch{Timeout: timeout}
+	}
+}
+
+// SequentialDispatch offers the ride to one driver at a time
+type SequentialDispatch struct {
+	Timeout time.Duration
+}
+
+func (s *SequentialDispatch) Name() string { return DispatchSequential }
+
+func (s *SequentialDispatch) Dispatch(ctx context.Context, drivers []dto.DriverInfo, wave offerWave) (offerResult, bool) {
+	for i := range drivers {
+		if ctx.Err() != nil {
+			break
+		}
+		if res, ok := wave(ctx, drivers[i:i+1], s.Timeout); ok {
+			return res, true
+		}
+	}
+	return offerResult{}, false
+}
+
+// BroadcastDispatch offers the ride to the top Size drivers at once
+type BroadcastDispatch struct {
+	Size    int
+	Timeout time.Duration
+}
+
+func (b *BroadcastDispatch) Name() string { return DispatchBroadcast }
+
+func (b *BroadcastDispatch) Dispatch(ctx context.Context, drivers []dto.DriverInfo, wave offerWave) (offerResult, bool) {
+	size := b.Size
+	if size <= 0 || size > len(drivers) {
+		size = len(drivers)
+	}
+	if size == 0 {
+		return offerResult{}, false
+	}
+	return wave(ctx, drivers[:size], b.Timeout)
+}
+
+// CascadeDispatch offers the ride in growing waves of the next best drivers,
+// the last wave size repeats until the candidates run out
+type CascadeDispatch struct {
+	Waves   []int
+	Timeout time.Duration
+}
+
+func (c *CascadeDispatch) Name() string { return DispatchCascade }
+
+func (c *CascadeDispatch) Dispatch(ctx context.Context, drivers []dto.DriverInfo, wave offerWave) (offerResult, bool) {
+	waves := c.Waves
+	if len(waves) == 0 {
+		waves = []int{1}
+	}
+
+	for i, start := 0, 0; start < len(drivers); i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		size := waves[min(i, len(waves)-1)]
+		end := min(start+size, len(drivers))
+		if res, ok := wave(ctx, drivers[start:end], c.Timeout); ok {
+			return res, true
+		}
+		start = end
+	}
+	return offerResult{}, false
+}
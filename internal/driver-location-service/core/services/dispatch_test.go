@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+)
+
+func candidates(n int) []dto.DriverInfo {
+	drivers := make([]dto.DriverInfo, n)
+	for i := range drivers {
+		drivers[i].DriverId = fmt.Sprintf("d%d", i)
+	}
+	return drivers
+}
+
+// recordWave returns a wave accepted by the driver accept, or by nobody when empty,
+// and the sizes of the waves it was called with
+func recordWave(accept string) (offerWave, *[]int) {
+	var sizes []int
+	wave := func(ctx context.Context, drivers []dto.DriverInfo, timeout time.Duration) (offerResult, bool) {
+		sizes = append(sizes, len(drivers))
+		for _, driver := range drivers {
+			if driver.DriverId == accept {
+				return offerResult{driver: driver}, true
+			}
+		}
+		return offerResult{}, false
+	}
+	return wave, &sizes
+}
+
+func TestDispatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  DispatchStrategy
+		drivers   int
+		accept    string
+		wantSizes []int
+		wantOk    bool
+	}{
+		{name: "sequential accepted", strategy: &SequentialDispatch{}, drivers: 4, accept: "d2", wantSizes: []int{1, 1, 1}, wantOk: true},
+		{name: "sequential declined", strategy: &SequentialDispatch{}, drivers: 3, wantSizes: []int{1, 1, 1}},
+		{name: "sequential no drivers", strategy: &SequentialDispatch{}, drivers: 0, wantSizes: nil},
+		{name: "broadcast top size", strategy: &BroadcastDispatch{Size: 3}, drivers: 5, accept: "d1", wantSizes: []int{3}, wantOk: true},
+		{name: "broadcast beyond top size", strategy: &BroadcastDispatch{Size: 3}, drivers: 5, accept: "d4", wantSizes: []int{3}},
+		{name: "broadcast size above candidates", strategy: &BroadcastDispatch{Size: 10}, drivers: 2, wantSizes: []int{2}},
+		{name: "broadcast without size", strategy: &BroadcastDispatch{}, drivers: 4, wantSizes: []int{4}},
+		{name: "broadcast no drivers", strategy: &BroadcastDispatch{Size: 3}, drivers: 0, wantSizes: nil},
+		{name: "cascade last wave repeats", strategy: &CascadeDispatch{Waves: []int{1, 2, 4}}, drivers: 14, wantSizes: []int{1, 2, 4, 4, 3}},
+		{name: "cascade accepted in second wave", strategy: &CascadeDispatch{Waves: []int{1, 2, 4}}, drivers: 10, accept: "d2", wantSizes: []int{1, 2}, wantOk: true},
+		{name: "cascade without waves", strategy: &CascadeDispatch{}, drivers: 3, wantSizes: []int{1, 1, 1}},
+		{name: "cascade fewer candidates than first wave", strategy: &CascadeDispatch{Waves: []int{5}}, drivers: 2, wantSizes: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wave, sizes := recordWave(tt.accept)
+			res, ok := tt.strategy.Dispatch(context.Background(), candidates(tt.drivers), wave)
+			if ok != tt.wantOk {
+				t.Fatalf("Dispatch ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && res.driver.DriverId != tt.accept {
+				t.Errorf("Dispatch driver = %s, want %s", res.driver.DriverId, tt.accept)
+			}
+			if !slices.Equal(*sizes, tt.wantSizes) {
+				t.Errorf("wave sizes = %v, want %v", *sizes, tt.wantSizes)
+			}
+		})
+	}
+}
+
+func TestDispatchStopsWhenCancelled(t *testing.T) {
+	for _, strategy := range []DispatchStrategy{&SequentialDispatch{}, &CascadeDispatch{Waves: []int{1}}} {
+		t.Run(strategy.Name(), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			calls := 0
+			wave := func(ctx context.Context, drivers []dto.DriverInfo, timeout time.Duration) (offerResult, bool) {
+				calls++
+				cancel()
+				return offerResult{}, false
+			}
+			if _, ok := strategy.Dispatch(ctx, candidates(3), wave); ok {
+				t.Fatal("Dispatch accepted after cancel")
+			}
+			if calls != 1 {
+				t.Errorf("waves after cancel = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestNewDispatchStrategy(t *testing.T) {
+	cfg := &config.Dispatchconfig{BroadcastSize: 3, CascadeWaves: []int{1, 3}}
+
+	tests := []struct {
+		name        string
+		wantName    string
+		wantTimeout time.Duration
+		timeoutSec  int
+	}{
+		{name: DispatchSequential, wantName: DispatchSequential, timeoutSec: 20, wantTimeout: 20 * time.Second},
+		{name: DispatchBroadcast, wantName: DispatchBroadcast, timeoutSec: 20, wantTimeout: 20 * time.Second},
+		{name: DispatchCascade, wantName: DispatchCascade, timeoutSec: 20, wantTimeout: 20 * time.Second},
+		{name: "unknown", wantName: DispatchSequential, timeoutSec: 20, wantTimeout: 20 * time.Second},
+		{name: "default timeout", wantName: DispatchSequential, timeoutSec: 0, wantTimeout: 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.OfferTimeoutSec = tt.timeoutSec
+			strategy := NewDispatchStrategy(tt.name, cfg)
+			if strategy.Name() != tt.wantName {
+				t.Errorf("Name = %s, want %s", strategy.Name(), tt.wantName)
+			}
+
+			var timeouts []time.Duration
+			wave := func(ctx context.Context, drivers []dto.DriverInfo, timeout time.Duration) (offerResult, bool) {
+				timeouts = append(timeouts, timeout)
+				return offerResult{}, false
+			}
+			strategy.Dispatch(context.Background(), candidates(1), wave)
+			if len(timeouts) != 1 || timeouts[0] != tt.wantTimeout {
+				t.Errorf("wave timeouts = %v, want [%v]", timeouts, tt.wantTimeout)
+			}
+		})
+	}
+}
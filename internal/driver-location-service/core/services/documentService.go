@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/filestore"
+	"ride-hail/internal/logger"
+)
+
+var (
+	ErrInvalidDocument     = errors.New("invalid document")
+	ErrUnsupportedDocument = errors.New("document must be a PDF, JPEG or PNG file")
+	ErrDocumentTooLarge    = errors.New("document is too large")
+	ErrDriverNotVerified   = errors.New("driver is not verified")
+	ErrDocumentsExpired    = errors.New("driver documents are expired")
+)
+
+// file extensions of the accepted content types, sniffed from the upload itself
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// DocumentService keeps the documents drivers upload for verification. Admins review them
+// in admin-service, a driver is cleared to drive once verified and while none of the
+// required documents has expired.
+type DocumentService struct {
+	repositories driven.IDocumentRepository
+	store        filestore.Store
+	cfg          *config.Documentsconfig
+	log          logger.Logger
+}
+
+func NewDocumentService(repositories driven.IDocumentRepository, store filestore.Store, cfg *config.Documentsconfig, log logger.Logger) *DocumentService {
+	return &DocumentService{repositories: repositories, store: store, cfg: cfg, log: log}
+}
+
+func (ds *DocumentService) MaxUploadBytes() int64 {
+	return int64(max(ds.cfg.MaxUploadMB, 1)) << 20
+}
+
+// Upload stores the file and records the document as PENDING review
+func (ds *DocumentService) Upload(ctx context.Context, driver_id, documentType, expiresAt, fileName string, file io.Reader) (dto.DriverDocument, error) {
+	if !slices.Contains(model.RequiredDocuments, documentType) {
+		return dto.DriverDocument{}, fmt.Errorf("%w: document_type must be one of %v", ErrInvalidDocument, model.RequiredDocuments)
+	}
+	expires, err := time.Parse(time.DateOnly, expiresAt)
+	if err != nil {
+		return dto.DriverDocument{}, fmt.Errorf("%w: expires_at must be YYYY-MM-DD", ErrInvalidDocument)
+	}
+	if expired(expires) {
+		return dto.DriverDocument{}, fmt.Errorf("%w: document has already expired", ErrInvalidDocument)
+	}
+
+	reader := bufio.NewReaderSize(file, 512)
+	head, _ := reader.Peek(512)
+	contentType := http.DetectContentType(head)
+	ext, ok := documentTypes[contentType]
+	if !ok {
+		return dto.DriverDocument{}, ErrUnsupportedDocument
+	}
+
+	key, err := documentKey(driver_id, ext)
+	if err != nil {
+		return dto.DriverDocument{}, err
+	}
+	limit := ds.MaxUploadBytes()
+	size, err := ds.store.Put(ctx, key, io.LimitReader(reader, limit+1))
+	if err != nil {
+		return dto.DriverDocument{}, err
+	}
+	if size > limit {
+		ds.discard(key)
+		return dto.DriverDocument{}, ErrDocumentTooLarge
+	}
+
+	document, err := ds.repositories.Create(ctx, model.DriverDocument{
+		DriverId:     driver_id,
+		DocumentType: documentType,
+		FileKey:      key,
+		FileName:     fileName,
+		ContentType:  contentType,
+		SizeBytes:    size,
+		ExpiresAt:    expires,
+	})
+	if err != nil {
+		ds.discard(key)
+		return dto.DriverDocument{}, err
+	}
+	ds.log.Action("UploadDocument").Info("Document uploaded", "driver_id", driver_id, "document_id", document.DocumentId, "document_type", documentType)
+	return documentToDTO(document), nil
+}
+
+// GetDocuments returns the documents of the driver and what is still missing to drive
+func (ds *DocumentService) GetDocuments(ctx context.Context, driver_id string) (dto.DriverDocuments, error) {
+	verified, err := ds.repositories.IsVerified(ctx, driver_id)
+	if err != nil {
+		return dto.DriverDocuments{}, err
+	}
+	documents, err := ds.repositories.List(ctx, driver_id)
+	if err != nil {
+		return dto.DriverDocuments{}, err
+	}
+
+	response := dto.DriverDocuments{
+		DriverId:   driver_id,
+		IsVerified: verified,
+		Missing:    []string{},
+		Expired:    []string{},
+		Documents:  make([]dto.DriverDocument, 0, len(documents)),
+	}
+	for _, document := range documents {
+		response.Documents = append(response.Documents, documentToDTO(document))
+	}
+	for _, required := range model.RequiredDocuments {
+		approved, valid := false, false
+		for _, document := range documents {
+			if document.DocumentType != required || document.Status != model.DocumentStatusApproved {
+				continue
+			}
+			approved = true
+			valid = valid || !expired(document.ExpiresAt)
+		}
+		if !approved {
+			response.Missing = append(response.Missing, required)
+		} else if !valid {
+			response.Expired = append(response.Expired, required)
+		}
+	}
+	response.CanDrive = verified && len(response.Missing) == 0 && len(response.Expired) == 0
+	return response, nil
+}
+
+// CheckCleared refuses drivers that are not verified or whose documents expired
+func (ds *DocumentService) CheckCleared(ctx context.Context, driver_id string) error {
+	documents, err := ds.GetDocuments(ctx, driver_id)
+	if err != nil {
+		return err
+	}
+	if !documents.IsVerified || len(documents.Missing) > 0 {
+		return ErrDriverNotVerified
+	}
+	if len(documents.Expired) > 0 {
+		return fmt.Errorf("%w: %v", ErrDocumentsExpired, documents.Expired)
+	}
+	return nil
+}
+
+// Filter drops the drivers that are not cleared to drive from matching
+func (ds *DocumentService) Filter(ctx context.Context, drivers []model.DriverInfo) ([]model.DriverInfo, error) {
+	if len(drivers) == 0 {
+		return drivers, nil
+	}
+	ids := make([]string, 0, len(drivers))
+	for _, driver := range drivers {
+		ids = append(ids, driver.DriverId)
+	}
+	cleared, err := ds.repositories.GetCleared(ctx, ids, model.RequiredDocuments)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := drivers[:0:0]
+	for _, driver := range drivers {
+		if cleared[driver.DriverId] {
+			filtered = append(filtered, driver)
+		}
+	}
+	return filtered, nil
+}
+
+func (ds *DocumentService) discard(key string) {
+	if err := ds.store.Delete(context.Background(), key); err != nil {
+		ds.log.Action("UploadDocument").Warn("Failed to delete orphan document file", "key", key, "err", err)
+	}
+}
+
+// expired compares dates only, a document is valid through its expiry day
+func expired(expiresAt time.Time) bool {
+	today := time.Now().UTC().Format(time.DateOnly)
+	return expiresAt.Format(time.DateOnly) < today
+}
+
+func documentKey(driver_id, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s%s", driver_id, hex.EncodeToString(b), ext), nil
+}
+
+func documentToDTO(document model.DriverDocument) dto.DriverDocument {
+	out := dto.DriverDocument{
+		DocumentId:   document.DocumentId,
+		DocumentType: document.DocumentType,
+		Status:       document.Status,
+		FileName:     document.FileName,
+		ContentType:  document.ContentType,
+		SizeBytes:    document.SizeBytes,
+		ExpiresAt:    document.ExpiresAt.Format(time.DateOnly),
+		Expired:      expired(document.ExpiresAt),
+		UploadedAt:   document.UploadedAt.UTC().Format(time.RFC3339),
+		ReviewNote:   document.ReviewNote,
+	}
+	if document.ReviewedAt != nil {
+		out.ReviewedAt = document.ReviewedAt.UTC().Format(time.RFC3339)
+	}
+	return out
+}
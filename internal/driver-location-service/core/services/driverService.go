@@ -3,24 +3,84 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sync"
+	"time"
 
+	"ride-hail/internal/driver-location-service/adapters/service/db"
 	"ride-hail/internal/driver-location-service/core/domain/dto"
 	"ride-hail/internal/driver-location-service/core/domain/model"
 	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
 	"ride-hail/internal/driver-location-service/core/ports/driven"
 	ports "ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
 	"ride-hail/internal/logger"
+	"ride-hail/internal/routing"
 )
 
+var ErrRideInProgress = errors.New("complete the ride in progress before going offline")
+
+// routeDeadline bounds routing every candidate to the pickup, a candidate the router
+// does not answer for in time keeps the fallback estimate
+const routeDeadline = 2 * time.Second
+
 type DriverService struct {
 	repositories driven.IDriverRepository
 	log          logger.Logger
 	broker       ports.IDriverBroker
+	router       routing.Router
+	scores       *ScoreService
+	index        *DriverIndex
+	pipeline     *LocationPipeline
+	writer       driven.ILocationWriter
+	arrival      *ArrivalDetector
+	events       *DriverEvents
+	earnings     *EarningsService
+	destinations *DestinationService
+	hours        *HoursService
+	documents    *DocumentService
+	vehicles     *VehicleService
 }
 
-func NewDriverService(repositories driven.IDriverRepository, log logger.Logger, broker ports.IDriverBroker) *DriverService {
-	return &DriverService{repositories: repositories, log: log, broker: broker}
+// DriverServiceDeps are the repositories and services the DriverService works with
+type DriverServiceDeps struct {
+	Repositories driven.IDriverRepository
+	Log          logger.Logger
+	Broker       ports.IDriverBroker
+	Router       routing.Router
+	Scores       *ScoreService
+	Index        *DriverIndex
+	Pipeline     *LocationPipeline
+	Writer       driven.ILocationWriter
+	Arrival      *ArrivalDetector
+	Events       *DriverEvents
+	Earnings     *EarningsService
+	Destinations *DestinationService
+	Hours        *HoursService
+	Documents    *DocumentService
+	Vehicles     *VehicleService
+}
+
+func NewDriverService(deps DriverServiceDeps) *DriverService {
+	return &DriverService{
+		repositories: deps.Repositories,
+		log:          deps.Log,
+		broker:       deps.Broker,
+		router:       deps.Router,
+		scores:       deps.Scores,
+		index:        deps.Index,
+		pipeline:     deps.Pipeline,
+		writer:       deps.Writer,
+		arrival:      deps.Arrival,
+		events:       deps.Events,
+		earnings:     deps.Earnings,
+		destinations: deps.Destinations,
+		hours:        deps.Hours,
+		documents:    deps.Documents,
+		vehicles:     deps.Vehicles,
+	}
 }
 
 func (ds *DriverService) GoOnline(ctx context.Context, coordDTO dto.DriverCoordinatesDTO) (dto.DriverOnlineResponse, error) {
@@ -30,10 +90,32 @@ func (ds *DriverService) GoOnline(ctx context.Context, coordDTO dto.DriverCoordi
 	coord.Latitude = coordDTO.Latitude
 	coord.Longitude = coordDTO.Longitude
 
+	if err := ds.documents.CheckCleared(ctx, coord.Driver_id); err != nil {
+		return dto.DriverOnlineResponse{}, err
+	}
+	if err := ds.hours.CheckGoOnline(ctx, coord.Driver_id); err != nil {
+		return dto.DriverOnlineResponse{}, err
+	}
+	// drivers without registered vehicles keep going online with the profile vehicle
+	vehicle, ok, err := ds.vehicles.SelectForSession(ctx, coord.Driver_id, coordDTO.Vehicle_id)
+	if err != nil {
+		return dto.DriverOnlineResponse{}, err
+	}
+	if ok {
+		coord.Vehicle_id = vehicle.VehicleId
+		response.Vehicle = &vehicle
+	}
+
 	session_id, err := ds.repositories.GoOnline(ctx, coord)
 	if err != nil {
 		return dto.DriverOnlineResponse{}, err
 	}
+	if driver, err := ds.repositories.GetLiveDriver(ctx, coord.Driver_id); err != nil {
+		ds.log.Warn("cannot add driver to the index", "driver_id", coord.Driver_id, "err", err)
+	} else {
+		ds.index.Upsert(driver)
+	}
+	ds.pipeline.Reset(coord.Driver_id)
 	response.Session_id = session_id
 	response.Status = "AVAILABLE"
 	response.Message = "You are now online and ready to accept rides"
@@ -41,10 +123,26 @@ func (ds *DriverService) GoOnline(ctx context.Context, coordDTO dto.DriverCoordi
 }
 
 func (ds *DriverService) GoOffline(ctx context.Context, driver_id string) (dto.DriverOfflineRespones, error) {
+	// a ride waiting for the driver is cancelled so the passenger is not left waiting,
+	// a ride in progress has to be completed first
+	ride, err := ds.repositories.GetActiveRide(ctx, driver_id)
+	if err == nil {
+		if ride.Status == model.RideStatusInProgress {
+			return dto.DriverOfflineRespones{}, ErrRideInProgress
+		}
+		if _, err := ds.cancelRide(ctx, driver_id, ride.Ride_id, "driver_offline"); err != nil {
+			return dto.DriverOfflineRespones{}, err
+		}
+	} else if !errors.Is(err, db.ErrNoActiveRide) {
+		return dto.DriverOfflineRespones{}, err
+	}
+
 	results, err := ds.repositories.GoOffline(ctx, driver_id)
 	if err != nil {
 		return dto.DriverOfflineRespones{}, err
 	}
+	ds.index.Remove(driver_id)
+	ds.pipeline.Reset(driver_id)
 	var response dto.DriverOfflineRespones
 	response.Session_id = results.Session_id
 	response.Status = "OFFLINE"
@@ -56,22 +154,116 @@ func (ds *DriverService) GoOffline(ctx context.Context, driver_id string) (dto.D
 }
 
 func (ds *DriverService) UpdateLocation(ctx context.Context, request dto.NewLocation, driver_id string) (dto.NewLocationResponse, error) {
+	point, err := ds.pipeline.Process(LocationPoint{
+		DriverId:       driver_id,
+		Latitude:       request.Latitude,
+		Longitude:      request.Longitude,
+		AccuracyMeters: request.Accuracy_meters,
+		SpeedKmh:       request.Speed_kmh,
+		HeadingDegrees: request.Heading_Degrees,
+		At:             time.Now(),
+	})
+	if err != nil {
+		return dto.NewLocationResponse{}, err
+	}
+
 	var requestDAO model.NewLocation
-	requestDAO.Accuracy_meters = request.Accuracy_meters
-	requestDAO.Heading_Degrees = request.Heading_Degrees
-	requestDAO.Latitude = request.Latitude
-	requestDAO.Longitude = request.Longitude
-	requestDAO.Speed_kmh = request.Speed_kmh
-	response, err := ds.repositories.UpdateLocation(ctx, driver_id, requestDAO)
+	requestDAO.Accuracy_meters = point.AccuracyMeters
+	requestDAO.Heading_Degrees = point.HeadingDegrees
+	requestDAO.Latitude = point.Latitude
+	requestDAO.Longitude = point.Longitude
+	requestDAO.Speed_kmh = point.SpeedKmh
+	response, err := ds.writer.Write(ctx, driver_id, requestDAO)
 	if err != nil {
 		return dto.NewLocationResponse{}, err
 	}
+	ds.index.Move(driver_id, point.Latitude, point.Longitude)
+	ds.arrival.Observe(ctx, driver_id, point.Latitude, point.Longitude, point.At)
 	var responseDTO dto.NewLocationResponse
 	responseDTO.Coordinate_id = response.Coordinate_id
 	responseDTO.Updated_at = response.Updated_at
+	responseDTO.Latitude = point.Latitude
+	responseDTO.Longitude = point.Longitude
 	return responseDTO, nil
 }
 
+// ActiveRideId returns the ride the driver is on or heading to, empty when there is none
+func (ds *DriverService) ActiveRideId(ctx context.Context, driver_id string) (string, error) {
+	ride, err := ds.repositories.GetActiveRide(ctx, driver_id)
+	if errors.Is(err, db.ErrNoActiveRide) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return ride.Ride_id, nil
+}
+
+func (ds *DriverService) GetLocationStats(ctx context.Context, driver_id string) dto.LocationStats {
+	return ds.pipeline.Stats(driver_id)
+}
+
+func (ds *DriverService) GetLocationWriterMetrics(ctx context.Context) dto.LocationWriterMetrics {
+	stats := ds.writer.Stats()
+	return dto.LocationWriterMetrics{
+		QueueLength:      stats.QueueLength,
+		QueueCapacity:    stats.QueueCapacity,
+		Written:          stats.Written,
+		Failed:           stats.Failed,
+		Refused:          stats.Refused,
+		Batches:          stats.Batches,
+		AvgBatchSize:     stats.AvgBatchSize,
+		AvgFlushMs:       stats.AvgFlushMs,
+		MaxFlushMs:       stats.MaxFlushMs,
+		AvgWaitMs:        stats.AvgWaitMs,
+		MaxWaitMs:        stats.MaxWaitMs,
+		ThroughputPerSec: stats.ThroughputPerSec,
+	}
+}
+
+// MarkArrived lets the driver report the arrival at the pickup without waiting for the detection
+func (ds *DriverService) MarkArrived(ctx context.Context, driver_id string) (dto.ArrivedResponse, error) {
+	return ds.arrival.MarkArrived(ctx, driver_id)
+}
+
+// CancelRide cancels the ride the driver is on the way to, the active one when no ride id is given
+func (ds *DriverService) CancelRide(ctx context.Context, driver_id string, request dto.CancelRide) (dto.CancelRideResponse, error) {
+	ride_id := request.Ride_id
+	if ride_id == "" {
+		ride, err := ds.repositories.GetActiveRide(ctx, driver_id)
+		if err != nil {
+			return dto.CancelRideResponse{}, err
+		}
+		ride_id = ride.Ride_id
+	}
+	reason := request.Reason
+	if reason == "" {
+		reason = "driver_cancelled"
+	}
+
+	cancelledAt, err := ds.cancelRide(ctx, driver_id, ride_id, reason)
+	if err != nil {
+		return dto.CancelRideResponse{}, err
+	}
+	return dto.CancelRideResponse{
+		Ride_id:      ride_id,
+		Status:       model.RideStatusCancelled,
+		Cancelled_at: cancelledAt.Format(time.RFC3339),
+		Message:      "Ride cancelled, you are available for new rides",
+	}, nil
+}
+
+func (ds *DriverService) cancelRide(ctx context.Context, driver_id, ride_id, reason string) (time.Time, error) {
+	cancelledAt, err := ds.repositories.CancelRide(ctx, ride_id, driver_id, reason)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ds.arrival.Forget(driver_id)
+	ds.index.SetStatus(driver_id, "AVAILABLE")
+	ds.events.Publish(ctx, driver_id, ride_id, model.RideStatusCancelled, reason, cancelledAt)
+	return cancelledAt, nil
+}
+
 func (ds *DriverService) StartRide(ctx context.Context, requestMessage dto.StartRide) (dto.StartRideResponse, error) {
 	var requestedData model.StartRide
 	requestedData.Ride_id = requestMessage.Ride_id
@@ -82,6 +274,9 @@ func (ds *DriverService) StartRide(ctx context.Context, requestMessage dto.Start
 	if err != nil {
 		return dto.StartRideResponse{}, err
 	}
+	ds.index.SetStatus(requestedData.Driver_location.Driver_id, results.Status)
+	ds.arrival.Forget(requestedData.Driver_location.Driver_id)
+	ds.events.Publish(ctx, requestedData.Driver_location.Driver_id, requestedData.Ride_id, model.RideStatusInProgress, "", time.Now())
 
 	var response dto.StartRideResponse
 	response.Message = "Ride started successfully"
@@ -91,17 +286,24 @@ func (ds *DriverService) StartRide(ctx context.Context, requestMessage dto.Start
 	return response, nil
 }
 
-func (ds *DriverService) CompleteRide(ctx context.Context, request dto.RideCompleteForm) (dto.RideCompleteResponse, error) {
+// CompleteRide completes the ride in progress of the driver, the earning is booked in the
+// same transaction. A retry of a completed ride gets the same answer and publishes nothing.
+func (ds *DriverService) CompleteRide(ctx context.Context, driver_id string, request dto.RideCompleteForm) (dto.RideCompleteResponse, error) {
 	var requestDAO model.RideCompleteForm
 	requestDAO.Ride_id = request.Ride_id
+	requestDAO.Driver_id = driver_id
 	requestDAO.ActualDistancekm = request.ActualDistancekm
 	requestDAO.ActualDurationm = request.ActualDurationm
 	requestDAO.FinalLocation.Latitude = request.FinalLocation.Latitude
 	requestDAO.FinalLocation.Longitude = request.FinalLocation.Longitude
-	results, err := ds.repositories.CompleteRide(ctx, requestDAO)
+	results, err := ds.repositories.CompleteRide(ctx, requestDAO, ds.earnings.CommissionRate())
 	if err != nil {
 		return dto.RideCompleteResponse{}, err
 	}
+	if results.Completed {
+		ds.index.SetStatus(driver_id, results.Status)
+		ds.events.Publish(ctx, driver_id, request.Ride_id, model.RideStatusCompleted, "", time.Now())
+	}
 	var response dto.RideCompleteResponse
 	response.Message = results.Message
 	response.Ride_id = results.Ride_id
@@ -111,13 +313,27 @@ func (ds *DriverService) CompleteRide(ctx context.Context, request dto.RideCompl
 	return response, nil
 }
 
-func (ds *DriverService) FindAppropriateDrivers(ctx context.Context, longtitude, latitude float64, vehicleType string) ([]dto.DriverInfo, error) {
-	drivers, err := ds.repositories.FindDrivers(ctx, longtitude, latitude, vehicleType)
+func (ds *DriverService) FindAppropriateDrivers(ctx context.Context, longtitude, latitude, destLongtitude, destLatitude float64, vehicleType string) ([]dto.DriverInfo, error) {
+	var drivers []model.DriverInfo
+	if ds.index.Current() {
+		drivers = ds.index.Nearest(longtitude, latitude, vehicleType)
+	} else {
+		// index is not built yet, or drivers on other replicas moved since the last resync:
+		// Postgres has every position from the location writers
+		var err error
+		drivers, err = ds.repositories.FindDrivers(ctx, longtitude, latitude, vehicleType, ds.vehicles.rules.OptInFrom(vehicleType))
+		if err != nil {
+			fmt.Println("Service Error Arrived ", err)
+			return []dto.DriverInfo{}, err
+		}
+	}
+	drivers, err := ds.documents.Filter(ctx, drivers)
 	if err != nil {
-		fmt.Println("Service Error Arrived ", err)
 		return []dto.DriverInfo{}, err
 	}
-	var results []dto.DriverInfo
+	drivers = ds.hours.Filter(drivers)
+	drivers = ds.destinations.Filter(ctx, drivers, geo.Point{Lat: destLatitude, Lng: destLongtitude})
+	results := make([]dto.DriverInfo, 0, len(drivers))
 	for _, driver := range drivers {
 		var result dto.DriverInfo
 		result.DriverId = driver.DriverId
@@ -133,29 +349,93 @@ func (ds *DriverService) FindAppropriateDrivers(ctx context.Context, longtitude,
 		}
 		results = append(results, result)
 	}
-	return results, nil
+	ds.routeToPickup(ctx, results, geo.Point{Lat: latitude, Lng: longtitude})
+	// the repository orders by straight line distance, rank by road distance and driver quality
+	return ds.scores.Rank(ctx, vehicleType, results), nil
+}
+
+// routeToPickup sets the road distance and ETA of every driver to the pickup, the routes
+// are asked at once under a shared deadline, the straight line stays as a last resort
+func (ds *DriverService) routeToPickup(ctx context.Context, drivers []dto.DriverInfo, pickup geo.Point) {
+	ctx, cancel := context.WithTimeout(ctx, routeDeadline)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := range drivers {
+		wg.Add(1)
+		go func(driver *dto.DriverInfo) {
+			defer wg.Done()
+			route, err := ds.router.Route(ctx, geo.Point{Lat: driver.Latitude, Lng: driver.Longitude}, pickup)
+			if err != nil {
+				ds.log.Warn("cannot route driver to pickup", "driver_id", driver.DriverId, "err", err)
+				return
+			}
+			driver.Distance = route.DistanceKm
+			driver.EtaMinutes = int(math.Ceil(route.DurationMinutes))
+		}(&drivers[i])
+	}
+	wg.Wait()
+}
+
+// StillAvailable keeps the drivers Postgres has as AVAILABLE. The index of this replica
+// learns of rides matched on other replicas only at the next resync, the drivers it has
+// wrong are corrected on the way.
+func (ds *DriverService) StillAvailable(ctx context.Context, drivers []dto.DriverInfo) ([]dto.DriverInfo, error) {
+	if len(drivers) == 0 {
+		return drivers, nil
+	}
+	ids := make([]string, len(drivers))
+	for i, driver := range drivers {
+		ids[i] = driver.DriverId
+	}
+	statuses, err := ds.repositories.GetDriverStatuses(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	available := drivers[:0:0]
+	for _, driver := range drivers {
+		status, ok := statuses[driver.DriverId]
+		if ok && status == "AVAILABLE" {
+			available = append(available, driver)
+			continue
+		}
+		if ok {
+			ds.index.SetStatus(driver.DriverId, status)
+		} else {
+			ds.index.Remove(driver.DriverId)
+		}
+	}
+	return available, nil
 }
 
 func (ds *DriverService) CalculateRideDetails(ctx context.Context, driverLocation dto.Location, passagerLocation dto.Location) (float64, int, error) {
-	distance, err := ds.repositories.CalculateRideDetails(ctx,
-		model.Location{
-			Latitude:  driverLocation.Latitude,
-			Longitude: driverLocation.Longitude,
-		},
-		model.Location{
-			Latitude:  passagerLocation.Latitude,
-			Longitude: passagerLocation.Longitude,
-		},
+	route, err := ds.router.Route(ctx,
+		geo.Point{Lat: driverLocation.Latitude, Lng: driverLocation.Longitude},
+		geo.Point{Lat: passagerLocation.Latitude, Lng: passagerLocation.Longitude},
 	)
 	if err != nil {
 		return 0, 0, err
 	}
-	minutes := int(distance / 45)
-	return distance, minutes, nil
+	return route.DistanceKm, int(math.Ceil(route.DurationMinutes)), nil
 }
 
 func (d *DriverService) UpdateDriverStatus(ctx context.Context, driver_id string, status string) error {
-	return d.repositories.UpdateDriverStatus(ctx, driver_id, status)
+	if err := d.repositories.UpdateDriverStatus(ctx, driver_id, status); err != nil {
+		return err
+	}
+	d.index.SetStatus(driver_id, status)
+	return nil
+}
+
+// ClaimDriver makes the driver BUSY for the ride unless another replica got the driver first
+func (d *DriverService) ClaimDriver(ctx context.Context, driver_id, ride_id string) (bool, error) {
+	claimed, err := d.repositories.ClaimDriver(ctx, driver_id, ride_id)
+	if err != nil || !claimed {
+		return false, err
+	}
+	d.index.SetStatus(driver_id, "BUSY")
+	return true, nil
 }
 
 func (d *DriverService) CheckDriverById(ctx context.Context, driver_id string) (bool, error) {
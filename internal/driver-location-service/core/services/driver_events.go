@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	messagebrokerdto "ride-hail/internal/driver-location-service/core/domain/message_broker_dto"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+)
+
+const driverExchange = "driver_topic"
+
+// DriverEvents publishes the driver side ride lifecycle on driver.status.{driver_id},
+// ride-service turns every event into a ride_status_update for the passenger
+type DriverEvents struct {
+	broker driven.IDriverBroker
+	log    logger.Logger
+}
+
+func NewDriverEvents(broker driven.IDriverBroker, log logger.Logger) *DriverEvents {
+	return &DriverEvents{broker: broker, log: log}
+}
+
+// Publish sends the transition of the ride to status. The event id is derived from the
+// ride and the status, each transition happens once so consumers can dedupe on it.
+func (de *DriverEvents) Publish(ctx context.Context, driver_id, ride_id, status, reason string, at time.Time) error {
+	update := messagebrokerdto.DriverStatusUpdate{
+		Version:   messagebrokerdto.DriverStatusUpdateVersion,
+		EventId:   fmt.Sprintf("%s:%s", ride_id, strings.ToLower(status)),
+		DriverId:  driver_id,
+		Status:    status,
+		RideId:    ride_id,
+		Reason:    reason,
+		Timestamp: at.UTC().Format(time.RFC3339),
+	}
+	if err := de.broker.PublishJSON(ctx, driverExchange, fmt.Sprintf("driver.status.%s", driver_id), update); err != nil {
+		de.log.Action("DriverEvents").Error("Failed to publish driver status", err, "ride_id", ride_id, "status", status)
+		return err
+	}
+	de.log.Action("DriverEvents").Info("Driver status published", "ride_id", ride_id, "driver_id", driver_id, "status", status)
+	return nil
+}
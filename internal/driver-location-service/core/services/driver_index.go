@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+const (
+	// same as the PostGIS query
+	indexSearchRadiusKm = 5.0
+	indexSearchLimit    = 10
+
+	kmPerDegreeLat = 111.32
+)
+
+type gridCell struct {
+	lat, lng int
+}
+
+// DriverIndex keeps online drivers in a lat/lng grid so nearest driver
+// queries do not hit Postgres. Postgres stays the source of truth, the index
+// is rebuilt from it on startup and every resync interval, on a connection of
+// its own so the resync never waits behind requests.
+type DriverIndex struct {
+	repositories driven.IDriverRepository
+	rules        *EligibilityRules
+	cfg          *config.Indexconfig
+	log          logger.Logger
+
+	// remote reports whether other replicas hold driver sessions, the index only learns
+	// their moves at the next resync
+	remote func() bool
+
+	mu      sync.RWMutex
+	ready   bool
+	drivers map[string]*model.LiveDriver
+	cells   map[gridCell]map[string]struct{}
+
+	// gen counts the changes made to the index, changed keeps the generation of the last
+	// change of each driver so a rebuild does not undo what happened while its snapshot was read
+	gen     uint64
+	changed map[string]uint64
+}
+
+func NewDriverIndex(repositories driven.IDriverRepository, rules *EligibilityRules, cfg *config.Indexconfig, log logger.Logger) *DriverIndex {
+	return &DriverIndex{
+		repositories: repositories,
+		rules:        rules,
+		cfg:          cfg,
+		log:          log,
+		drivers:      make(map[string]*model.LiveDriver),
+		cells:        make(map[gridCell]map[string]struct{}),
+		changed:      make(map[string]uint64),
+	}
+}
+
+// Run resyncs the index with Postgres until ctx is done, Rebuild must be called first
+func (di *DriverIndex) Run(ctx context.Context) {
+	log := di.log.Action("DriverIndex")
+	if di.cfg.ResyncSec <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(di.cfg.ResyncSec) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := di.Rebuild(ctx); err != nil {
+				log.Error("Failed to resync driver index", err)
+			}
+		}
+	}
+}
+
+// Rebuild replaces the index content with the online drivers from Postgres. Drivers changed
+// after the snapshot was started keep their indexed state, the snapshot may predate the change.
+func (di *DriverIndex) Rebuild(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	di.mu.RLock()
+	start := di.gen
+	di.mu.RUnlock()
+
+	drivers, err := di.repositories.GetLiveDrivers(ctx)
+	if err != nil {
+		return err
+	}
+
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	previous := di.drivers
+	di.drivers = make(map[string]*model.LiveDriver, len(drivers))
+	di.cells = make(map[gridCell]map[string]struct{})
+	for i := range drivers {
+		if di.changed[drivers[i].DriverId] <= start {
+			di.put(&drivers[i])
+		}
+	}
+	for driver_id, gen := range di.changed {
+		if gen <= start {
+			// older changes are part of the snapshot
+			delete(di.changed, driver_id)
+			continue
+		}
+		// a driver missing from previous was removed after the snapshot started
+		if driver, ok := previous[driver_id]; ok {
+			di.put(driver)
+		}
+	}
+	di.ready = true
+
+	di.log.Action("DriverIndex").Info("Driver index rebuilt", "drivers", len(drivers))
+	return nil
+}
+
+// Ready reports whether the index has been built and can serve queries
+func (di *DriverIndex) Ready() bool {
+	di.mu.RLock()
+	defer di.mu.RUnlock()
+	return di.ready
+}
+
+// SetRemote tells the index how to know drivers are connected to other replicas
+func (di *DriverIndex) SetRemote(remote func() bool) {
+	di.remote = remote
+}
+
+// Current reports whether the positions of the index are up to date: it is built and no
+// driver moves on another replica
+func (di *DriverIndex) Current() bool {
+	return di.Ready() && (di.remote == nil || !di.remote())
+}
+
+// Upsert adds or replaces a driver
+func (di *DriverIndex) Upsert(driver model.LiveDriver) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	di.touch(driver.DriverId)
+	di.remove(driver.DriverId)
+	di.put(&driver)
+}
+
+// Move updates the position of an indexed driver
+func (di *DriverIndex) Move(driver_id string, latitude, longitude float64) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	driver, ok := di.drivers[driver_id]
+	if !ok {
+		return
+	}
+	di.touch(driver_id)
+	from, to := di.cell(driver.Latitude, driver.Longitude), di.cell(latitude, longitude)
+	driver.Latitude, driver.Longitude = latitude, longitude
+	if from != to {
+		di.unlink(from, driver_id)
+		di.link(to, driver_id)
+	}
+}
+
+// SetStatus updates the status of an indexed driver, OFFLINE drivers are dropped
+func (di *DriverIndex) SetStatus(driver_id string, status string) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	di.touch(driver_id)
+	if status == "OFFLINE" {
+		di.remove(driver_id)
+		return
+	}
+	if driver, ok := di.drivers[driver_id]; ok {
+		driver.Status = status
+	}
+}
+
+// Get returns a copy of an indexed driver
+func (di *DriverIndex) Get(driver_id string) (model.LiveDriver, bool) {
+	di.mu.RLock()
+	defer di.mu.RUnlock()
+	driver, ok := di.drivers[driver_id]
+	if !ok {
+		return model.LiveDriver{}, false
+	}
+	return *driver, true
+}
+
+// Available returns a copy of every AVAILABLE driver
+func (di *DriverIndex) Available() []model.LiveDriver {
+	di.mu.RLock()
+	defer di.mu.RUnlock()
+	var drivers []model.LiveDriver
+	for _, driver := range di.drivers {
+		if driver.Status == "AVAILABLE" {
+			drivers = append(drivers, *driver)
+		}
+	}
+	return drivers
+}
+
+func (di *DriverIndex) Remove(driver_id string) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	di.touch(driver_id)
+	di.remove(driver_id)
+}
+
+// Nearest returns available drivers serving the ride type within the search radius,
+// closest first, the same result the PostGIS query gives
+func (di *DriverIndex) Nearest(longtitude, latitude float64, vehicleType string) []model.DriverInfo {
+	di.mu.RLock()
+	defer di.mu.RUnlock()
+
+	center := geo.Point{Lat: latitude, Lng: longtitude}
+	latSpan := indexSearchRadiusKm / kmPerDegreeLat
+	lngSpan := latSpan / math.Max(math.Cos(latitude*math.Pi/180), 0.01)
+	minCell := di.cell(latitude-latSpan, longtitude-lngSpan)
+	maxCell := di.cell(latitude+latSpan, longtitude+lngSpan)
+
+	var result []model.DriverInfo
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for lng := minCell.lng; lng <= maxCell.lng; lng++ {
+			for id := range di.cells[gridCell{lat: lat, lng: lng}] {
+				driver := di.drivers[id]
+				if driver.Status != "AVAILABLE" || !di.rules.Serves(driver.VehicleType, driver.OptInTypes, vehicleType) {
+					continue
+				}
+				distance := geo.HaversineKm(center, geo.Point{Lat: driver.Latitude, Lng: driver.Longitude})
+				if distance > indexSearchRadiusKm {
+					continue
+				}
+				result = append(result, model.DriverInfo{
+					DriverId:  driver.DriverId,
+					Name:      driver.Name,
+					Email:     driver.Email,
+					Vehicle:   driver.Vehicle,
+					Rating:    driver.Rating,
+					Latitude:  driver.Latitude,
+					Longitude: driver.Longitude,
+					Distance:  distance,
+				})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Distance != result[j].Distance {
+			return result[i].Distance < result[j].Distance
+		}
+		return result[i].Rating > result[j].Rating
+	})
+	if len(result) > indexSearchLimit {
+		result = result[:indexSearchLimit]
+	}
+	return result
+}
+
+func (di *DriverIndex) cell(latitude, longtitude float64) gridCell {
+	size := di.cfg.CellSizeDeg
+	if size <= 0 {
+		size = 0.01
+	}
+	return gridCell{
+		lat: int(math.Floor(latitude / size)),
+		lng: int(math.Floor(longtitude / size)),
+	}
+}
+
+// touch, put, remove, link and unlink must be called with mu held
+func (di *DriverIndex) touch(driver_id string) {
+	di.gen++
+	di.changed[driver_id] = di.gen
+}
+
+func (di *DriverIndex) put(driver *model.LiveDriver) {
+	if old, ok := di.drivers[driver.DriverId]; ok {
+		di.unlink(di.cell(old.Latitude, old.Longitude), old.DriverId)
+	}
+	di.drivers[driver.DriverId] = driver
+	di.link(di.cell(driver.Latitude, driver.Longitude), driver.DriverId)
+}
+
+func (di *DriverIndex) remove(driver_id string) {
+	driver, ok := di.drivers[driver_id]
+	if !ok {
+		return
+	}
+	di.unlink(di.cell(driver.Latitude, driver.Longitude), driver_id)
+	delete(di.drivers, driver_id)
+}
+
+func (di *DriverIndex) link(c gridCell, driver_id string) {
+	ids, ok := di.cells[c]
+	if !ok {
+		ids = make(map[string]struct{})
+		di.cells[c] = ids
+	}
+	ids[driver_id] = struct{}{}
+}
+
+func (di *DriverIndex) unlink(c gridCell, driver_id string) {
+	if ids, ok := di.cells[c]; ok {
+		delete(ids, driver_id)
+		if len(ids) == 0 {
+			delete(di.cells, c)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+)
+
+type EarningsService struct {
+	repositories driven.IEarningsRepository
+	cfg          *config.Earningsconfig
+	log          logger.Logger
+}
+
+func NewEarningsService(repositories driven.IEarningsRepository, cfg *config.Earningsconfig, log logger.Logger) *EarningsService {
+	return &EarningsService{repositories: repositories, cfg: cfg, log: log}
+}
+
+// CommissionRate is the configured commission, clamped to [0, 1]
+func (es *EarningsService) CommissionRate() float64 {
+	return min(max(es.cfg.CommissionRate, 0), 1)
+}
+
+// DriverEarnings is the part of the fare the driver keeps
+func (es *EarningsService) DriverEarnings(fare float64) float64 {
+	return round2(fare * (1 - es.CommissionRate()))
+}
+
+// RecordRide books a completed ride with the configured commission, once per ride
+func (es *EarningsService) RecordRide(ctx context.Context, ride_id string) (model.RideEarning, bool, error) {
+	earning, recorded, err := es.repositories.RecordRide(ctx, ride_id, es.CommissionRate())
+	if err != nil {
+		return model.RideEarning{}, false, err
+	}
+	if recorded && earning.SessionId == nil {
+		es.log.Warn("Ride booked without an open session", "ride_id", ride_id)
+	}
+	return earning, recorded, nil
+}
+
+func (es *EarningsService) GetStatement(ctx context.Context, driver_id, period string, from, to time.Time) (dto.EarningsStatement, error) {
+	lines, err := es.repositories.GetStatement(ctx, driver_id, period, from, to)
+	if err != nil {
+		return dto.EarningsStatement{}, err
+	}
+
+	statement := dto.EarningsStatement{
+		DriverId: driver_id,
+		Period:   period,
+		From:     from.Format(time.DateOnly),
+		To:       to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Lines:    make([]dto.EarningsLine, 0, len(lines)),
+	}
+	now := time.Now()
+	for _, line := range lines {
+		out := dto.EarningsLine{
+			Start:      line.Start.UTC().Format(time.RFC3339),
+			Rides:      line.Rides,
+			GrossFare:  round2(line.GrossFare),
+			Commission: round2(line.Commission),
+			Tips:       round2(line.Tips),
+			Net:        round2(line.Net),
+		}
+		switch period {
+		case model.EarningsPeriodDay:
+			out.End = line.Start.AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+		case model.EarningsPeriodWeek:
+			out.End = line.Start.AddDate(0, 0, 7).UTC().Format(time.RFC3339)
+		case model.EarningsPeriodSession:
+			if line.SessionId != nil {
+				out.SessionId = *line.SessionId
+			}
+			end := now
+			if line.End != nil {
+				end = *line.End
+				out.End = end.UTC().Format(time.RFC3339)
+			}
+			out.DurationHours = round2(end.Sub(line.Start).Hours())
+		}
+
+		statement.Total.Rides += out.Rides
+		statement.Total.GrossFare += line.GrossFare
+		statement.Total.Commission += line.Commission
+		statement.Total.Tips += line.Tips
+		statement.Total.Net += line.Net
+		statement.Total.DurationHours += out.DurationHours
+		statement.Lines = append(statement.Lines, out)
+	}
+	statement.Total.GrossFare = round2(statement.Total.GrossFare)
+	statement.Total.Commission = round2(statement.Total.Commission)
+	statement.Total.Tips = round2(statement.Total.Tips)
+	statement.Total.Net = round2(statement.Total.Net)
+	statement.Total.DurationHours = round2(statement.Total.DurationHours)
+	return statement, nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
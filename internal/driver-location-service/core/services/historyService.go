@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+const (
+	// late location updates of a ride are still in the writer for a moment after completion
+	compactGrace = 30 * time.Second
+	compactBatch = 100
+)
+
+// HistoryService manages the daily partitions of location_history, applies the
+// retention and downsamples completed rides into ride_tracks
+type HistoryService struct {
+	repositories driven.IHistoryRepository
+	cfg          *config.Retentionconfig
+	log          logger.Logger
+}
+
+func NewHistoryService(repositories driven.IHistoryRepository, cfg *config.Retentionconfig, log logger.Logger) *HistoryService {
+	return &HistoryService{repositories: repositories, cfg: cfg, log: log}
+}
+
+// Run keeps partitions, retention and ride tracks up to date until ctx is done
+func (hs *HistoryService) Run(ctx context.Context) {
+	maintenance := time.NewTicker(seconds(hs.cfg.IntervalSec, time.Hour))
+	defer maintenance.Stop()
+	compaction := time.NewTicker(seconds(hs.cfg.CompactIntervalSec, time.Minute))
+	defer compaction.Stop()
+
+	hs.Maintain(ctx)
+	hs.CompactRides(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-maintenance.C:
+			hs.Maintain(ctx)
+		case <-compaction.C:
+			hs.CompactRides(ctx)
+		}
+	}
+}
+
+// Maintain creates the partitions of yesterday to PartitionAheadDays ahead and drops
+// the data out of retention
+func (hs *HistoryService) Maintain(ctx context.Context) {
+	log := hs.log.Action("HistoryMaintenance")
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for d := -1; d <= hs.cfg.PartitionAheadDays; d++ {
+		day := today.AddDate(0, 0, d)
+		created, err := hs.repositories.EnsurePartition(ctx, day)
+		if err != nil {
+			log.Error("Failed to create location_history partition", err, "day", day.Format(time.DateOnly))
+			continue
+		}
+		if created {
+			log.Info("location_history partition created", "day", day.Format(time.DateOnly))
+		}
+	}
+
+	if hs.cfg.RideDays > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -hs.cfg.RideDays)
+		partitions, err := hs.repositories.ListPartitions(ctx)
+		if err != nil {
+			log.Error("Failed to list location_history partitions", err)
+		}
+		for _, partition := range partitions {
+			if partition.Day.AddDate(0, 0, 1).After(cutoff) {
+				break
+			}
+			if err := hs.repositories.DropPartition(ctx, partition.Name); err != nil {
+				log.Error("Failed to drop location_history partition", err, "partition", partition.Name)
+				continue
+			}
+			log.Info("location_history partition dropped", "partition", partition.Name)
+		}
+		if n, err := hs.repositories.DeleteDefaultPoints(ctx, cutoff); err != nil {
+			log.Error("Failed to clean the default location_history partition", err)
+		} else if n > 0 {
+			log.Info("Old points deleted from the default partition", "rows", n)
+		}
+	}
+
+	if hs.cfg.NonRideDays > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -hs.cfg.NonRideDays)
+		if n, err := hs.repositories.DeleteNonRidePoints(ctx, cutoff); err != nil {
+			log.Error("Failed to delete non ride points", err)
+		} else if n > 0 {
+			log.Info("Non ride points deleted", "rows", n)
+		}
+	}
+}
+
+// CompactRides stores the simplified polyline of every completed ride that has none yet
+func (hs *HistoryService) CompactRides(ctx context.Context) {
+	log := hs.log.Action("RideTrackCompaction")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	rides, err := hs.repositories.RidesWithoutTrack(ctx, time.Now().Add(-compactGrace), compactBatch)
+	if err != nil {
+		log.Error("Failed to get rides without track", err)
+		return
+	}
+	for _, ride_id := range rides {
+		if err := hs.compactRide(ctx, ride_id); err != nil {
+			log.Error("Failed to compact ride track", err, "ride_id", ride_id)
+		}
+	}
+}
+
+func (hs *HistoryService) compactRide(ctx context.Context, ride_id string) error {
+	points, err := hs.repositories.GetRidePoints(ctx, ride_id)
+	if err != nil {
+		return err
+	}
+
+	// rides without points still get an empty track so they are not picked again
+	track := model.RideTrack{RideId: ride_id, RawPointsCount: len(points)}
+	if len(points) > 0 {
+		path := make([]geo.Point, len(points))
+		for i, point := range points {
+			path[i] = geo.Point{Lat: point.Latitude, Lng: point.Longitude}
+			if i > 0 {
+				track.DistanceKm += geo.HaversineKm(path[i-1], path[i])
+			}
+		}
+		simplified := geo.Simplify(path, hs.cfg.SimplifyMeters)
+		track.Polyline = geo.EncodePolyline(simplified)
+		track.PointsCount = len(simplified)
+		track.StartedAt = &points[0].RecordedAt
+		track.EndedAt = &points[len(points)-1].RecordedAt
+	}
+	return hs.repositories.SaveTrack(ctx, track)
+}
+
+func seconds(sec int, def time.Duration) time.Duration {
+	if sec <= 0 {
+		return def
+	}
+	return time.Duration(sec) * time.Second
+}
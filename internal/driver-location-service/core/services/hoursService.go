@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	websocketdto "ride-hail/internal/driver-location-service/core/domain/websocket_dto"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+)
+
+var ErrOnBreak = errors.New("driver is on a mandatory break")
+
+// sessions older than this never belong to the current shift
+const hoursLookback = 48 * time.Hour
+
+// shift is the stretch of sessions of a driver not separated by a full break
+type shift struct {
+	driverId     string
+	jurisdiction string
+	start        time.Time
+	lastEnd      time.Time // end of the last session, now while online
+	online       time.Duration
+	driving      time.Duration
+}
+
+// HoursService caps continuous driving: online and on-trip time are summed over the sessions
+// since the last break long enough for the jurisdiction of the driver. Drivers get a warning
+// ahead of the limit, no offers once it is reached, and a forced break as soon as they are
+// not on a trip. GoOnline is refused until the break is over.
+//
+// Every replica runs the check to keep its own drivers out of matching, only the replica
+// holding the advisory lock sends warnings and starts breaks.
+type HoursService struct {
+	repositories driven.IHoursRepository
+	checkRepo    driven.IHoursRepository // dedicated connection of the check, holds the lock
+	rides        driven.IDriverRepository
+	cfg          *config.Hoursconfig
+	log          logger.Logger
+
+	mu      sync.RWMutex
+	limited map[string]time.Time // driver -> start of the shift that reached a limit
+	warned  map[string]time.Time // driver -> start of the shift the warning was sent for
+}
+
+func NewHoursService(repositories, checkRepo driven.IHoursRepository, rides driven.IDriverRepository, cfg *config.Hoursconfig, log logger.Logger) *HoursService {
+	return &HoursService{
+		repositories: repositories,
+		checkRepo:    checkRepo,
+		rides:        rides,
+		cfg:          cfg,
+		log:          log,
+		limited:      make(map[string]time.Time),
+		warned:       make(map[string]time.Time),
+	}
+}
+
+// Run checks the shifts of the online drivers until ctx is done, goOffline takes a driver
+// offline when a forced break starts
+func (hs *HoursService) Run(ctx context.Context, wsManager driven.WSConnectionMeneger, goOffline func(ctx context.Context, driver_id string) error) {
+	log := hs.log.Action("HoursService")
+	interval := time.Duration(max(hs.cfg.CheckIntervalSec, 1)) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := hs.check(ctx, wsManager, goOffline); err != nil {
+			log.Error("Failed to check driving hours", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (hs *HoursService) check(ctx context.Context, wsManager driven.WSConnectionMeneger, goOffline func(ctx context.Context, driver_id string) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	sessions, err := hs.checkRepo.GetOnlineSessions(ctx, time.Now().Add(-hoursLookback))
+	if err != nil {
+		return err
+	}
+	var shifts []*shift
+	for i := 0; i < len(sessions); {
+		j := i
+		for j < len(sessions) && sessions[j].DriverId == sessions[i].DriverId {
+			j++
+		}
+		s := shiftOf(sessions[i:j], hs.limits(sessions[i].Jurisdiction))
+		shifts = append(shifts, &s)
+		i = j
+	}
+	if err := hs.addDriving(ctx, hs.checkRepo, shifts); err != nil {
+		return err
+	}
+	leader, err := hs.checkRepo.TryLeadership(ctx)
+	if err != nil {
+		hs.log.Action("HoursService").Error("Failed to take the hours check lock", err)
+	}
+
+	hs.mu.Lock()
+	previous := hs.limited
+	hs.limited = make(map[string]time.Time)
+	online := make(map[string]struct{}, len(shifts))
+	var warn, reached []*shift
+	for _, s := range shifts {
+		online[s.driverId] = struct{}{}
+		left := hs.minutesLeft(*s)
+		if left <= 0 {
+			hs.limited[s.driverId] = s.start
+			reached = append(reached, s)
+		} else if leader && left <= float64(hs.cfg.WarnBeforeMinutes) && !hs.warned[s.driverId].Equal(s.start) {
+			hs.warned[s.driverId] = s.start
+			warn = append(warn, s)
+		}
+	}
+	for driver_id := range hs.warned {
+		if _, ok := online[driver_id]; !ok {
+			delete(hs.warned, driver_id)
+		}
+	}
+	hs.mu.Unlock()
+	if !leader {
+		return nil
+	}
+
+	for _, s := range warn {
+		left := hs.minutesLeft(*s)
+		hs.notify(ctx, wsManager, websocketdto.MessageTypeHoursWarning, *s, "",
+			fmt.Sprintf("You will reach the driving time limit in %.0f minutes", left))
+	}
+	for _, s := range reached {
+		first := !previous[s.driverId].Equal(s.start)
+		hs.enforce(ctx, wsManager, goOffline, *s, first)
+	}
+	return nil
+}
+
+// enforce starts the break of a driver past a limit once the driver is not on a trip,
+// until then the driver only stops getting offers. The trip is looked up in Postgres,
+// going offline would cancel a ride the index of this replica does not know about yet.
+func (hs *HoursService) enforce(ctx context.Context, wsManager driven.WSConnectionMeneger, goOffline func(ctx context.Context, driver_id string) error, s shift, first bool) {
+	log := hs.log.Action("HoursService")
+	if _, err := hs.rides.GetActiveRide(ctx, s.driverId); err == nil {
+		if first {
+			hs.notify(ctx, wsManager, websocketdto.MessageTypeHoursLimit, s, "",
+				"Driving time limit reached, no new rides will be offered. Your break starts after the current ride")
+		}
+		return
+	} else if !errors.Is(err, db.ErrNoActiveRide) {
+		log.Error("Failed to check the active ride before a forced break", err, "driver_id", s.driverId)
+		return
+	}
+
+	brk, err := hs.startBreak(ctx, hs.checkRepo, s, time.Now())
+	if err != nil {
+		log.Error("Failed to start forced break", err, "driver_id", s.driverId)
+		return
+	}
+	hs.notify(ctx, wsManager, websocketdto.MessageTypeHoursLimit, s, brk.EndsAt.UTC().Format(time.RFC3339),
+		fmt.Sprintf("Driving time limit reached, you can go online again at %s", brk.EndsAt.UTC().Format(time.RFC3339)))
+	if err := goOffline(ctx, s.driverId); err != nil {
+		log.Warn("Failed to take driver offline for a break", "driver_id", s.driverId, "err", err)
+		return
+	}
+	log.Info("Driver sent on a break", "driver_id", s.driverId, "ends_at", brk.EndsAt)
+}
+
+func (hs *HoursService) notify(ctx context.Context, wsManager driven.WSConnectionMeneger, messageType string, s shift, breakEndsAt, text string) {
+	if !wsManager.IsDriverConnected(s.driverId) {
+		return
+	}
+	hours := hs.toDTO(s)
+	hours.LimitReached = messageType == websocketdto.MessageTypeHoursLimit
+	hours.BreakEndsAt = breakEndsAt
+	message := websocketdto.HoursMessage{
+		WebSocketMessage: websocketdto.WebSocketMessage{Type: messageType},
+		DriverHours:      hours,
+		Message:          text,
+	}
+	if err := wsManager.SendToDriver(ctx, s.driverId, message); err != nil {
+		hs.log.Action("HoursService").Warn("Failed to send hours message", "driver_id", s.driverId, "type", messageType, "err", err)
+	}
+}
+
+// CheckGoOnline refuses drivers on a break. A driver back before a full break after reaching
+// a limit is sent on one, the time already offline counts.
+func (hs *HoursService) CheckGoOnline(ctx context.Context, driver_id string) error {
+	brk, err := hs.repositories.GetActiveBreak(ctx, driver_id)
+	if err == nil {
+		return fmt.Errorf("%w until %s", ErrOnBreak, brk.EndsAt.UTC().Format(time.RFC3339))
+	} else if !errors.Is(err, db.ErrNoActiveBreak) {
+		return err
+	}
+
+	s, ok, err := hs.current(ctx, driver_id)
+	if err != nil || !ok || hs.minutesLeft(s) > 0 {
+		return err
+	}
+	brk, err = hs.startBreak(ctx, hs.repositories, s, s.lastEnd)
+	if err != nil {
+		return err
+	}
+	if brk.EndsAt.After(time.Now()) {
+		return fmt.Errorf("%w until %s", ErrOnBreak, brk.EndsAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetHours returns the online and driving time of the current shift against the limits
+func (hs *HoursService) GetHours(ctx context.Context, driver_id string) (dto.DriverHours, error) {
+	s, ok, err := hs.current(ctx, driver_id)
+	if err != nil {
+		return dto.DriverHours{}, err
+	}
+	if !ok {
+		jurisdiction, err := hs.repositories.GetJurisdiction(ctx, driver_id)
+		if err != nil {
+			return dto.DriverHours{}, err
+		}
+		s = shift{driverId: driver_id, jurisdiction: jurisdiction}
+	}
+
+	hours := hs.toDTO(s)
+	hours.LimitReached = ok && hours.MinutesLeft <= 0
+	brk, err := hs.repositories.GetActiveBreak(ctx, driver_id)
+	if err == nil {
+		hours.LimitReached = true
+		hours.BreakEndsAt = brk.EndsAt.UTC().Format(time.RFC3339)
+	} else if !errors.Is(err, db.ErrNoActiveBreak) {
+		return dto.DriverHours{}, err
+	}
+	return hours, nil
+}
+
+// Filter drops the drivers past a limit from matching
+func (hs *HoursService) Filter(drivers []model.DriverInfo) []model.DriverInfo {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	if len(hs.limited) == 0 {
+		return drivers
+	}
+	filtered := drivers[:0:0]
+	for _, driver := range drivers {
+		if _, ok := hs.limited[driver.DriverId]; !ok {
+			filtered = append(filtered, driver)
+		}
+	}
+	return filtered
+}
+
+// current returns the shift of the driver, false when the driver has rested since
+func (hs *HoursService) current(ctx context.Context, driver_id string) (shift, bool, error) {
+	sessions, err := hs.repositories.GetDriverSessions(ctx, driver_id, time.Now().Add(-hoursLookback))
+	if err != nil || len(sessions) == 0 {
+		return shift{}, false, err
+	}
+	limits := hs.limits(sessions[0].Jurisdiction)
+	s := shiftOf(sessions, limits)
+	if time.Since(s.lastEnd) >= time.Duration(limits.BreakMinutes)*time.Minute {
+		return shift{}, false, nil
+	}
+	shifts := []*shift{&s}
+	if err := hs.addDriving(ctx, hs.repositories, shifts); err != nil {
+		return shift{}, false, err
+	}
+	return s, true, nil
+}
+
+func (hs *HoursService) startBreak(ctx context.Context, repo driven.IHoursRepository, s shift, from time.Time) (model.DriverBreak, error) {
+	limits := hs.limits(s.jurisdiction)
+	return repo.StartBreak(ctx, model.DriverBreak{
+		DriverId:     s.driverId,
+		Reason:       "hours_limit",
+		OnlineHours:  round2(s.online.Hours()),
+		DrivingHours: round2(s.driving.Hours()),
+		StartedAt:    from,
+		EndsAt:       from.Add(time.Duration(limits.BreakMinutes) * time.Minute),
+	})
+}
+
+// addDriving runs on the repository of the caller, the check and the requests have
+// connections of their own
+func (hs *HoursService) addDriving(ctx context.Context, repo driven.IHoursRepository, shifts []*shift) error {
+	starts := make(map[string]time.Time, len(shifts))
+	for _, s := range shifts {
+		starts[s.driverId] = s.start
+	}
+	driving, err := repo.GetDrivingSeconds(ctx, starts)
+	if err != nil {
+		return err
+	}
+	for _, s := range shifts {
+		s.driving = time.Duration(driving[s.driverId] * float64(time.Second))
+	}
+	return nil
+}
+
+func (hs *HoursService) limits(jurisdiction string) config.HoursLimits {
+	if limits, ok := hs.cfg.Limits[jurisdiction]; ok {
+		return limits
+	}
+	return hs.cfg.Limits[model.DefaultJurisdiction]
+}
+
+func (hs *HoursService) minutesLeft(s shift) float64 {
+	limits := hs.limits(s.jurisdiction)
+	return min(limits.MaxOnlineHours*60-s.online.Minutes(), limits.MaxDrivingHours*60-s.driving.Minutes())
+}
+
+func (hs *HoursService) toDTO(s shift) dto.DriverHours {
+	limits := hs.limits(s.jurisdiction)
+	hours := dto.DriverHours{
+		DriverId:        s.driverId,
+		Jurisdiction:    s.jurisdiction,
+		OnlineHours:     round2(s.online.Hours()),
+		DrivingHours:    round2(s.driving.Hours()),
+		MaxOnlineHours:  limits.MaxOnlineHours,
+		MaxDrivingHours: limits.MaxDrivingHours,
+		BreakMinutes:    limits.BreakMinutes,
+		MinutesLeft:     math.Max(math.Round(hs.minutesLeft(s)), 0),
+	}
+	if !s.start.IsZero() {
+		hours.ShiftStartedAt = s.start.UTC().Format(time.RFC3339)
+	}
+	return hours
+}
+
+// shiftOf walks back from the last session of the driver while the gaps between sessions
+// are shorter than the break, and sums the online time from there
+func shiftOf(sessions []model.ShiftSession, limits config.HoursLimits) shift {
+	rest := time.Duration(limits.BreakMinutes) * time.Minute
+	last := sessions[len(sessions)-1]
+	s := shift{
+		driverId:     last.DriverId,
+		jurisdiction: last.Jurisdiction,
+		start:        last.StartedAt,
+		lastEnd:      last.EndedAt,
+	}
+	for i := len(sessions) - 2; i >= 0; i-- {
+		if s.start.Sub(sessions[i].EndedAt) >= rest {
+			break
+		}
+		if sessions[i].StartedAt.Before(s.start) {
+			s.start = sessions[i].StartedAt
+		}
+	}
+
+	// sessions left open by a crash overlap the next one, time is counted once
+	covered := s.start
+	for _, session := range sessions {
+		from := session.StartedAt
+		if from.Before(covered) {
+			from = covered
+		}
+		if session.EndedAt.After(from) {
+			s.online += session.EndedAt.Sub(from)
+			covered = session.EndedAt
+		}
+		if session.EndedAt.After(s.lastEnd) {
+			s.lastEnd = session.EndedAt
+		}
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/geo"
+)
+
+const (
+	RejectThrottled  = "throttled"
+	RejectOutOfOrder = "out_of_order"
+	RejectInaccurate = "inaccurate"
+	RejectJump       = "impossible_jump"
+	RejectInvalid    = "invalid_coordinates"
+)
+
+var (
+	ErrLocationThrottled = errors.New("location update too frequent")
+	ErrLocationRejected  = errors.New("location update rejected")
+)
+
+// LocationPoint is a driver location going through the pipeline
+type LocationPoint struct {
+	DriverId       string
+	Latitude       float64
+	Longitude      float64
+	AccuracyMeters float64
+	SpeedKmh       float64
+	HeadingDegrees float64
+	At             time.Time
+}
+
+// driverTrack is the pipeline state of one driver
+type driverTrack struct {
+	lastAt    time.Time // last accepted point
+	lastRawAt time.Time // last point received, accepted or not
+	lat, lng  float64   // filtered position
+	variance  float64   // of the filtered position, meters^2
+	jumps     int       // consecutive rejected jumps
+}
+
+// locationStage inspects and may change a point, a non empty reason rejects it
+type locationStage func(track *driverTrack, p *LocationPoint) string
+
+// LocationPipeline throttles, validates and smooths driver locations before they are stored
+type LocationPipeline struct {
+	cfg    *config.Locationconfig
+	stages []locationStage
+
+	mu     sync.Mutex
+	tracks map[string]*driverTrack
+	stats  map[string]*dto.LocationStats
+}
+
+func NewLocationPipeline(cfg *config.Locationconfig) *LocationPipeline {
+	lp := &LocationPipeline{
+		cfg:    cfg,
+		tracks: make(map[string]*driverTrack),
+		stats:  make(map[string]*dto.LocationStats),
+	}
+	lp.stages = []locationStage{lp.validate, lp.throttle, lp.rejectJumps, lp.smooth}
+	return lp
+}
+
+// Process runs the point through every stage. It returns the smoothed point,
+// or ErrLocationThrottled / ErrLocationRejected with the reason.
+func (lp *LocationPipeline) Process(p LocationPoint) (LocationPoint, error) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	track, ok := lp.tracks[p.DriverId]
+	if !ok {
+		track = &driverTrack{}
+		lp.tracks[p.DriverId] = track
+	}
+	stats := lp.driverStats(p.DriverId)
+	stats.Received++
+
+	for _, stage := range lp.stages {
+		if reason := stage(track, &p); reason != "" {
+			stats.Rejected++
+			stats.Reasons[reason]++
+			if reason == RejectThrottled {
+				return LocationPoint{}, fmt.Errorf("%w: %s", ErrLocationThrottled, reason)
+			}
+			return LocationPoint{}, fmt.Errorf("%w: %s", ErrLocationRejected, reason)
+		}
+	}
+
+	track.lastAt = p.At
+	stats.Accepted++
+	return p, nil
+}
+
+// Reset drops the track of a driver, the next point starts a new one
+func (lp *LocationPipeline) Reset(driver_id string) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	delete(lp.tracks, driver_id)
+}
+
+func (lp *LocationPipeline) Stats(driver_id string) dto.LocationStats {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+
+	stats := *lp.driverStats(driver_id)
+	reasons := make(map[string]int64, len(stats.Reasons))
+	for reason, n := range stats.Reasons {
+		reasons[reason] = n
+	}
+	stats.Reasons = reasons
+	return stats
+}
+
+func (lp *LocationPipeline) driverStats(driver_id string) *dto.LocationStats {
+	stats, ok := lp.stats[driver_id]
+	if !ok {
+		stats = &dto.LocationStats{DriverId: driver_id, Reasons: make(map[string]int64)}
+		lp.stats[driver_id] = stats
+	}
+	return stats
+}
+
+func (lp *LocationPipeline) validate(track *driverTrack, p *LocationPoint) string {
+	if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+		return RejectInvalid
+	}
+	if lp.cfg.MaxAccuracyMeters > 0 && p.AccuracyMeters > lp.cfg.MaxAccuracyMeters {
+		return RejectInaccurate
+	}
+	return ""
+}
+
+// throttle drops points that arrive sooner than the minimal interval after the last accepted one
+func (lp *LocationPipeline) throttle(track *driverTrack, p *LocationPoint) string {
+	if track.lastAt.IsZero() {
+		return ""
+	}
+	if !p.At.After(track.lastRawAt) {
+		return RejectOutOfOrder
+	}
+	track.lastRawAt = p.At
+	if p.At.Sub(track.lastAt).Seconds() < lp.cfg.MinIntervalSeconds {
+		return RejectThrottled
+	}
+	return ""
+}
+
+// rejectJumps drops points that would need a speed above the limit to reach from the
+// last position, the accuracy of the point is taken in favour of the driver. After too
+// many jumps in a row the driver has really moved (tunnel, GPS gap) and the track restarts.
+func (lp *LocationPipeline) rejectJumps(track *driverTrack, p *LocationPoint) string {
+	if track.lastAt.IsZero() || lp.cfg.MaxSpeedKmh <= 0 {
+		return ""
+	}
+	hours := p.At.Sub(track.lastAt).Hours()
+	km := geo.HaversineKm(geo.Point{Lat: track.lat, Lng: track.lng}, geo.Point{Lat: p.Latitude, Lng: p.Longitude})
+	km = math.Max(0, km-p.AccuracyMeters/1000)
+	if km/hours <= lp.cfg.MaxSpeedKmh {
+		track.jumps = 0
+		return ""
+	}
+
+	track.jumps++
+	if lp.cfg.OutlierResetAfter > 0 && track.jumps >= lp.cfg.OutlierResetAfter {
+		*track = driverTrack{}
+		return ""
+	}
+	return RejectJump
+}
+
+// smooth is a constant position Kalman filter, the measurement noise is the
+// reported accuracy so precise points move the estimate more
+func (lp *LocationPipeline) smooth(track *driverTrack, p *LocationPoint) string {
+	accuracy := math.Max(p.AccuracyMeters, 1)
+	if track.lastAt.IsZero() {
+		track.lat, track.lng = p.Latitude, p.Longitude
+		track.variance = accuracy * accuracy
+		track.lastRawAt = p.At
+		return ""
+	}
+
+	// the position gets less certain as time passes
+	seconds := p.At.Sub(track.lastAt).Seconds()
+	track.variance += seconds * lp.cfg.ProcessNoiseMps * lp.cfg.ProcessNoiseMps
+
+	gain := track.variance / (track.variance + accuracy*accuracy)
+	track.lat += gain * (p.Latitude - track.lat)
+	track.lng += gain * (p.Longitude - track.lng)
+	track.variance *= 1 - gain
+
+	p.Latitude, p.Longitude = track.lat, track.lng
+	p.AccuracyMeters = math.Sqrt(track.variance)
+	return ""
+}
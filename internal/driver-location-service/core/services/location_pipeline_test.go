@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"ride-hail/internal/config"
+)
+
+var pipelineStart = time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC)
+
+func testPipeline() *LocationPipeline {
+	return NewLocationPipeline(&config.Locationconfig{
+		MinIntervalSeconds: 3,
+		MaxSpeedKmh:        200,
+		MaxAccuracyMeters:  100,
+		ProcessNoiseMps:    3,
+		OutlierResetAfter:  3,
+	})
+}
+
+// step is one point of a driver, seconds after pipelineStart
+type step struct {
+	seconds  float64
+	lat, lng float64
+	accuracy float64
+	reason   string // expected rejection, empty when accepted
+}
+
+func (s step) point() LocationPoint {
+	return LocationPoint{
+		DriverId:       "driver",
+		Latitude:       s.lat,
+		Longitude:      s.lng,
+		AccuracyMeters: s.accuracy,
+		At:             pipelineStart.Add(time.Duration(s.seconds * float64(time.Second))),
+	}
+}
+
+func runSteps(t *testing.T, lp *LocationPipeline, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		_, err := lp.Process(s.point())
+		switch {
+		case s.reason == "" && err != nil:
+			t.Fatalf("step %d: Process: %v, want accepted", i, err)
+		case s.reason == "":
+		case err == nil:
+			t.Fatalf("step %d: accepted, want %s", i, s.reason)
+		case !strings.HasSuffix(err.Error(), s.reason):
+			t.Fatalf("step %d: Process: %v, want %s", i, err, s.reason)
+		case s.reason == RejectThrottled && !errors.Is(err, ErrLocationThrottled):
+			t.Fatalf("step %d: Process: %v, want %v", i, err, ErrLocationThrottled)
+		case s.reason != RejectThrottled && !errors.Is(err, ErrLocationRejected):
+			t.Fatalf("step %d: Process: %v, want %v", i, err, ErrLocationRejected)
+		}
+	}
+}
+
+func TestLocationPipelineThrottle(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name:  "first point",
+			steps: []step{{seconds: 0, accuracy: 5}},
+		},
+		{
+			name: "too soon after the last accepted",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 1, accuracy: 5, reason: RejectThrottled},
+				{seconds: 2.9, accuracy: 5, reason: RejectThrottled},
+				{seconds: 3, accuracy: 5},
+			},
+		},
+		{
+			name: "interval counts from the last accepted point",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 2, accuracy: 5, reason: RejectThrottled},
+				{seconds: 4, accuracy: 5},
+				{seconds: 6, accuracy: 5, reason: RejectThrottled},
+			},
+		},
+		{
+			name: "out of order",
+			steps: []step{
+				{seconds: 10, accuracy: 5},
+				{seconds: 10, accuracy: 5, reason: RejectOutOfOrder},
+				{seconds: 5, accuracy: 5, reason: RejectOutOfOrder},
+				{seconds: 11, accuracy: 5, reason: RejectThrottled},
+				{seconds: 11, accuracy: 5, reason: RejectOutOfOrder},
+				{seconds: 13, accuracy: 5},
+			},
+		},
+		{
+			name: "invalid and inaccurate points",
+			steps: []step{
+				{seconds: 0, lat: 91, accuracy: 5, reason: RejectInvalid},
+				{seconds: 0, lng: -180.5, accuracy: 5, reason: RejectInvalid},
+				{seconds: 0, accuracy: 150, reason: RejectInaccurate},
+				{seconds: 0, accuracy: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, testPipeline(), tt.steps)
+		})
+	}
+}
+
+func TestLocationPipelineJumps(t *testing.T) {
+	// 0.001 degree of latitude is about 111 m
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "reachable move",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 10, lat: 0.002, accuracy: 5},
+			},
+		},
+		{
+			name: "impossible jump",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 10, lat: 0.1, accuracy: 5, reason: RejectJump},
+			},
+		},
+		{
+			name: "accuracy taken in favour of the driver",
+			steps: []step{
+				{seconds: 0, accuracy: 1},
+				// 250 m in 3 s is 300 km/h, 160 m without the accuracy is 192 km/h
+				{seconds: 3, lat: 0.00225, accuracy: 90},
+			},
+		},
+		{
+			name: "accuracy not enough",
+			steps: []step{
+				{seconds: 0, accuracy: 1},
+				{seconds: 3, lat: 0.00225, accuracy: 10, reason: RejectJump},
+			},
+		},
+		{
+			name: "track restarts after consecutive jumps",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 10, lat: 0.1, accuracy: 5, reason: RejectJump},
+				{seconds: 20, lat: 0.1, accuracy: 5, reason: RejectJump},
+				{seconds: 30, lat: 0.1, accuracy: 5},
+				{seconds: 40, lat: 0.1005, accuracy: 5},
+			},
+		},
+		{
+			name: "a good point clears the jump count",
+			steps: []step{
+				{seconds: 0, accuracy: 5},
+				{seconds: 10, lat: 0.1, accuracy: 5, reason: RejectJump},
+				{seconds: 20, lat: 0.1, accuracy: 5, reason: RejectJump},
+				{seconds: 30, lat: 0.0001, accuracy: 5},
+				{seconds: 40, lat: 0.1, accuracy: 5, reason: RejectJump},
+				{seconds: 50, lat: 0.1, accuracy: 5, reason: RejectJump},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runSteps(t, testPipeline(), tt.steps)
+		})
+	}
+}
+
+func TestLocationPipelineSmooth(t *testing.T) {
+	t.Run("first point is kept", func(t *testing.T) {
+		lp := testPipeline()
+		got, err := lp.Process(step{lat: 43.2389, lng: 76.8897, accuracy: 0.2}.point())
+		if err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+		if got.Latitude != 43.2389 || got.Longitude != 76.8897 {
+			t.Errorf("first point = %v,%v, want 43.2389,76.8897", got.Latitude, got.Longitude)
+		}
+	})
+
+	t.Run("kalman update", func(t *testing.T) {
+		lp := testPipeline()
+		if _, err := lp.Process(step{accuracy: 10}.point()); err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+		got, err := lp.Process(step{seconds: 10, lat: 0.001, lng: -0.001, accuracy: 10}.point())
+		if err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+
+		// variance 10^2 grows by 10 s * 3^2, the measurement has variance 10^2
+		variance := 100.0 + 10*9
+		gain := variance / (variance + 100)
+		if want := gain * 0.001; math.Abs(got.Latitude-want) > 1e-12 {
+			t.Errorf("latitude = %v, want %v", got.Latitude, want)
+		}
+		if want := gain * -0.001; math.Abs(got.Longitude-want) > 1e-12 {
+			t.Errorf("longitude = %v, want %v", got.Longitude, want)
+		}
+		if want := math.Sqrt(variance * (1 - gain)); math.Abs(got.AccuracyMeters-want) > 1e-9 {
+			t.Errorf("accuracy = %v, want %v", got.AccuracyMeters, want)
+		}
+	})
+
+	t.Run("precise points move the estimate more", func(t *testing.T) {
+		moved := func(accuracy float64) float64 {
+			lp := testPipeline()
+			if _, err := lp.Process(step{accuracy: 10}.point()); err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			got, err := lp.Process(step{seconds: 5, lat: 0.0005, accuracy: accuracy}.point())
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			return got.Latitude
+		}
+		precise, rough := moved(2), moved(50)
+		if !(0 < rough && rough < precise && precise < 0.0005) {
+			t.Errorf("moved %v with accuracy 2 and %v with accuracy 50, want 0 < rough < precise < 0.0005", precise, rough)
+		}
+	})
+}
+
+func TestLocationPipelineStats(t *testing.T) {
+	lp := testPipeline()
+	runSteps(t, lp, []step{
+		{seconds: 0, accuracy: 5},
+		{seconds: 1, accuracy: 5, reason: RejectThrottled},
+		{seconds: 2, accuracy: 5, reason: RejectThrottled},
+		{seconds: 10, lat: 0.1, accuracy: 5, reason: RejectJump},
+		{seconds: 20, accuracy: 500, reason: RejectInaccurate},
+		{seconds: 30, accuracy: 5},
+	})
+
+	stats := lp.Stats("driver")
+	if stats.Received != 6 || stats.Accepted != 2 || stats.Rejected != 4 {
+		t.Errorf("stats = %+v, want 6 received, 2 accepted, 4 rejected", stats)
+	}
+	want := map[string]int64{RejectThrottled: 2, RejectJump: 1, RejectInaccurate: 1}
+	for reason, n := range want {
+		if stats.Reasons[reason] != n {
+			t.Errorf("rejected by %s = %d, want %d", reason, stats.Reasons[reason], n)
+		}
+	}
+
+	// a copy, the caller cannot change the counters
+	stats.Reasons[RejectThrottled] = 100
+	if lp.Stats("driver").Reasons[RejectThrottled] != 2 {
+		t.Error("Stats returned the live reasons map")
+	}
+
+	// after a reset the next point starts a new track instead of being a jump
+	lp.Reset("driver")
+	runSteps(t, lp, []step{{seconds: 31, lat: 1, accuracy: 5}})
+}
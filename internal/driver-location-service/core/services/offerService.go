@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+)
+
+type OfferService struct {
+	repositories driven.IOfferRepository
+	log          logger.Logger
+}
+
+func NewOfferService(repositories driven.IOfferRepository, log logger.Logger) *OfferService {
+	return &OfferService{repositories: repositories, log: log}
+}
+
+func (ofs *OfferService) OfferSent(ctx context.Context, offer_id, ride_id, driver_id, strategy, instance_id string, expiresAt time.Time) error {
+	return ofs.repositories.CreateOffer(ctx, model.RideOffer{
+		OfferId:    offer_id,
+		RideId:     ride_id,
+		DriverId:   driver_id,
+		Status:     model.OfferStatusSent,
+		Strategy:   strategy,
+		InstanceId: instance_id,
+		SentAt:     time.Now(),
+		ExpiresAt:  expiresAt,
+	})
+}
+
+func (ofs *OfferService) CloseOffer(ctx context.Context, offer_id string, status string, reason string) error {
+	return ofs.repositories.CloseOffer(ctx, offer_id, status, reason)
+}
+
+func (ofs *OfferService) GetOffer(ctx context.Context, offer_id string) (model.RideOffer, error) {
+	return ofs.repositories.GetOffer(ctx, offer_id)
+}
+
+func (ofs *OfferService) GetOfferStats(ctx context.Context, driver_id string, days int) (dto.OfferStats, error) {
+	stats, err := ofs.repositories.GetOfferStats(ctx, driver_id, days)
+	if err != nil {
+		return dto.OfferStats{}, err
+	}
+
+	response := dto.OfferStats{
+		DriverId:       driver_id,
+		WindowDays:     days,
+		Sent:           stats.Sent,
+		Accepted:       stats.Accepted,
+		Declined:       stats.Declined,
+		Expired:        stats.Expired,
+		Withdrawn:      stats.Withdrawn,
+		DeclineReasons: stats.DeclineReasons,
+	}
+	// withdrawn offers were never the driver's choice
+	if answerable := stats.Sent - stats.Withdrawn; answerable > 0 {
+		response.AcceptanceRate = float64(stats.Accepted) / float64(answerable)
+		response.DeclineRate = float64(stats.Declined) / float64(answerable)
+	}
+	return response, nil
+}
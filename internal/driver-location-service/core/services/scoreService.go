@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/scoring"
+)
+
+type ScoreService struct {
+	repositories driven.IScoreRepository
+	refreshRepo  driven.IScoreRepository // dedicated connection, the refresh runs for a while
+	cfg          *config.Scoringconfig
+	log          logger.Logger
+}
+
+func NewScoreService(repositories, refreshRepo driven.IScoreRepository, cfg *config.Scoringconfig, log logger.Logger) *ScoreService {
+	return &ScoreService{repositories: repositories, refreshRepo: refreshRepo, cfg: cfg, log: log}
+}
+
+// Run refreshes driver scores until ctx is done
+func (ss *ScoreService) Run(ctx context.Context) {
+	log := ss.log.Action("ScoreRefresh")
+
+	interval := time.Duration(ss.cfg.RefreshIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		refreshCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		n, err := ss.refreshRepo.RefreshScores(refreshCtx, ss.cfg.WindowDays, ss.cfg.ShortWindowDays, ss.cfg.ShortWindowWeight)
+		cancel()
+		if err != nil {
+			log.Error("Failed to refresh driver scores", err)
+		} else {
+			log.Info("Driver scores refreshed", "drivers", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ss *ScoreService) weights(vehicleType string) config.ScoreWeights {
+	if w, ok := ss.cfg.Weights[vehicleType]; ok {
+		return w
+	}
+	return config.ScoreWeights{Distance: 1}
+}
+
+// Rank orders drivers by proximity and quality with the weights of the vehicle type.
+// Drivers without a score yet get the defaults, when scores cannot be loaded the order is kept.
+func (ss *ScoreService) Rank(ctx context.Context, vehicleType string, drivers []dto.DriverInfo) []dto.DriverInfo {
+	if len(drivers) == 0 {
+		return drivers
+	}
+	ids := make([]string, len(drivers))
+	for i, driver := range drivers {
+		ids[i] = driver.DriverId
+	}
+	scores, err := ss.repositories.GetScores(ctx, ids)
+	if err != nil {
+		ss.log.Action("Rank").Error("Failed to load driver scores", err)
+		return drivers
+	}
+
+	w := ss.weights(vehicleType)
+	for i, driver := range drivers {
+		components := scoring.Defaults
+		components.Rating = driver.Rating
+		if score, ok := scores[driver.DriverId]; ok {
+			components = toComponents(score)
+		}
+		drivers[i].Score = scoring.Quality(w, components)
+		drivers[i].Rank = scoring.Rank(w, components, driver.Distance)
+	}
+	sort.SliceStable(drivers, func(i, j int) bool {
+		return drivers[i].Rank > drivers[j].Rank
+	})
+	return drivers
+}
+
+func (ss *ScoreService) GetDriverScore(ctx context.Context, driver_id string) (dto.DriverScore, error) {
+	score, err := ss.repositories.GetScore(ctx, driver_id)
+	if err != nil {
+		return dto.DriverScore{}, err
+	}
+
+	w := ss.weights(score.VehicleType)
+	components := toComponents(score)
+	response := dto.DriverScore{
+		DriverId:    score.DriverId,
+		VehicleType: score.VehicleType,
+		Score:       scoring.Quality(w, components),
+		Components:  components,
+		Weights:     w,
+		OffersCount: score.OffersCount,
+		RidesCount:  score.RidesCount,
+		WindowDays:  score.WindowDays,
+		HasHistory:  score.OffersCount+score.RidesCount > 0,
+	}
+	if score.ComputedAt != nil {
+		response.ComputedAt = score.ComputedAt.Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+func toComponents(score model.DriverScore) scoring.Components {
+	return scoring.Components{
+		AcceptanceRate:   score.AcceptanceRate,
+		CancellationRate: score.CancellationRate,
+		CompletionRate:   score.CompletionRate,
+		Rating:           score.Rating,
+	}
+}
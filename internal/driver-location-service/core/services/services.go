@@ -1,20 +1,82 @@
 package services
 
 import (
+	"ride-hail/internal/config"
 	"ride-hail/internal/driver-location-service/adapters/service/db"
 	ports "ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/filestore"
 	"ride-hail/internal/logger"
+	"ride-hail/internal/routing"
 )
 
 type Service struct {
-	DriverService *DriverService
-	AuthService   *AuthService
+	DriverService      *DriverService
+	AuthService        *AuthService
+	OfferService       *OfferService
+	ScoreService       *ScoreService
+	DriverIndex        *DriverIndex
+	HistoryService     *HistoryService
+	EarningsService    *EarningsService
+	DemandService      *DemandService
+	DestinationService *DestinationService
+	HoursService       *HoursService
+	DocumentService    *DocumentService
+	VehicleService     *VehicleService
+}
+
+// Deps are the connections, adapters and configuration New builds the services from
+type Deps struct {
+	Repositories *db.Repository
+	Jobs         *db.Jobs // a connection per background loop
+	Log          logger.Logger
+	Broker       ports.IDriverBroker
+	Router       routing.Router
+	Writer       ports.ILocationWriter
+	Store        filestore.Store
+	Config       *config.Config
 }
 
 // Must properly implement Auth Service
-func New(repositories *db.Repository, log logger.Logger, broker ports.IDriverBroker, secretKey string) *Service {
+func New(deps Deps) *Service {
+	repositories, jobs, log, cfg := deps.Repositories, deps.Jobs, deps.Log, deps.Config
+	scoreService := NewScoreService(repositories.ScoreRepository, jobs.ScoreRepository, cfg.Scoring, log)
+	rules := NewEligibilityRules(cfg.Vehicles)
+	driverIndex := NewDriverIndex(jobs.IndexRepository, rules, cfg.Index, log)
+	events := NewDriverEvents(deps.Broker, log)
+	earningsService := NewEarningsService(repositories.EarningsRepository, cfg.Earnings, log)
+	destinationService := NewDestinationService(repositories.DestinationRepository, cfg.Destination, log)
+	hoursService := NewHoursService(repositories.HoursRepository, jobs.HoursRepository, jobs.HoursRides, cfg.Hours, log)
+	documentService := NewDocumentService(repositories.DocumentRepository, deps.Store, cfg.Documents, log)
+	vehicleService := NewVehicleService(repositories.VehicleRepository, rules, log)
+	driverService := NewDriverService(DriverServiceDeps{
+		Repositories: repositories.DriverRepository,
+		Log:          log,
+		Broker:       deps.Broker,
+		Router:       deps.Router,
+		Scores:       scoreService,
+		Index:        driverIndex,
+		Pipeline:     NewLocationPipeline(cfg.Location),
+		Writer:       deps.Writer,
+		Arrival:      NewArrivalDetector(repositories.DriverRepository, driverIndex, events, cfg.Arrival, log),
+		Events:       events,
+		Earnings:     earningsService,
+		Destinations: destinationService,
+		Hours:        hoursService,
+		Documents:    documentService,
+		Vehicles:     vehicleService,
+	})
 	return &Service{
-		DriverService: NewDriverService(repositories.DriverRepository, log, broker),
-		AuthService:   NewAuthService(secretKey),
+		DriverService:      driverService,
+		ScoreService:       scoreService,
+		DriverIndex:        driverIndex,
+		HistoryService:     NewHistoryService(jobs.HistoryRepository, cfg.Retention, log),
+		EarningsService:    earningsService,
+		DemandService:      NewDemandService(jobs.DemandRepository, driverIndex, cfg.Demand, log),
+		DestinationService: destinationService,
+		HoursService:       hoursService,
+		DocumentService:    documentService,
+		VehicleService:     vehicleService,
+		AuthService:        NewAuthService(cfg.App.PublicJwtSecret),
+		OfferService:       NewOfferService(repositories.OfferRepository, log),
 	}
 }
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/driver-location-service/adapters/service/db"
+	"ride-hail/internal/driver-location-service/core/domain/dto"
+	"ride-hail/internal/driver-location-service/core/domain/model"
+	"ride-hail/internal/driver-location-service/core/ports/driven"
+	"ride-hail/internal/logger"
+)
+
+var ErrInvalidVehicle = errors.New("invalid vehicle")
+
+// EligibilityRules decide which ride types a vehicle is matched for: its own type, and the
+// types it opted in to as long as vehicles of its type may opt in to them
+type EligibilityRules struct {
+	cfg *config.Vehiclesconfig
+}
+
+func NewEligibilityRules(cfg *config.Vehiclesconfig) *EligibilityRules {
+	return &EligibilityRules{cfg: cfg}
+}
+
+func (er *EligibilityRules) Serves(vehicleType string, optInTypes []string, rideType string) bool {
+	if vehicleType == rideType {
+		return true
+	}
+	return slices.Contains(optInTypes, rideType) && slices.Contains(er.cfg.OptIn[vehicleType], rideType)
+}
+
+// OptInFrom returns the vehicle types whose vehicles may opt in to the ride type
+func (er *EligibilityRules) OptInFrom(rideType string) []string {
+	from := []string{}
+	for vehicleType, rideTypes := range er.cfg.OptIn {
+		if slices.Contains(rideTypes, rideType) {
+			from = append(from, vehicleType)
+		}
+	}
+	return from
+}
+
+func (er *EligibilityRules) RideTypes(vehicleType string, optInTypes []string) []string {
+	types := []string{vehicleType}
+	for _, rideType := range optInTypes {
+		if rideType != vehicleType && er.Serves(vehicleType, optInTypes, rideType) {
+			types = append(types, rideType)
+		}
+	}
+	return types
+}
+
+type VehicleService struct {
+	repositories driven.IVehicleRepository
+	rules        *EligibilityRules
+	log          logger.Logger
+}
+
+func NewVehicleService(repositories driven.IVehicleRepository, rules *EligibilityRules, log logger.Logger) *VehicleService {
+	return &VehicleService{repositories: repositories, rules: rules, log: log}
+}
+
+func (vs *VehicleService) CreateVehicle(ctx context.Context, driver_id string, req dto.VehicleRequest) (dto.Vehicle, error) {
+	vehicle := model.Vehicle{DriverId: driver_id, Seats: 4}
+	if req.VehicleType == nil || req.Make == nil || req.Model == nil || req.Plate == nil {
+		return dto.Vehicle{}, fmt.Errorf("%w: vehicle_type, make, model and plate are required", ErrInvalidVehicle)
+	}
+	if err := vs.apply(&vehicle, req); err != nil {
+		return dto.Vehicle{}, err
+	}
+
+	created, err := vs.repositories.Create(ctx, vehicle)
+	if err != nil {
+		return dto.Vehicle{}, err
+	}
+	vs.log.Action("CreateVehicle").Info("Vehicle registered", "driver_id", driver_id, "vehicle_id", created.VehicleId)
+	return vs.toDTO(created), nil
+}
+
+func (vs *VehicleService) ListVehicles(ctx context.Context, driver_id string) (dto.Vehicles, error) {
+	vehicles, err := vs.repositories.List(ctx, driver_id)
+	if err != nil {
+		return dto.Vehicles{}, err
+	}
+	response := dto.Vehicles{Vehicles: make([]dto.Vehicle, 0, len(vehicles)), TotalCount: len(vehicles)}
+	for _, vehicle := range vehicles {
+		response.Vehicles = append(response.Vehicles, vs.toDTO(vehicle))
+	}
+	return response, nil
+}
+
+// UpdateVehicle changes the vehicle, a vehicle in use is matched with the new values once
+// the driver index picks them up on the next resync or GoOnline
+func (vs *VehicleService) UpdateVehicle(ctx context.Context, driver_id, vehicle_id string, req dto.VehicleRequest) (dto.Vehicle, error) {
+	vehicle, err := vs.repositories.Get(ctx, driver_id, vehicle_id)
+	if err != nil {
+		return dto.Vehicle{}, err
+	}
+	if err := vs.apply(&vehicle, req); err != nil {
+		return dto.Vehicle{}, err
+	}
+	updated, err := vs.repositories.Update(ctx, vehicle)
+	if err != nil {
+		return dto.Vehicle{}, err
+	}
+	return vs.toDTO(updated), nil
+}
+
+func (vs *VehicleService) RemoveVehicle(ctx context.Context, driver_id, vehicle_id string) error {
+	return vs.repositories.Remove(ctx, driver_id, vehicle_id)
+}
+
+// SelectForSession returns the vehicle the driver goes online with: the requested one, or
+// the last used one. false means the driver has no vehicle and the profile vehicle is used.
+func (vs *VehicleService) SelectForSession(ctx context.Context, driver_id, vehicle_id string) (dto.Vehicle, bool, error) {
+	var vehicle model.Vehicle
+	var err error
+	if vehicle_id != "" {
+		vehicle, err = vs.repositories.Get(ctx, driver_id, vehicle_id)
+	} else {
+		vehicle, err = vs.repositories.GetLastUsed(ctx, driver_id)
+		if errors.Is(err, db.ErrVehicleNotFound) {
+			return dto.Vehicle{}, false, nil
+		}
+	}
+	if err != nil {
+		return dto.Vehicle{}, false, err
+	}
+	return vs.toDTO(vehicle), true, nil
+}
+
+func (vs *VehicleService) apply(vehicle *model.Vehicle, req dto.VehicleRequest) error {
+	if req.VehicleType != nil {
+		vehicle.VehicleType = strings.ToUpper(strings.TrimSpace(*req.VehicleType))
+	}
+	if !slices.Contains(model.VehicleTypes, vehicle.VehicleType) {
+		return fmt.Errorf("%w: vehicle_type must be one of %v", ErrInvalidVehicle, model.VehicleTypes)
+	}
+	for field, value := range map[string]*string{"make": req.Make, "model": req.Model, "plate": req.Plate} {
+		if value != nil && strings.TrimSpace(*value) == "" {
+			return fmt.Errorf("%w: %s cannot be empty", ErrInvalidVehicle, field)
+		}
+	}
+	if req.Make != nil {
+		vehicle.Make = strings.TrimSpace(*req.Make)
+	}
+	if req.Model != nil {
+		vehicle.Model = strings.TrimSpace(*req.Model)
+	}
+	if req.Plate != nil {
+		vehicle.Plate = strings.ToUpper(strings.TrimSpace(*req.Plate))
+	}
+	if req.Color != nil {
+		vehicle.Color = strings.TrimSpace(*req.Color)
+	}
+	if req.Year != nil {
+		if *req.Year < 1950 || *req.Year > time.Now().Year()+1 {
+			return fmt.Errorf("%w: year is out of range", ErrInvalidVehicle)
+		}
+		vehicle.Year = req.Year
+	}
+	if req.Seats != nil {
+		if *req.Seats < 1 || *req.Seats > 20 {
+			return fmt.Errorf("%w: seats must be between 1 and 20", ErrInvalidVehicle)
+		}
+		vehicle.Seats = *req.Seats
+	}
+	if req.Capabilities != nil {
+		vehicle.Capabilities = []string{}
+		for _, capability := range *req.Capabilities {
+			capability = strings.ToLower(strings.TrimSpace(capability))
+			if capability != "" && !slices.Contains(vehicle.Capabilities, capability) {
+				vehicle.Capabilities = append(vehicle.Capabilities, capability)
+			}
+		}
+	}
+	if req.OptInTypes != nil {
+		vehicle.OptInTypes = []string{}
+		for _, rideType := range *req.OptInTypes {
+			rideType = strings.ToUpper(strings.TrimSpace(rideType))
+			if rideType == vehicle.VehicleType || slices.Contains(vehicle.OptInTypes, rideType) {
+				continue
+			}
+			if !slices.Contains(vs.rules.cfg.OptIn[vehicle.VehicleType], rideType) {
+				return fmt.Errorf("%w: %s vehicles cannot opt in to %s", ErrInvalidVehicle, vehicle.VehicleType, rideType)
+			}
+			vehicle.OptInTypes = append(vehicle.OptInTypes, rideType)
+		}
+	}
+	if vehicle.Capabilities == nil {
+		vehicle.Capabilities = []string{}
+	}
+	if vehicle.OptInTypes == nil {
+		vehicle.OptInTypes = []string{}
+	}
+	return nil
+}
+
+func (vs *VehicleService) toDTO(vehicle model.Vehicle) dto.Vehicle {
+	return dto.Vehicle{
+		VehicleId:    vehicle.VehicleId,
+		VehicleType:  vehicle.VehicleType,
+		Make:         vehicle.Make,
+		Model:        vehicle.Model,
+		Year:         vehicle.Year,
+		Color:        vehicle.Color,
+		Plate:        vehicle.Plate,
+		Seats:        vehicle.Seats,
+		Capabilities: vehicle.Capabilities,
+		OptInTypes:   vehicle.OptInTypes,
+		RideTypes:    vs.rules.RideTypes(vehicle.VehicleType, vehicle.OptInTypes),
+		CreatedAt:    vehicle.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:    vehicle.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
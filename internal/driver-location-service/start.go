@@ -16,7 +16,9 @@ import (
 	"ride-hail/internal/driver-location-service/adapters/service/rabbitmq"
 	"ride-hail/internal/driver-location-service/adapters/service/ws"
 	"ride-hail/internal/driver-location-service/core/services"
+	"ride-hail/internal/filestore"
 	"ride-hail/internal/logger"
+	"ride-hail/internal/routing"
 )
 
 func Execute(ctx context.Context, mylog logger.Logger, cfg *config.Config) error {
@@ -35,6 +37,15 @@ func Execute(ctx context.Context, mylog logger.Logger, cfg *config.Config) error
 	defer database.Close()
 	log.Info("Database connection established successufuly")
 
+	// Location writes get their own connection
+	writerDB, err := db.ConnectDB(newCtx, cfg.DB, mylog)
+	if err != nil {
+		log.Error("Location writer database connection failed: ", err)
+		return err
+	}
+	locationWriter := db.NewLocationWriter(writerDB, cfg.Writer, mylog)
+	go locationWriter.Run(newCtx)
+
 	// Declaring Broker
 	broker, err := rabbitmq.New(ctx, *cfg.RabbitMq, mylog)
 	if err != nil {
@@ -55,15 +66,77 @@ func Execute(ctx context.Context, mylog logger.Logger, cfg *config.Config) error
 	}
 	log.Info("Consumer is listenning for the messages")
 
+	// Background loops, each on a connection of its own
+	jobs, err := db.ConnectJobs(newCtx, cfg.DB, mylog)
+	if err != nil {
+		log.Error("Background jobs database connection failed: ", err)
+		return err
+	}
+	defer jobs.Close()
+
+	// Driver documents, shared with admin-service
+	store, err := filestore.NewLocal(cfg.Documents.Dir)
+	if err != nil {
+		log.Error("Document store is not available: ", err)
+		return err
+	}
+
 	// Declaring service components
 	repository := db.New(database)
-	wbManager := ws.NewWebSocketManager()
-	service := services.New(repository, mylog, broker, cfg.App.PublicJwtSecret)
+	wbManager := ws.NewWebSocketManager(cfg.Resume)
+
+	// Replicas share driver sockets through the connection registry, it gets its own connection
+	registryDB, err := db.ConnectDB(newCtx, cfg.DB, mylog)
+	if err != nil {
+		log.Error("Connection registry database connection failed: ", err)
+		return err
+	}
+	defer registryDB.Close()
+	cluster := ws.NewCluster(wbManager, db.NewConnectionRepository(registryDB), broker, cfg.Cluster, mylog)
+	if err := cluster.Start(newCtx); err != nil {
+		log.Error("Failed to join the cluster: ", err)
+		return err
+	}
+	log.Info("Joined the cluster", "instance_id", cfg.Cluster.InstanceID)
+	router := routing.New(cfg.Routing, mylog)
+	service := services.New(services.Deps{
+		Repositories: repository,
+		Jobs:         jobs,
+		Log:          mylog,
+		Broker:       broker,
+		Router:       router,
+		Writer:       locationWriter,
+		Store:        store,
+		Config:       cfg,
+	})
+	service.DriverIndex.SetRemote(wbManager.HasRemoteDrivers)
 	handler := handlers.New(service, mylog, wbManager)
 	log.Info("All driver-location components are declared")
 
+	// Driver scores refresh
+	go service.ScoreService.Run(newCtx)
+
+	// Live driver index, built before the distributor starts matching
+	if err := service.DriverIndex.Rebuild(newCtx); err != nil {
+		log.Error("Failed to build driver index, using Postgres for driver search", err)
+	}
+	go service.DriverIndex.Run(newCtx)
+
+	// location_history partitions, retention and ride tracks
+	go service.HistoryService.Run(newCtx)
+
+	// driver sessions not resumed in time
+	go wbManager.Run(newCtx)
+
+	// demand heatmap, pushed to idle drivers
+	go service.DemandService.Run(newCtx, wbManager)
+	go service.HoursService.Run(newCtx, wbManager, func(ctx context.Context, driver_id string) error {
+		_, err := service.DriverService.GoOffline(ctx, driver_id)
+		return err
+	})
+
 	// Creating the distributor
-	distributor := services.NewDistributor(newCtx, req, statusMsgs, wbManager, broker, service.DriverService, mylog)
+	distributor := services.NewDistributor(newCtx, req, statusMsgs, wbManager, broker, service.DriverService, service.OfferService, service.EarningsService, cfg.Matching, cfg.Dispatch, mylog)
 	go func() {
 		if err := distributor.MessageDistributor(); err != nil {
 			mylog.Error("Message distributor encountered an error", err)
@@ -92,6 +165,8 @@ func Execute(ctx context.Context, mylog logger.Logger, cfg *config.Config) error
 	select {
 	case <-newCtx.Done():
 		mylog.Info("Shutdown signal received")
+		// pending locations are flushed before the database closes
+		locationWriter.Wait()
 		// return GracefullShutDown(context.Background())
 	case err := <-runErrCh:
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+// EncodePolyline encodes points with the Google encoded polyline algorithm (precision 1e5)
+func EncodePolyline(points []Point) string {
+	var (
+		b                strings.Builder
+		prevLat, prevLng int64
+	)
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * 1e5))
+		lng := int64(math.Round(p.Lng * 1e5))
+		encodeValue(&b, lat-prevLat)
+		encodeValue(&b, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return b.String()
+}
+
+// DecodePolyline is the reverse of EncodePolyline
+func DecodePolyline(encoded string) ([]Point, error) {
+	var (
+		points   []Point
+		lat, lng int64
+		i        int
+	)
+	for i < len(encoded) {
+		dLat, n, err := decodeValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLng, n, err := decodeValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lng += dLng
+		points = append(points, Point{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+	return points, nil
+}
+
+func encodeValue(b *strings.Builder, v int64) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte((0x20 | (u & 0x1f)) + 63))
+		u >>= 5
+	}
+	b.WriteByte(byte(u + 63))
+}
+
+func decodeValue(s string) (int64, int, error) {
+	var (
+		result int64
+		shift  uint
+	)
+	for i := 0; i < len(s); i++ {
+		c := int64(s[i]) - 63
+		if c < 0 || c > 0x3f {
+			return 0, 0, ErrInvalidPolyline
+		}
+		result |= (c & 0x1f) << shift
+		shift += 5
+		if c < 0x20 {
+			if result&1 != 0 {
+				return ^(result >> 1), i + 1, nil
+			}
+			return result >> 1, i + 1, nil
+		}
+	}
+	return 0, 0, ErrInvalidPolyline
+}
@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+		want   string
+	}{
+		{name: "empty", points: nil, want: ""},
+		{name: "origin", points: []Point{{Lat: 0, Lng: 0}}, want: "??"},
+		{
+			// the example of the algorithm description
+			name:   "reference",
+			points: []Point{{Lat: 38.5, Lng: -120.2}, {Lat: 40.7, Lng: -120.95}, {Lat: 43.252, Lng: -126.453}},
+			want:   "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodePolyline(tt.points); got != tt.want {
+				t.Errorf("EncodePolyline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolylineRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []Point
+	}{
+		{name: "single", points: []Point{{Lat: 43.238949, Lng: 76.889709}}},
+		{name: "negative coordinates", points: []Point{{Lat: -33.86882, Lng: 151.20929}, {Lat: -34.60372, Lng: -58.38159}, {Lat: 40.71277, Lng: -74.00597}}},
+		{name: "negative deltas", points: []Point{{Lat: 10, Lng: 10}, {Lat: 9.5, Lng: 9.25}, {Lat: 9.49999, Lng: 9.24999}}},
+		{name: "zero deltas", points: []Point{{Lat: 43.2389, Lng: 76.8897}, {Lat: 43.2389, Lng: 76.8897}, {Lat: 43.2389, Lng: 76.8897}}},
+		{name: "smallest deltas", points: []Point{{Lat: 0, Lng: 0}, {Lat: 0.00001, Lng: -0.00001}, {Lat: 0, Lng: 0}, {Lat: -0.00001, Lng: 0.00001}}},
+		{name: "crossing zero", points: []Point{{Lat: 0.00002, Lng: -0.00003}, {Lat: -0.00002, Lng: 0.00003}}},
+		{name: "below precision", points: []Point{{Lat: 0.000004, Lng: -0.000004}, {Lat: 0.000006, Lng: -0.000006}}},
+		{name: "extremes", points: []Point{{Lat: 90, Lng: 180}, {Lat: -90, Lng: -180}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePolyline(EncodePolyline(tt.points))
+			if err != nil {
+				t.Fatalf("DecodePolyline: %v", err)
+			}
+			if len(got) != len(tt.points) {
+				t.Fatalf("decoded %d points, want %d", len(got), len(tt.points))
+			}
+			for i, p := range tt.points {
+				// the encoding keeps 5 decimals
+				want := Point{Lat: math.Round(p.Lat*1e5) / 1e5, Lng: math.Round(p.Lng*1e5) / 1e5}
+				if math.Abs(got[i].Lat-want.Lat) > 1e-9 || math.Abs(got[i].Lng-want.Lng) > 1e-9 {
+					t.Errorf("point %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePolylineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "truncated value", encoded: "_p~iF~ps|"},
+		{name: "latitude without longitude", encoded: "_p~iF"},
+		{name: "character below range", encoded: "_p~iF ps|U"},
+		{name: "character above range", encoded: "\x7f?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodePolyline(tt.encoded); !errors.Is(err, ErrInvalidPolyline) {
+				t.Errorf("DecodePolyline(%q): %v, want %v", tt.encoded, err, ErrInvalidPolyline)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package geo
+
+import "math"
+
+// Simplify drops points closer than toleranceMeters to the line through their
+// neighbours (Douglas-Peucker), the first and last points are always kept
+func Simplify(points []Point, toleranceMeters float64) []Point {
+	keep := SimplifyIndices(points, toleranceMeters)
+	res := make([]Point, len(keep))
+	for i, idx := range keep {
+		res[i] = points[idx]
+	}
+	return res
+}
+
+// SimplifyIndices is Simplify returning the indices of the kept points in order,
+// so callers can keep data attached to the points
+func SimplifyIndices(points []Point, toleranceMeters float64) []int {
+	n := len(points)
+	if n <= 2 || toleranceMeters <= 0 {
+		res := make([]int, n)
+		for i := range res {
+			res[i] = i
+		}
+		return res
+	}
+
+	keep := make([]bool, n)
+	keep[0], keep[n-1] = true, true
+
+	type span struct{ first, last int }
+	stack := []span{{0, n - 1}}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest, maxDist := -1, toleranceMeters
+		for i := s.first + 1; i < s.last; i++ {
+			if d := segmentDistanceMeters(points[i], points[s.first], points[s.last]); d > maxDist {
+				farthest, maxDist = i, d
+			}
+		}
+		if farthest >= 0 {
+			keep[farthest] = true
+			stack = append(stack, span{s.first, farthest}, span{farthest, s.last})
+		}
+	}
+
+	var res []int
+	for i, k := range keep {
+		if k {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// segmentDistanceMeters is the distance from p to the segment a-b on a local
+// equirectangular projection, precise enough for the short segments of a trip
+func segmentDistanceMeters(p, a, b Point) float64 {
+	metersPerDeg := EarthRadiusKm * 1000 * math.Pi / 180
+	cosLat := math.Cos(toRadians(a.Lat))
+	px, py := (p.Lng-a.Lng)*cosLat*metersPerDeg, (p.Lat-a.Lat)*metersPerDeg
+	bx, by := (b.Lng-a.Lng)*cosLat*metersPerDeg, (b.Lat-a.Lat)*metersPerDeg
+
+	lengthSq := bx*bx + by*by
+	if lengthSq == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+	return math.Hypot(px-t*bx, py-t*by)
+}
@@ -0,0 +1,75 @@
+package geo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSimplifyIndices(t *testing.T) {
+	// about 111 m per 0.001 degree of latitude, 0.001 degree of longitude is about 111 m at the equator
+	tests := []struct {
+		name      string
+		points    []Point
+		tolerance float64
+		want      []int
+	}{
+		{name: "empty", points: nil, tolerance: 10, want: []int{}},
+		{name: "single", points: []Point{{Lat: 1, Lng: 1}}, tolerance: 10, want: []int{0}},
+		{name: "two points", points: []Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 0.01}}, tolerance: 10, want: []int{0, 1}},
+		{
+			name:      "straight line",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 0.001}, {Lat: 0, Lng: 0.002}, {Lat: 0, Lng: 0.003}},
+			tolerance: 1,
+			want:      []int{0, 3},
+		},
+		{
+			name:      "deviation within tolerance",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0.00005, Lng: 0.001}, {Lat: 0, Lng: 0.002}},
+			tolerance: 10,
+			want:      []int{0, 2},
+		},
+		{
+			name:      "deviation above tolerance",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0.001, Lng: 0.001}, {Lat: 0, Lng: 0.002}},
+			tolerance: 10,
+			want:      []int{0, 1, 2},
+		},
+		{
+			name:      "corner kept, points on the legs dropped",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 0.001}, {Lat: 0, Lng: 0.002}, {Lat: 0.001, Lng: 0.002}, {Lat: 0.002, Lng: 0.002}},
+			tolerance: 5,
+			want:      []int{0, 2, 4},
+		},
+		{
+			name:      "zero tolerance keeps everything",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 0.001}, {Lat: 0, Lng: 0.002}},
+			tolerance: 0,
+			want:      []int{0, 1, 2},
+		},
+		{
+			name:      "closed loop",
+			points:    []Point{{Lat: 0, Lng: 0}, {Lat: 0.001, Lng: 0}, {Lat: 0.001, Lng: 0.001}, {Lat: 0, Lng: 0}},
+			tolerance: 10,
+			want:      []int{0, 1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SimplifyIndices(tt.points, tt.tolerance)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SimplifyIndices = %v, want %v", got, tt.want)
+			}
+
+			simplified := Simplify(tt.points, tt.tolerance)
+			if len(simplified) != len(tt.want) {
+				t.Fatalf("Simplify kept %d points, want %d", len(simplified), len(tt.want))
+			}
+			for i, idx := range tt.want {
+				if simplified[i] != tt.points[idx] {
+					t.Errorf("Simplify point %d = %+v, want %+v", i, simplified[i], tt.points[idx])
+				}
+			}
+		})
+	}
+}
@@ -2,11 +2,13 @@ package handle
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"ride-hail/internal/logger"
 	"ride-hail/internal/ride-service/core/domain/data"
 	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/ride-service/core/services"
 )
 
 type RidesHandler struct {
@@ -32,6 +34,10 @@ func (rh *RidesHandler) CreateRide() http.HandlerFunc {
 
 		res, err := rh.ridesService.CreateRide(req)
 		if err != nil {
+			if errors.Is(err, services.ErrOutsideServiceArea) || errors.Is(err, services.ErrRestrictedZone) {
+				JsonError(w, http.StatusUnprocessableEntity, err)
+				return
+			}
 			JsonError(w, http.StatusInternalServerError, err)
 			return
 		}
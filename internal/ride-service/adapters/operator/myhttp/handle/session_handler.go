@@ -0,0 +1,69 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/ride-service/core/services"
+)
+
+type SessionHandler struct {
+	sessionService ports.ISessionService
+	log            logger.Logger
+}
+
+func NewSessionHandler(ss ports.ISessionService, log logger.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: ss,
+		log:            log,
+	}
+}
+
+// ListSessions serves GET /passengers/{passenger_id}/sessions
+func (sh *SessionHandler) ListSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		res, err := sh.sessionService.ListSessions(ctx, r.PathValue("passenger_id"), r.Header.Get("X-UserId"))
+		switch {
+		case errors.Is(err, services.ErrSessionForbidden):
+			JsonError(w, http.StatusForbidden, err)
+			return
+		case err != nil:
+			sh.log.Action("list_sessions_failed").Error("Failed to list sessions", err)
+			JsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, res)
+	}
+}
+
+// RevokeSession serves DELETE /passengers/{passenger_id}/sessions/{device_id}
+func (sh *SessionHandler) RevokeSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		res, err := sh.sessionService.RevokeSession(ctx, r.PathValue("passenger_id"), r.Header.Get("X-UserId"), r.PathValue("device_id"))
+		switch {
+		case errors.Is(err, services.ErrSessionForbidden):
+			JsonError(w, http.StatusForbidden, err)
+			return
+		case errors.Is(err, services.ErrSessionNotFound):
+			JsonError(w, http.StatusNotFound, err)
+			return
+		case err != nil:
+			sh.log.Action("revoke_session_failed").Error("Failed to revoke session", err)
+			JsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, res)
+	}
+}
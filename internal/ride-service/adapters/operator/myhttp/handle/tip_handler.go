@@ -0,0 +1,59 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/adapters/service/database"
+	"ride-hail/internal/ride-service/core/domain/data"
+	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/ride-service/core/services"
+)
+
+type TipHandler struct {
+	tipService ports.ITipService
+	log        logger.Logger
+}
+
+func NewTipHandler(ts ports.ITipService, log logger.Logger) *TipHandler {
+	return &TipHandler{
+		tipService: ts,
+		log:        log,
+	}
+}
+
+func (th *TipHandler) AddTip() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		req := data.RideTipRequestDto{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res, err := th.tipService.AddTip(ctx, r.PathValue("ride_id"), r.Header.Get("X-UserId"), req.Amount)
+		switch {
+		case errors.Is(err, services.ErrInvalidTip):
+			JsonError(w, http.StatusBadRequest, err)
+			return
+		case errors.Is(err, database.ErrRideNotFound):
+			JsonError(w, http.StatusNotFound, err)
+			return
+		case errors.Is(err, database.ErrRideNotCompleted), errors.Is(err, database.ErrTipAlreadyGiven), errors.Is(err, database.ErrEarningNotBooked):
+			JsonError(w, http.StatusConflict, err)
+			return
+		case err != nil:
+			th.log.Action("add_tip_failed").Error("Failed to add tip", err)
+			JsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		jsonResponse(w, http.StatusOK, res)
+	}
+}
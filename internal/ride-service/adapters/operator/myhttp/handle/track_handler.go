@@ -0,0 +1,230 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/adapters/service/database"
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/ride-service/core/services"
+)
+
+const (
+	TrackFormatGeoJSON  = "geojson"
+	TrackFormatGPX      = "gpx"
+	TrackFormatPolyline = "polyline"
+
+	maxSimplifyMeters = 1000
+)
+
+type TrackHandler struct {
+	trackService ports.ITrackService
+	log          logger.Logger
+}
+
+func NewTrackHandler(ts ports.ITrackService, log logger.Logger) *TrackHandler {
+	return &TrackHandler{
+		trackService: ts,
+		log:          log,
+	}
+}
+
+// GetTrack serves GET /rides/{ride_id}/track?format=geojson|gpx|polyline&from=&to=&simplify=
+func (th *TrackHandler) GetTrack() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(context.Background(), WaitTime*time.Second)
+		defer cancel()
+
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = TrackFormatGeoJSON
+		}
+		if format != TrackFormatGeoJSON && format != TrackFormatGPX && format != TrackFormatPolyline {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid format, allowed geojson, gpx, polyline"))
+			return
+		}
+
+		from, err := parseTime(query.Get("from"))
+		if err != nil {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid from, RFC3339 expected"))
+			return
+		}
+		to, err := parseTime(query.Get("to"))
+		if err != nil {
+			JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid to, RFC3339 expected"))
+			return
+		}
+
+		simplify := 0.0
+		if s := query.Get("simplify"); s != "" {
+			simplify, err = strconv.ParseFloat(s, 64)
+			if err != nil || simplify < 0 || simplify > maxSimplifyMeters {
+				JsonError(w, http.StatusBadRequest, fmt.Errorf("invalid simplify, meters in [0, %d]", maxSimplifyMeters))
+				return
+			}
+		}
+
+		track, err := th.trackService.GetTrack(ctx, r.PathValue("ride_id"), r.Header.Get("X-UserId"), r.Header.Get("X-Role"), from, to, simplify)
+		switch {
+		case errors.Is(err, services.ErrTrackForbidden):
+			JsonError(w, http.StatusForbidden, err)
+			return
+		case errors.Is(err, database.ErrRideNotFound), errors.Is(err, database.ErrTrackNotFound):
+			JsonError(w, http.StatusNotFound, err)
+			return
+		case err != nil:
+			th.log.Action("get_track_failed").Error("Failed to get ride track", err)
+			JsonError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		switch format {
+		case TrackFormatGPX:
+			writeGPX(w, track)
+		case TrackFormatPolyline:
+			jsonResponse(w, http.StatusOK, polylineTrack(track))
+		default:
+			w.Header().Set("Content-Type", "application/geo+json")
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(geoJSONTrack(track))
+		}
+	}
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+type trackProperties struct {
+	RideId     string   `json:"ride_id"`
+	Source     string   `json:"source"`
+	Points     int      `json:"points"`
+	RawPoints  int      `json:"raw_points"`
+	DistanceKm float64  `json:"distance_km"`
+	StartedAt  string   `json:"started_at,omitempty"`
+	EndedAt    string   `json:"ended_at,omitempty"`
+	Times      []string `json:"coordTimes,omitempty"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONLine     `json:"geometry"`
+	Properties trackProperties `json:"properties"`
+}
+
+type geoJSONLine struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+func geoJSONTrack(track model.Track) geoJSONFeature {
+	props := properties(track)
+	coords := make([][2]float64, len(track.Points))
+	for i, p := range track.Points {
+		coords[i] = [2]float64{p.Longitude, p.Latitude}
+		if p.RecordedAt != nil {
+			props.Times = append(props.Times, p.RecordedAt.UTC().Format(time.RFC3339))
+		}
+	}
+	if len(props.Times) != len(coords) {
+		props.Times = nil
+	}
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geoJSONLine{Type: "LineString", Coordinates: coords},
+		Properties: props,
+	}
+}
+
+type polylineResponse struct {
+	trackProperties
+	Polyline string `json:"polyline"`
+}
+
+func polylineTrack(track model.Track) polylineResponse {
+	path := make([]geo.Point, len(track.Points))
+	for i, p := range track.Points {
+		path[i] = geo.Point{Lat: p.Latitude, Lng: p.Longitude}
+	}
+	return polylineResponse{trackProperties: properties(track), Polyline: geo.EncodePolyline(path)}
+}
+
+func properties(track model.Track) trackProperties {
+	props := trackProperties{
+		RideId:     track.RideId,
+		Source:     track.Source,
+		Points:     len(track.Points),
+		RawPoints:  track.RawPoints,
+		DistanceKm: track.DistanceKm,
+	}
+	if track.StartedAt != nil {
+		props.StartedAt = track.StartedAt.UTC().Format(time.RFC3339)
+	}
+	if track.EndedAt != nil {
+		props.EndedAt = track.EndedAt.UTC().Format(time.RFC3339)
+	}
+	return props
+}
+
+type gpx struct {
+	XMLName xml.Name `xml:"gpx"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Track   gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time,omitempty"`
+}
+
+func writeGPX(w http.ResponseWriter, track model.Track) {
+	doc := gpx{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: "ride-hail",
+		Track:   gpxTrack{Name: track.RideId},
+	}
+	for _, p := range track.Points {
+		point := gpxPoint{Lat: p.Latitude, Lon: p.Longitude}
+		if p.RecordedAt != nil {
+			point.Time = p.RecordedAt.UTC().Format(time.RFC3339)
+		}
+		doc.Track.Segment.Points = append(doc.Track.Segment.Points, point)
+	}
+
+	w.Header().Set("Content-Type", "application/gpx+xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	_ = enc.Encode(doc)
+}
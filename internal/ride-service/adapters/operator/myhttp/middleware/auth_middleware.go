@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"ride-hail/internal/ride-service/adapters/operator/myhttp/handle"
@@ -21,6 +22,11 @@ func NewAuthMiddleware(accessSecret string) *AuthMiddleware {
 }
 
 func (am *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+	return am.WrapRoles(next, "PASSENGER")
+}
+
+// WrapRoles lets through the given roles, the user id and role are passed on in X-UserId and X-Role
+func (am *AuthMiddleware) WrapRoles(next http.Handler, roles ...string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
@@ -59,12 +65,13 @@ func (am *AuthMiddleware) Wrap(next http.Handler) http.Handler {
 			return
 		}
 
-		if role != "PASSENGER" {
-			handle.JsonError(w, http.StatusBadRequest, fmt.Errorf("Only passengers allowed to use this service"))
+		if !slices.Contains(roles, role) {
+			handle.JsonError(w, http.StatusBadRequest, fmt.Errorf("Only %s allowed to use this service", strings.ToLower(strings.Join(roles, ", "))))
 			return
 		}
 
 		r.Header.Set("X-UserId", userId)
+		r.Header.Set("X-Role", role)
 
 		next.ServeHTTP(w, r)
 	})
@@ -19,6 +19,7 @@ import (
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 	"ride-hail/internal/ride-service/core/ports"
 	"ride-hail/internal/ride-service/core/services"
+	"ride-hail/internal/routing"
 )
 
 var ErrServerClosed = errors.New("Server closed")
@@ -38,11 +39,15 @@ type Server struct {
 
 	notify     *notification.Notification
 	dispatcher *ws.Dispatcher
+	cluster    *ws.Cluster
 
 	db               *database.DB
+	presenceDB       *database.DB
+	etaDB            *database.DB
 	mb               ports.IRidesBroker
 	rideService      ports.IRidesService
 	passengerService ports.IPassengerService
+	etaService       *services.EtaService
 }
 
 func NewServer(ctx, appCtx context.Context, mylog logger.Logger, cfg *config.Config) *Server {
@@ -70,6 +75,20 @@ func (s *Server) Run() error {
 	s.db = db
 	mylog.Info("Successful database connection")
 
+	// presence registry of the replicas runs on a connection of its own
+	presenceDB, err := database.New(s.ctx, s.cfg.DB, mylog)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	s.presenceDB = presenceDB
+
+	// speed profile rebuilds run on a connection of their own, requests don't wait behind them
+	etaDB, err := database.New(s.ctx, s.cfg.DB, mylog)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	s.etaDB = etaDB
+
 	// Initialize RabbitMQ connection
 	mb, err := rabbitmq.New(s.appCtx, *s.cfg.RabbitMq, s.mylog)
 	if err != nil {
@@ -88,11 +107,22 @@ func (s *Server) Run() error {
 	}
 	s.mu.Unlock()
 
+	if err := s.cluster.Start(s.appCtx); err != nil {
+		return fmt.Errorf("failed to join the cluster: %w", err)
+	}
+
 	err = s.notify.Run()
 	if err != nil {
 		return err
 	}
 
+	// speed profile refresh for ETAs
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.etaService.Run(s.ctx)
+	}()
+
 	mylog.Info("server is running")
 	return s.startHTTPServer()
 }
@@ -113,20 +143,8 @@ func (s *Server) Stop(ctx context.Context) error {
 
 	s.dispatcher.BroadCast(msg)
 	s.wg.Wait()
-	// make rides that have status like
-	//   'REQUESTED'
-	//   'MATCHED'
-	//   'EN_ROUTE'
-	//   'ARRIVED'
-	//   'IN_PROGRESS'
-	//
-	// to 'CANCELLED'
-	log.Info("cancel everything...")
-	err := s.rideService.CancelEveryPossibleRides()
-	if err != nil {
-		log.Error("cannot cancel", err)
-	}
-	log.Info("cancelled everything...")
+	// rides are left as they are, other replicas keep serving them and the passengers
+	// of this one reconnect elsewhere
 
 	if s.srv != nil {
 		shutdownCtx, cancel := context.WithTimeout(ctx, WaitTime*time.Second)
@@ -146,6 +164,20 @@ func (s *Server) Stop(ctx context.Context) error {
 		log.Info("Database closed")
 	}
 
+	if s.presenceDB != nil {
+		if err := s.presenceDB.Close(); err != nil {
+			log.Error("Failed to close presence database", err)
+			return fmt.Errorf("presence db close: %w", err)
+		}
+	}
+
+	if s.etaDB != nil {
+		if err := s.etaDB.Close(); err != nil {
+			log.Error("Failed to close speed profile database", err)
+			return fmt.Errorf("eta db close: %w", err)
+		}
+	}
+
 	log.Info("HTTP server shut down gracefully")
 	return nil
 }
@@ -174,22 +206,43 @@ func (s *Server) Configure() {
 	// Repositories
 	rideRepo := database.NewRidesRepo(s.db)
 	passengerRepo := database.NewPassengerRepo(s.db)
+	zonesRepo := database.NewZonesRepo(s.db)
+	etaRepo := database.NewEtaRepo(s.db)
+	trackRepo := database.NewTrackRepo(s.db)
+	tipRepo := database.NewTipRepo(s.db)
+	presenceRepo := database.NewPresenceRepo(s.presenceDB)
+
+	// routing
+	router := routing.New(s.cfg.Routing, s.mylog)
 
 	// services
-	rideService := services.NewRidesService(s.appCtx, s.mylog, rideRepo, s.mb, nil)
+	etaService := services.NewEtaService(s.mylog, s.cfg.Eta, etaRepo, database.NewEtaRepo(s.etaDB), router)
+	s.etaService = etaService
+	rideService := services.NewRidesService(s.appCtx, s.mylog, rideRepo, zonesRepo, s.mb, nil, router, etaService, etaRepo)
 	passengerService := services.NewPassengerService(s.appCtx, s.mylog, passengerRepo, nil)
 	s.rideService = rideService
 	s.passengerService = passengerService
+	trackService := services.NewTrackService(s.mylog, trackRepo)
+	tipService := services.NewTipService(s.mylog, s.cfg.Earnings, tipRepo)
 
 	// handlers
 	rideHandler := handle.NewRidesHandler(rideService, s.mylog)
+	trackHandler := handle.NewTrackHandler(trackService, s.mylog)
+	tipHandler := handle.NewTipHandler(tipService, s.mylog)
 
 	authMiddleware := middleware.NewAuthMiddleware(s.cfg.App.PublicJwtSecret)
 
-	eventHandle := ws.NewEventHandler(s.cfg.App.PublicJwtSecret)
+	// the cluster keeps the presence connection to itself, requests read through the main one
+	sessionRepo := database.NewPresenceRepo(s.db)
+
+	eventHandle := ws.NewEventHandler(s.cfg.App.PublicJwtSecret, sessionRepo)
 	dispatcher := ws.NewDispathcer(s.appCtx, s.mylog, passengerService, eventHandle, &s.wg)
 	dispatcher.InitHandler()
 	s.dispatcher = dispatcher
+	s.cluster = ws.NewCluster(dispatcher, presenceRepo, s.mb, s.cfg.Cluster, s.mylog)
+
+	sessionService := services.NewSessionService(s.mylog, s.cfg.Cluster, sessionRepo, dispatcher)
+	sessionHandler := handle.NewSessionHandler(sessionService, s.mylog)
 
 	// consumers
 	notify := notification.New(s.ctx, &s.wg, s.mylog, dispatcher, s.mb, passengerService, rideService)
@@ -198,6 +251,10 @@ func (s *Server) Configure() {
 	// Register routes
 	s.mux.Handle("POST /rides", authMiddleware.Wrap(rideHandler.CreateRide()))
 	s.mux.Handle("POST /rides/{ride_id}/cancel", authMiddleware.Wrap(rideHandler.CancelRide()))
+	s.mux.Handle("POST /rides/{ride_id}/tip", authMiddleware.Wrap(tipHandler.AddTip()))
+	s.mux.Handle("GET /rides/{ride_id}/track", authMiddleware.WrapRoles(trackHandler.GetTrack(), "PASSENGER", "DRIVER", "ADMIN"))
+	s.mux.Handle("GET /passengers/{passenger_id}/sessions", authMiddleware.Wrap(sessionHandler.ListSessions()))
+	s.mux.Handle("DELETE /passengers/{passenger_id}/sessions/{device_id}", authMiddleware.Wrap(sessionHandler.RevokeSession()))
 
 	// websocket routes
 	s.mux.Handle("/ws/passengers/{passenger_id}", dispatcher.WsHandler())
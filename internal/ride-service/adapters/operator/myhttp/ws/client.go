@@ -2,11 +2,11 @@ package ws
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
 	"time"
 
 	"ride-hail/internal/logger"
+	"ride-hail/internal/wscodec"
 
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 
@@ -27,21 +27,27 @@ type Client struct {
 	log         logger.Logger
 	ctx         context.Context
 	conn        *websocket.Conn
+	codec       wscodec.Codec // negotiated with the subprotocol, JSON by default
 	dispatcher  *Dispatcher
 	egress      chan websocketdto.Event
 	passengerId string
+	deviceId    string
 	wg          *sync.WaitGroup
+	cancel      context.CancelFunc
 	cancelAuth  context.CancelFunc
 }
 
-func NewClient(ctx context.Context, log logger.Logger, conn *websocket.Conn, dis *Dispatcher, passengerId string, cancelAuth context.CancelFunc, wg *sync.WaitGroup) *Client {
+func NewClient(ctx context.Context, cancel context.CancelFunc, log logger.Logger, conn *websocket.Conn, codec wscodec.Codec, dis *Dispatcher, passengerId, deviceId string, cancelAuth context.CancelFunc, wg *sync.WaitGroup) *Client {
 	return &Client{
 		log:         log,
 		ctx:         ctx,
 		conn:        conn,
+		codec:       codec,
 		dispatcher:  dis,
 		egress:      make(chan websocketdto.Event),
 		passengerId: passengerId,
+		deviceId:    deviceId,
+		cancel:      cancel,
 		cancelAuth:  cancelAuth,
 		wg:          wg,
 	}
@@ -51,14 +57,14 @@ func (c *Client) ReadMessage() {
 	defer func() {
 		c.dispatcher.RemoveClient(c)
 	}()
-	log := c.log.Action("ReadMessage").With("passenger-id", c.passengerId)
+	log := c.log.Action("ReadMessage").With("passenger-id", c.passengerId, "device-id", c.deviceId)
 	c.conn.SetReadLimit(1024)
 
 	c.conn.SetPongHandler(c.PingHandler)
 
 	// loop forever
 	for {
-		_, payload, err := c.conn.ReadMessage()
+		messageType, payload, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Error("cannot read message", err)
@@ -66,9 +72,14 @@ func (c *Client) ReadMessage() {
 			break
 		}
 
+		if messageType != c.codec.FrameType() {
+			log.Warn("unexpected frame type", "type", messageType, "subprotocol", c.codec.Subprotocol())
+			continue
+		}
+
 		var req websocketdto.Event
-		if err := json.Unmarshal(payload, &req); err != nil {
-			log.Warn("cannot unmarshal json", "err", err)
+		if err := c.codec.Unmarshal(payload, &req); err != nil {
+			log.Warn("cannot unmarshal message", "err", err)
 
 			continue
 		}
@@ -85,7 +96,7 @@ func (c *Client) WriteMessage() {
 		c.wg.Done()
 		c.dispatcher.RemoveClient(c)
 	}()
-	log := c.log.Action("WriteMessage").With("passenger-id", c.passengerId)
+	log := c.log.Action("WriteMessage").With("passenger-id", c.passengerId, "device-id", c.deviceId)
 
 	ticker := time.NewTicker(pingInterval)
 
@@ -106,13 +117,13 @@ func (c *Client) WriteMessage() {
 				return
 			}
 
-			data, err := json.Marshal(msg)
+			data, err := c.codec.Marshal(msg)
 			if err != nil {
 				log.Error("cannot marshal message", err)
 				return // closes the connection, should we really
 			}
-			// Write a Regular text message to the connection
-			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			// Write a text or binary message, whichever the subprotocol uses
+			if err := c.conn.WriteMessage(c.codec.FrameType(), data); err != nil {
 				log.Error("cannot write message", err)
 			}
 		case <-ticker.C:
@@ -131,3 +142,20 @@ func (c *Client) PingHandler(pongMessage string) error {
 	log.Debug("pong")
 	return c.conn.SetReadDeadline(time.Now().Add(pongWait))
 }
+
+// send hands the event to WriteMessage, a session that is already closing drops it
+func (c *Client) send(event websocketdto.Event) {
+	select {
+	case c.egress <- event:
+	case <-c.ctx.Done():
+	}
+}
+
+// close ends the session with a close frame, WriteMessage may be writing so only a control
+// frame is sent from here
+func (c *Client) close(code int, reason string) {
+	deadline := time.Now().Add(time.Second)
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+	c.cancel()
+	c.conn.Close()
+}
@@ -0,0 +1,215 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/logger"
+	messagebrokerdto "ride-hail/internal/ride-service/core/domain/message_broker_dto"
+	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
+	"ride-hail/internal/ride-service/core/ports"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Cluster lets ride-service replicas reach passengers connected to each other. Every
+// replica lists its passengers in the presence registry, an event for a passenger
+// connected elsewhere is published to the queue of that replica and written from there.
+type Cluster struct {
+	dispatcher *Dispatcher
+	registry   ports.IPresenceRepo
+	broker     ports.IRidesBroker
+	cfg        *config.Clusterconfig
+	log        logger.Logger
+
+	registryMu sync.Mutex // the registry has a connection of its own, one query at a time
+
+	mu     sync.RWMutex
+	remote map[string][]string // passenger -> other replicas, refreshed every heartbeat
+}
+
+func NewCluster(dispatcher *Dispatcher, registry ports.IPresenceRepo, broker ports.IRidesBroker, cfg *config.Clusterconfig, log logger.Logger) *Cluster {
+	cluster := &Cluster{
+		dispatcher: dispatcher,
+		registry:   registry,
+		broker:     broker,
+		cfg:        cfg,
+		log:        log,
+		remote:     make(map[string][]string),
+	}
+	dispatcher.cluster = cluster
+	return cluster
+}
+
+// Start declares the queue of the replica and sends the first heartbeat, the rest runs
+// until ctx is done
+func (c *Cluster) Start(ctx context.Context) error {
+	queue := c.queue(c.cfg.InstanceID)
+	relay, err := c.broker.ConsumeInstance(ctx, queue)
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", queue, err)
+	}
+	if err := c.heartbeat(ctx); err != nil {
+		return err
+	}
+	go c.run(ctx, relay)
+	return nil
+}
+
+func (c *Cluster) run(ctx context.Context, relay <-chan amqp.Delivery) {
+	log := c.log.Action("Cluster")
+	ticker := time.NewTicker(time.Duration(max(c.cfg.HeartbeatSec, 1)) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.heartbeat(ctx); err != nil {
+				log.Error("cannot refresh presence registry", err)
+			}
+		case delivery, ok := <-relay:
+			if !ok {
+				log.Warn("relay queue closed", "instance_id", c.cfg.InstanceID)
+				return
+			}
+			c.handle(delivery.Body)
+		}
+	}
+}
+
+func (c *Cluster) handle(body []byte) {
+	log := c.log.Action("Cluster")
+	var msg messagebrokerdto.PassengerRelay
+	if err := json.Unmarshal(body, &msg); err != nil {
+		log.Error("cannot unmarshal relay message", err)
+		return
+	}
+
+	switch msg.Kind {
+	case messagebrokerdto.RelayEvent, "":
+		if !c.dispatcher.writeLocal(msg.PassengerId, msg.Event) {
+			log.Warn("event for a passenger without a connection dropped", "passengerId", msg.PassengerId, "from", msg.From)
+		}
+	case messagebrokerdto.RelayRevoke:
+		if c.dispatcher.revokeLocal(msg.PassengerId, msg.DeviceId) {
+			log.Info("session revoked", "passengerId", msg.PassengerId, "deviceId", msg.DeviceId, "from", msg.From)
+		}
+	default:
+		log.Warn("unknown relay message", "kind", msg.Kind)
+	}
+}
+
+func (c *Cluster) heartbeat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.Heartbeat(ctx, c.cfg.InstanceID, c.dispatcher.sessions(), c.ttl()); err != nil {
+		return err
+	}
+	connections, err := c.registry.GetConnections(ctx, c.ttl())
+	if err != nil {
+		return err
+	}
+
+	remote := make(map[string][]string)
+	for _, connection := range connections {
+		instances := remote[connection.PassengerId]
+		if connection.InstanceId != c.cfg.InstanceID && !slices.Contains(instances, connection.InstanceId) {
+			remote[connection.PassengerId] = append(instances, connection.InstanceId)
+		}
+	}
+	c.mu.Lock()
+	c.remote = remote
+	c.mu.Unlock()
+	return nil
+}
+
+// send publishes the event to the other replicas the passenger is connected to. The
+// registry is asked only when the passenger is nowhere to be found, a passenger who
+// connected since the last heartbeat is still reached.
+func (c *Cluster) send(passengerId string, event websocketdto.Event, deliveredLocally bool) {
+	c.publish(messagebrokerdto.PassengerRelay{
+		Kind:        messagebrokerdto.RelayEvent,
+		PassengerId: passengerId,
+		Event:       event,
+	}, !deliveredLocally)
+}
+
+// revoke asks the other replicas of the passenger to close the session of the device
+func (c *Cluster) revoke(passengerId, deviceId string) {
+	c.publish(messagebrokerdto.PassengerRelay{
+		Kind:        messagebrokerdto.RelayRevoke,
+		PassengerId: passengerId,
+		DeviceId:    deviceId,
+	}, true)
+}
+
+func (c *Cluster) publish(msg messagebrokerdto.PassengerRelay, askRegistry bool) {
+	log := c.log.Action("Cluster")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.mu.RLock()
+	instances := c.remote[msg.PassengerId]
+	c.mu.RUnlock()
+
+	if len(instances) == 0 && askRegistry {
+		c.registryMu.Lock()
+		found, err := c.registry.GetInstances(ctx, msg.PassengerId, c.ttl())
+		c.registryMu.Unlock()
+		if err != nil {
+			log.Error("cannot look up passenger connection", err, "passengerId", msg.PassengerId)
+			return
+		}
+		instances = found
+	}
+
+	msg.From = c.cfg.InstanceID
+	for _, instanceId := range instances {
+		if instanceId == c.cfg.InstanceID {
+			continue
+		}
+		if err := c.broker.PushMessageToInstance(ctx, c.queue(instanceId), msg); err != nil {
+			log.Error("cannot relay message", err, "passengerId", msg.PassengerId, "instance_id", instanceId)
+		}
+	}
+}
+
+func (c *Cluster) registered(passengerId, deviceId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.Register(ctx, c.cfg.InstanceID, passengerId, deviceId); err != nil {
+		c.log.Action("Cluster").Error("cannot register passenger connection", err, "passengerId", passengerId, "deviceId", deviceId)
+	}
+}
+
+func (c *Cluster) removed(passengerId, deviceId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c.registryMu.Lock()
+	defer c.registryMu.Unlock()
+	if err := c.registry.Remove(ctx, c.cfg.InstanceID, passengerId, deviceId); err != nil {
+		c.log.Action("Cluster").Error("cannot remove passenger connection", err, "passengerId", passengerId, "deviceId", deviceId)
+	}
+}
+
+func (c *Cluster) queue(instanceId string) string {
+	return "passenger.instance." + instanceId
+}
+
+func (c *Cluster) ttl() time.Duration {
+	return time.Duration(c.cfg.TTLSec) * time.Second
+}
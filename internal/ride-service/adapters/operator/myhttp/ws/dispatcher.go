@@ -2,14 +2,19 @@ package ws
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"regexp"
 	"sync"
 	"time"
 
 	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/domain/model"
 	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/wscodec"
 
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 
@@ -18,6 +23,9 @@ import (
 
 var ErrEventNotSupported = errors.New("this event type is not supported")
 
+// deviceIdPattern is what a client may pick as its device_id, one is generated otherwise
+var deviceIdPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
 // ================================================================================================== //
 // websocketUpgrader is used to upgrade incomming HTTP requests into a persitent websocket connection //
 // ================================================================================================== //
@@ -27,10 +35,11 @@ var websocketUpgrader = websocket.Upgrader{
 	// CheckOrigin:     checkOrigin,
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    wscodec.Subprotocols,
 }
 
-// ClientList is a map used to help manage a map of clients
-type ClientList map[string]*Client
+// ClientList is a map used to help manage a map of clients, passenger -> device -> client
+type ClientList map[string]map[string]*Client
 
 type Dispatcher struct {
 	ctx              context.Context
@@ -38,6 +47,7 @@ type Dispatcher struct {
 	eventHandler     *EventHandler
 	hander           map[string]EventHandle
 	clients          ClientList
+	cluster          *Cluster // nil when the replica runs alone
 	sync.RWMutex
 	wg  *sync.WaitGroup
 	log logger.Logger
@@ -81,6 +91,15 @@ func (d *Dispatcher) WsHandler() http.HandlerFunc {
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
+		deviceId := r.URL.Query().Get("device_id")
+		if deviceId == "" {
+			deviceId = newDeviceId()
+		} else if !deviceIdPattern.MatchString(deviceId) {
+			log.Warn("invalid device id", "passengerId", passengerId)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
 		conn, err := websocketUpgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Error("cannot upgrade", err)
@@ -89,8 +108,10 @@ func (d *Dispatcher) WsHandler() http.HandlerFunc {
 		ctx, cancel := context.WithCancel(d.ctx)
 		ctxAuth, cancelAuth := context.WithCancel(d.ctx)
 
-		client := NewClient(ctx, d.log, conn, d, passengerId, cancelAuth, d.wg)
-		d.AddClient(client)
+		codec := wscodec.ForSubprotocol(conn.Subprotocol())
+		// the client is added once authenticated, until then it gets no events and keeps
+		// the previous session of the device open
+		client := NewClient(ctx, cancel, d.log, conn, codec, d, passengerId, deviceId, cancelAuth, d.wg)
 		d.wg.Add(1)
 		go client.ReadMessage()
 		go client.WriteMessage()
@@ -98,50 +119,136 @@ func (d *Dispatcher) WsHandler() http.HandlerFunc {
 	}
 }
 
+// AddClient adds the authenticated session of the device next to the other devices of the
+// passenger. A device connecting again replaces its previous session.
 func (d *Dispatcher) AddClient(client *Client) {
 	log := d.log.Action("AddClient")
 	d.Lock()
-	defer d.Unlock()
+	devices, ok := d.clients[client.passengerId]
+	if !ok {
+		devices = make(map[string]*Client)
+		d.clients[client.passengerId] = devices
+	}
+	previous := devices[client.deviceId]
+	devices[client.deviceId] = client
+	d.Unlock()
 
-	d.clients[client.passengerId] = client
-	log.Info("passenger successfully added", "passengerId", client.passengerId)
+	if previous != nil && previous != client {
+		previous.close(websocket.ClosePolicyViolation, "replaced by a new connection of the device")
+	}
+	if d.cluster != nil {
+		d.cluster.registered(client.passengerId, client.deviceId)
+	}
+	log.Info("passenger successfully added", "passengerId", client.passengerId, "deviceId", client.deviceId)
 }
 
+// RemoveClient removes the session of the client only, a newer session of the same device
+// stays
 func (d *Dispatcher) RemoveClient(client *Client) {
 	log := d.log.Action("RemoveClient")
 	d.Lock()
-	defer d.Unlock()
+	devices := d.clients[client.passengerId]
+	ok := devices[client.deviceId] == client
+	if ok {
+		delete(devices, client.deviceId)
+		if len(devices) == 0 {
+			delete(d.clients, client.passengerId)
+		}
+	}
+	d.Unlock()
 
-	if _, ok := d.clients[client.passengerId]; ok {
-		client.conn.Close()
-		delete(d.clients, client.passengerId)
-		log.Info("passenger successfully deleted", "passengerId", client.passengerId)
-	} else {
-		log.Warn("passenger doesnt exist in map", "passengerId", client.passengerId)
+	client.cancel()
+	client.conn.Close()
+	if !ok {
+		log.Debug("session already removed", "passengerId", client.passengerId, "deviceId", client.deviceId)
+		return
+	}
+	if d.cluster != nil {
+		d.cluster.removed(client.passengerId, client.deviceId)
 	}
+	log.Info("passenger successfully deleted", "passengerId", client.passengerId, "deviceId", client.deviceId)
 }
 
+// WriteToUser writes the event to every device of the passenger, on this replica and on the
+// other replicas the passenger is connected to
 func (d *Dispatcher) WriteToUser(passengerId string, event websocketdto.Event) {
-	d.Lock()
-	defer d.Unlock()
+	delivered := d.writeLocal(passengerId, event)
+	if d.cluster != nil {
+		d.cluster.send(passengerId, event, delivered)
+	}
+}
+
+// RevokeSession closes the session of the device wherever it is connected
+func (d *Dispatcher) RevokeSession(passengerId, deviceId string) {
+	d.revokeLocal(passengerId, deviceId)
+	if d.cluster != nil {
+		d.cluster.revoke(passengerId, deviceId)
+	}
+}
 
-	if client, ok := d.clients[passengerId]; ok {
-		client.egress <- event
+func (d *Dispatcher) writeLocal(passengerId string, event websocketdto.Event) bool {
+	clients := d.devices(passengerId)
+	for _, client := range clients {
+		client.send(event)
 	}
+	return len(clients) > 0
+}
+
+func (d *Dispatcher) revokeLocal(passengerId, deviceId string) bool {
+	d.RLock()
+	client, ok := d.clients[passengerId][deviceId]
+	d.RUnlock()
+
+	if ok {
+		client.close(websocket.ClosePolicyViolation, "session revoked")
+		d.RemoveClient(client)
+	}
+	return ok
+}
+
+func (d *Dispatcher) devices(passengerId string) []*Client {
+	d.RLock()
+	defer d.RUnlock()
+
+	clients := make([]*Client, 0, len(d.clients[passengerId]))
+	for _, client := range d.clients[passengerId] {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+func (d *Dispatcher) sessions() []model.PassengerConnection {
+	d.RLock()
+	defer d.RUnlock()
+
+	var sessions []model.PassengerConnection
+	for passengerId, devices := range d.clients {
+		for deviceId := range devices {
+			sessions = append(sessions, model.PassengerConnection{PassengerId: passengerId, DeviceId: deviceId})
+		}
+	}
+	return sessions
 }
 
 func (d *Dispatcher) BroadCast(event websocketdto.Event) {
-	d.Lock()
-	defer d.Unlock()
+	d.RLock()
+	var clients []*Client
+	for _, devices := range d.clients {
+		for _, client := range devices {
+			clients = append(clients, client)
+		}
+	}
+	d.RUnlock()
 
-	for _, client := range d.clients {
-		client.egress <- event
+	for _, client := range clients {
+		client.send(event)
 	}
 }
 
 func (d *Dispatcher) StartTimerAuth(client *Client, cancel context.CancelFunc, ctxAuth context.Context) {
 	type msg struct {
-		Text string `json:"text"`
+		Text     string `json:"text"`
+		DeviceId string `json:"device_id,omitempty"`
 	}
 	select {
 	case <-time.After(time.Second * 5):
@@ -154,18 +261,19 @@ func (d *Dispatcher) StartTimerAuth(client *Client, cancel context.CancelFunc, c
 			Data: data,
 		}
 
-		d.WriteToUser(client.passengerId, event)
+		client.send(event)
 		cancel()
 	case <-ctxAuth.Done():
 		msg := msg{
-			Text: "auth success",
+			Text:     "auth success",
+			DeviceId: client.deviceId,
 		}
 		data, _ := json.Marshal(msg)
 		event := websocketdto.Event{
 			Type: "auth",
 			Data: data,
 		}
-		d.WriteToUser(client.passengerId, event)
+		client.send(event)
 		return
 	}
 }
@@ -181,3 +289,9 @@ func (d *Dispatcher) EventHandle(client *Client, event websocketdto.Event) error
 		return ErrEventNotSupported
 	}
 }
+
+func newDeviceId() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
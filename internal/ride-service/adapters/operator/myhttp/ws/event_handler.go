@@ -1,12 +1,14 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
+	"ride-hail/internal/ride-service/core/ports"
 
 	"github.com/golang-jwt/jwt"
 )
@@ -15,11 +17,13 @@ type EventHandle func(c *Client, e websocketdto.Event) error
 
 type EventHandler struct {
 	accessToken string
+	sessions    ports.IPresenceRepo // revoked devices
 }
 
-func NewEventHandler(accessToken string) *EventHandler {
+func NewEventHandler(accessToken string, sessions ports.IPresenceRepo) *EventHandler {
 	return &EventHandler{
 		accessToken: accessToken,
+		sessions:    sessions,
 	}
 }
 
@@ -62,6 +66,20 @@ func (eh *EventHandler) AuthHandler(client *Client, e websocketdto.Event) error
 	if time.Now().Unix() > int64(exp) {
 		return fmt.Errorf("nigga time is up")
 	}
+
+	ctx, cancel := context.WithTimeout(client.ctx, 5*time.Second)
+	defer cancel()
+	revoked, err := eh.sessions.IsRevoked(ctx, client.passengerId, client.deviceId)
+	if err != nil {
+		return fmt.Errorf("cannot check session: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("session revoked")
+	}
+	if client.ctx.Err() != nil {
+		return fmt.Errorf("connection closed before auth")
+	}
+	client.dispatcher.AddClient(client)
 	client.cancelAuth()
 
 	return nil
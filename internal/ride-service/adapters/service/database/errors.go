@@ -1 +1,14 @@
 package database
+
+import "errors"
+
+var (
+	ErrRideNotFound  = errors.New("ride not found")
+	ErrTrackNotFound = errors.New("ride has no recorded track")
+
+	ErrRideNotCompleted = errors.New("only completed rides can be tipped")
+	ErrTipAlreadyGiven  = errors.New("ride was already tipped")
+	ErrEarningNotBooked = errors.New("ride earnings are not booked yet")
+
+	ErrStaleStatus = errors.New("ride has already moved past the status")
+)
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type EtaRepo struct {
+	db *DB
+}
+
+func NewEtaRepo(db *DB) ports.IEtaRepo {
+	return &EtaRepo{
+		db: db,
+	}
+}
+
+// RefreshSpeedProfiles rebuilds speed_profiles from the recent location history
+func (er *EtaRepo) RefreshSpeedProfiles(ctx context.Context, cellSizeDeg float64, minSamples, lookbackDays int) (int64, error) {
+	q1 := `DELETE FROM speed_profiles`
+
+	q2 := `
+	INSERT INTO speed_profiles (cell_lat, cell_lng, hour_of_week, avg_speed_kmh, samples)
+	SELECT
+		floor(latitude / $1)::int,
+		floor(longitude / $1)::int,
+		(EXTRACT(ISODOW FROM recorded_at AT TIME ZONE 'UTC')::int - 1) * 24
+			+ EXTRACT(HOUR FROM recorded_at AT TIME ZONE 'UTC')::int AS hour_of_week,
+		AVG(speed_kmh),
+		COUNT(*)
+	FROM location_history
+	WHERE speed_kmh > 0
+		AND recorded_at >= NOW() - make_interval(days => $3)
+	GROUP BY 1, 2, 3
+	HAVING COUNT(*) >= $2`
+
+	conn := er.db.conn
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) // Safe rollback if not committed
+
+	if _, err := tx.Exec(ctx, q1); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, q2, cellSizeDeg, minSamples, lookbackDays)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), tx.Commit(ctx)
+}
+
+func (er *EtaRepo) LoadSpeedProfiles(ctx context.Context) ([]model.SpeedProfile, error) {
+	q := `SELECT cell_lat, cell_lng, hour_of_week, avg_speed_kmh::float, samples FROM speed_profiles`
+
+	rows, err := er.db.conn.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []model.SpeedProfile
+	for rows.Next() {
+		var p model.SpeedProfile
+		if err := rows.Scan(&p.CellLat, &p.CellLng, &p.HourOfWeek, &p.AvgSpeedKmh, &p.Samples); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// SavePrediction keeps only the first prediction of each ride leg
+func (er *EtaRepo) SavePrediction(ctx context.Context, rideId, kind string, arrival time.Time, source string) error {
+	q := `
+	INSERT INTO eta_predictions (ride_id, kind, predicted_arrival, source)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (ride_id, kind) DO NOTHING`
+
+	_, err := er.db.conn.Exec(ctx, q, rideId, kind, arrival, source)
+	return err
+}
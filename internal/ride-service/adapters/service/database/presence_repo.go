@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const wsService = "ride-service"
+
+type PresenceRepo struct {
+	db *DB
+}
+
+func NewPresenceRepo(db *DB) ports.IPresenceRepo {
+	return &PresenceRepo{db: db}
+}
+
+// Heartbeat refreshes the replica and replaces its rows with the sessions connected to it.
+// Replicas silent for longer than ttl are removed with their rows.
+func (pr *PresenceRepo) Heartbeat(ctx context.Context, instanceId string, sessions []model.PassengerConnection, ttl time.Duration) error {
+	tx, err := pr.db.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	q1 := `
+	INSERT INTO ws_instances(instance_id, service)
+	VALUES ($1, $2)
+	ON CONFLICT (instance_id) DO UPDATE SET heartbeat_at = NOW()`
+
+	q2 := `
+	DELETE FROM ws_instances
+	WHERE service = $1 AND heartbeat_at < NOW() - make_interval(secs => $2)`
+
+	q3 := `
+	DELETE FROM passenger_connections
+	WHERE instance_id = $1
+		AND (passenger_id::text, device_id) NOT IN (
+			SELECT s.passenger_id, s.device_id FROM unnest($2::text[], $3::text[]) AS s(passenger_id, device_id)
+		)`
+
+	q4 := `
+	INSERT INTO passenger_connections(passenger_id, instance_id, device_id)
+	SELECT s.passenger_id::uuid, $1, s.device_id
+	FROM unnest($2::text[], $3::text[]) AS s(passenger_id, device_id)
+	ON CONFLICT (passenger_id, instance_id, device_id) DO UPDATE SET updated_at = NOW()`
+
+	passengerIds := make([]string, 0, len(sessions))
+	deviceIds := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		passengerIds = append(passengerIds, session.PassengerId)
+		deviceIds = append(deviceIds, session.DeviceId)
+	}
+
+	if _, err := tx.Exec(ctx, q1, instanceId, wsService); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, q2, wsService, ttl.Seconds()); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, q3, instanceId, passengerIds, deviceIds); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, q4, instanceId, passengerIds, deviceIds); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (pr *PresenceRepo) Register(ctx context.Context, instanceId, passengerId, deviceId string) error {
+	q := `
+	INSERT INTO passenger_connections(passenger_id, instance_id, device_id)
+	VALUES ($2, $1, $3)
+	ON CONFLICT (passenger_id, instance_id, device_id) DO UPDATE SET connected_at = NOW(), updated_at = NOW()`
+
+	_, err := pr.db.conn.Exec(ctx, q, instanceId, passengerId, deviceId)
+	return err
+}
+
+func (pr *PresenceRepo) Remove(ctx context.Context, instanceId, passengerId, deviceId string) error {
+	q := `DELETE FROM passenger_connections WHERE passenger_id = $2 AND instance_id = $1 AND device_id = $3`
+
+	_, err := pr.db.conn.Exec(ctx, q, instanceId, passengerId, deviceId)
+	return err
+}
+
+// GetConnections returns the sessions connected to replicas alive within ttl
+func (pr *PresenceRepo) GetConnections(ctx context.Context, ttl time.Duration) ([]model.PassengerConnection, error) {
+	q := `
+	SELECT pc.passenger_id, pc.instance_id, pc.device_id, pc.connected_at
+	FROM passenger_connections pc
+	JOIN ws_instances i ON i.instance_id = pc.instance_id
+	WHERE i.heartbeat_at >= NOW() - make_interval(secs => $1)`
+
+	rows, err := pr.db.conn.Query(ctx, q, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return scanConnections(rows)
+}
+
+// GetInstances returns the replicas alive within ttl the passenger is connected to
+func (pr *PresenceRepo) GetInstances(ctx context.Context, passengerId string, ttl time.Duration) ([]string, error) {
+	q := `
+	SELECT DISTINCT pc.instance_id
+	FROM passenger_connections pc
+	JOIN ws_instances i ON i.instance_id = pc.instance_id
+	WHERE pc.passenger_id = $1 AND i.heartbeat_at >= NOW() - make_interval(secs => $2)`
+
+	rows, err := pr.db.conn.Query(ctx, q, passengerId, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instances []string
+	for rows.Next() {
+		var instanceId string
+		if err := rows.Scan(&instanceId); err != nil {
+			return nil, err
+		}
+		instances = append(instances, instanceId)
+	}
+	return instances, rows.Err()
+}
+
+// GetSessions returns the devices of the passenger on replicas alive within ttl, newest first
+func (pr *PresenceRepo) GetSessions(ctx context.Context, passengerId string, ttl time.Duration) ([]model.PassengerConnection, error) {
+	q := `
+	SELECT pc.passenger_id, pc.instance_id, pc.device_id, pc.connected_at
+	FROM passenger_connections pc
+	JOIN ws_instances i ON i.instance_id = pc.instance_id
+	WHERE pc.passenger_id = $1 AND i.heartbeat_at >= NOW() - make_interval(secs => $2)
+	ORDER BY pc.connected_at DESC`
+
+	rows, err := pr.db.conn.Query(ctx, q, passengerId, ttl.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return scanConnections(rows)
+}
+
+// Revoke refuses the device of the passenger for ttl, expired revocations are dropped on the way
+func (pr *PresenceRepo) Revoke(ctx context.Context, passengerId, deviceId string, ttl time.Duration) error {
+	tx, err := pr.db.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	q1 := `DELETE FROM passenger_session_revocations WHERE expires_at < NOW()`
+
+	q2 := `
+	INSERT INTO passenger_session_revocations(passenger_id, device_id, expires_at)
+	VALUES ($1, $2, NOW() + make_interval(secs => $3))
+	ON CONFLICT (passenger_id, device_id) DO UPDATE SET revoked_at = NOW(), expires_at = EXCLUDED.expires_at`
+
+	if _, err := tx.Exec(ctx, q1); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, q2, passengerId, deviceId, ttl.Seconds()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// IsRevoked reports whether the device of the passenger has a revocation that did not expire
+func (pr *PresenceRepo) IsRevoked(ctx context.Context, passengerId, deviceId string) (bool, error) {
+	q := `
+	SELECT EXISTS (
+		SELECT 1 FROM passenger_session_revocations
+		WHERE passenger_id = $1 AND device_id = $2 AND expires_at >= NOW()
+	)`
+
+	var revoked bool
+	err := pr.db.conn.QueryRow(ctx, q, passengerId, deviceId).Scan(&revoked)
+	return revoked, err
+}
+
+func scanConnections(rows pgx.Rows) ([]model.PassengerConnection, error) {
+	defer rows.Close()
+
+	var connections []model.PassengerConnection
+	for rows.Next() {
+		var connection model.PassengerConnection
+		if err := rows.Scan(&connection.PassengerId, &connection.InstanceId, &connection.DeviceId, &connection.ConnectedAt); err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+	return connections, rows.Err()
+}
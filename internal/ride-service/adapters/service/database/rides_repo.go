@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"ride-hail/internal/ride-service/core/domain/data"
 	"ride-hail/internal/ride-service/core/domain/model"
 	"ride-hail/internal/ride-service/core/ports"
 
@@ -27,19 +26,6 @@ func NewRidesRepo(db *DB) ports.IRidesRepo {
 	}
 }
 
-func (rr *RidesRepo) GetDistance(ctx context.Context, req data.RidesRequestDto) (float64, error) {
-	q := `SELECT ST_Distance(ST_MakePoint($1, $2)::geography, ST_MakePoint($3, $4)::geography) / 1000 as distance_km`
-
-	db := rr.db.conn
-	row := db.QueryRow(ctx, q, req.PickUpLongitude, req.PickUpLatitude, req.DestinationLongitude, req.DestinationLatitude)
-	distance := 0.0
-	err := row.Scan(&distance)
-	if err != nil {
-		return 0.0, err
-	}
-	return distance, nil
-}
-
 func (rr *RidesRepo) GetNumberRides(ctx context.Context) (int64, error) {
 	q := `
 	SELECT 
@@ -146,7 +132,9 @@ func (rr *RidesRepo) CreateRide(ctx context.Context, m model.Rides) (string, err
 		estimated_fare,
 		final_fare, 
 		pickup_coord_id, 
-		destination_coord_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING ride_id`
+		destination_coord_id,
+		zone_ids,
+		zone_surcharge) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING ride_id`
 
 	row = tx.QueryRow(ctx, q3,
 		m.RideNumber,
@@ -157,6 +145,8 @@ func (rr *RidesRepo) CreateRide(ctx context.Context, m model.Rides) (string, err
 		m.FinalFare,
 		PickupCoordinateId,
 		DestinationCoordinateId,
+		m.ZoneIds,
+		m.ZoneSurcharge,
 	)
 
 	RideId := ""
@@ -201,26 +191,35 @@ func (rr *RidesRepo) ChangeStatusMatch(ctx context.Context, rideID, driverID str
 	return passengerId, rideNumber, tx.Commit(ctx)
 }
 
-func (pr *RidesRepo) FindDistanceAndPassengerId(ctx context.Context, longitude, latitude float64, rideId string) (float64, string, error) {
+func (pr *RidesRepo) FindRideRoute(ctx context.Context, rideId string) (model.RideRoute, error) {
 	q := `SELECT
-			ST_Distance(ST_MakePoint(c.longitude, c.latitude)::geography, ST_MakePoint($1, $2)::geography),
-			r.passenger_id
+			r.passenger_id,
+			r.status,
+			p.latitude,
+			p.longitude,
+			d.latitude,
+			d.longitude
 		FROM rides r 
-		JOIN coordinates c ON r.pickup_coord_id = c.coord_id 
-		WHERE r.ride_id = $3`
+		JOIN coordinates p ON r.pickup_coord_id = p.coord_id 
+		JOIN coordinates d ON r.destination_coord_id = d.coord_id 
+		WHERE r.ride_id = $1`
 
 	conn := pr.db.conn
 
-	row := conn.QueryRow(ctx, q, longitude, latitude, rideId)
-	var (
-		distance    float64
-		passengerId string
-	)
-	if err := row.Scan(&distance, &passengerId); err != nil {
-		return 0.0, "", err
+	row := conn.QueryRow(ctx, q, rideId)
+	var route model.RideRoute
+	if err := row.Scan(
+		&route.PassengerId,
+		&route.Status,
+		&route.Pickup.Lat,
+		&route.Pickup.Lng,
+		&route.Destination.Lat,
+		&route.Destination.Lng,
+	); err != nil {
+		return model.RideRoute{}, err
 	}
 
-	return distance, passengerId, nil
+	return route, nil
 }
 
 func (rr *RidesRepo) CancelRide(ctx context.Context, rideId, reason string) (string, error) {
@@ -238,7 +237,8 @@ func (rr *RidesRepo) CancelRide(ctx context.Context, rideId, reason string) (str
     SET 
         status = 'CANCELLED', 
         cancelled_at = NOW(),
-        cancellation_reason = $2
+        cancellation_reason = $2,
+        cancelled_by = 'PASSENGER'
     WHERE ride_id = $1`
 
 	conn := rr.db.conn
@@ -285,27 +285,55 @@ func (rr *RidesRepo) CancelRide(ctx context.Context, rideId, reason string) (str
 	return driverId.String, nil
 }
 
-// ChangeStatus will return passenger id, ride number and driver information
+// statusPredecessors are the statuses a ride may move from into the key, driver_status is
+// consumed by every replica so a late event must not move a ride backwards
+var statusPredecessors = map[string][]string{
+	"EN_ROUTE":    {"MATCHED"},
+	"ARRIVED":     {"MATCHED", "EN_ROUTE"},
+	"IN_PROGRESS": {"MATCHED", "EN_ROUTE", "ARRIVED"},
+	"COMPLETED":   {"IN_PROGRESS"},
+	"CANCELLED":   {"REQUESTED", "MATCHED", "EN_ROUTE", "ARRIVED", "IN_PROGRESS"},
+}
+
+// ChangeStatus will return passenger id, ride number and driver information,
+// ErrStaleStatus when the ride is already past the status of the event
 func (rr *RidesRepo) ChangeStatus(ctx context.Context, msg messagebrokerdto.DriverStatusUpdate) (string, string, websocketdto.DriverInfo, error) {
 	q1 := `
     SELECT  
         r.passenger_id, 
         r.ride_number,
 		d.username,
-		d.rating,
-		d.vehicle_attrs,
+		COALESCE(d.rating, 5),
+		CASE WHEN v.vehicle_id IS NULL THEN d.vehicle_attrs
+		ELSE jsonb_build_object('make', v.make, 'model', v.model, 'color', v.color, 'plate', v.plate) END
     FROM 
         rides r
 	JOIN drivers d 
 	ON d.driver_id = r.driver_id 
+	-- the vehicle the driver is online with, drivers without one keep the profile vehicle
+	LEFT JOIN LATERAL (
+		SELECT v.*
+		FROM driver_sessions s
+		JOIN vehicles v ON v.vehicle_id = s.vehicle_id
+		WHERE s.driver_id = d.driver_id AND s.ended_at IS NULL
+		ORDER BY s.started_at DESC
+		LIMIT 1
+	) v ON true
     WHERE 
-        ride_id = $1`
+        r.ride_id = $1`
 
+	// driver-location-service may have moved the ride already, the update is idempotent,
+	// otherwise only a forward transition is applied
 	q2 := `
 	UPDATE rides
     SET 
-        status = '$2', 
-    WHERE ride_id = $1`
+        status = $2,
+        arrived_at = CASE WHEN $2 = 'ARRIVED' THEN COALESCE(arrived_at, NOW()) ELSE arrived_at END,
+        cancelled_at = CASE WHEN $2 = 'CANCELLED' THEN COALESCE(cancelled_at, NOW()) ELSE cancelled_at END,
+        cancellation_reason = CASE WHEN $2 = 'CANCELLED' THEN COALESCE(cancellation_reason, NULLIF($3, '')) ELSE cancellation_reason END,
+        cancelled_by = CASE WHEN $2 = 'CANCELLED' THEN COALESCE(cancelled_by, 'DRIVER') ELSE cancelled_by END,
+        updated_at = NOW()
+    WHERE ride_id = $1 AND (status = $2 OR status::text = ANY($4::text[]))`
 
 	conn := rr.db.conn
 
@@ -336,8 +364,10 @@ func (rr *RidesRepo) ChangeStatus(ctx context.Context, msg messagebrokerdto.Driv
 		return "", "", websocketdto.DriverInfo{}, fmt.Errorf("failed to fetch ride details: %w", err)
 	}
 
-	if err := json.Unmarshal(jsonData, &driverInfo.Vehicle); err != nil {
-		return "", "", websocketdto.DriverInfo{}, fmt.Errorf("failed to unmarshal vehile details: %w", err)
+	if len(jsonData) > 0 {
+		if err := json.Unmarshal(jsonData, &driverInfo.Vehicle); err != nil {
+			return "", "", websocketdto.DriverInfo{}, fmt.Errorf("failed to unmarshal vehile details: %w", err)
+		}
 	}
 
 	// Check for values
@@ -350,9 +380,13 @@ func (rr *RidesRepo) ChangeStatus(ctx context.Context, msg messagebrokerdto.Driv
 	}
 
 	// Perform the update
-	if _, err := tx.Exec(ctx, q2, msg.RideId); err != nil {
+	tag, err := tx.Exec(ctx, q2, msg.RideId, msg.Status, msg.Reason, statusPredecessors[msg.Status])
+	if err != nil {
 		return "", "", websocketdto.DriverInfo{}, fmt.Errorf("failed to update status: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return "", "", websocketdto.DriverInfo{}, ErrStaleStatus
+	}
 
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
@@ -361,22 +395,3 @@ func (rr *RidesRepo) ChangeStatus(ctx context.Context, msg messagebrokerdto.Driv
 
 	return passengerId.String, rideNumber.String, driverInfo, nil
 }
-
-func (pr *RidesRepo) CancelEveryPossibleRides(ctx context.Context) error {
-	q := `UPDATE rides SET status = 'CANCELLED' WHERE status IN ('REQUESTED', 'MATCHED', 'EN_ROUTE', 'ARRIVED', 'IN_PROGRESS')`
-	conn := pr.db.conn
-
-	// tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
-	// if err != nil {
-	// 	return err
-	// }
-
-	_, err := conn.Exec(ctx, q)
-	if err != nil {
-		// tx.Rollback(ctx)
-		return err
-	}
-
-	// return tx.Commit(ctx)
-	return nil
-}
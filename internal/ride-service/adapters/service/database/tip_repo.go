@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TipRepo struct {
+	db *DB
+}
+
+func NewTipRepo(db *DB) ports.ITipRepo {
+	return &TipRepo{db: db}
+}
+
+// AddTip adds the tip to the booked earnings of the ride and to the session they belong to
+func (tr *TipRepo) AddTip(ctx context.Context, rideId, passengerId string, amount float64) (model.Tip, error) {
+	q1 := `
+	UPDATE driver_earnings e
+	SET tip = $3::numeric,
+		net = e.net + $3::numeric,
+		tipped_at = NOW()
+	FROM rides r
+	WHERE e.ride_id = $1
+		AND r.ride_id = e.ride_id
+		AND r.passenger_id = $2
+		AND r.status = 'COMPLETED'
+		AND e.tipped_at IS NULL
+	RETURNING e.driver_id, e.driver_session_id, e.tipped_at`
+
+	q2 := `
+	UPDATE driver_sessions
+	SET total_earnings = COALESCE(total_earnings, 0) + $2::numeric
+	WHERE driver_session_id = $1`
+
+	tx, err := tr.db.conn.Begin(ctx)
+	if err != nil {
+		return model.Tip{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	tip := model.Tip{RideId: rideId, Amount: amount}
+	var sessionId *string
+	err = tx.QueryRow(ctx, q1, rideId, passengerId, amount).Scan(&tip.DriverId, &sessionId, &tip.TippedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Tip{}, tr.whyNoTip(ctx, tx, rideId, passengerId)
+	} else if err != nil {
+		return model.Tip{}, err
+	}
+
+	if sessionId != nil {
+		if _, err := tx.Exec(ctx, q2, *sessionId, amount); err != nil {
+			return model.Tip{}, err
+		}
+	}
+	return tip, tx.Commit(ctx)
+}
+
+// whyNoTip tells apart the reasons the tip update matched nothing
+func (tr *TipRepo) whyNoTip(ctx context.Context, tx pgx.Tx, rideId, passengerId string) error {
+	q := `
+	SELECT r.status::text, e.ride_id IS NOT NULL, e.tipped_at
+	FROM rides r
+	LEFT JOIN driver_earnings e ON e.ride_id = r.ride_id
+	WHERE r.ride_id = $1 AND r.passenger_id = $2`
+
+	var (
+		status   string
+		booked   bool
+		tippedAt *time.Time
+	)
+	err := tx.QueryRow(ctx, q, rideId, passengerId).Scan(&status, &booked, &tippedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrRideNotFound
+	case err != nil:
+		return err
+	case status != "COMPLETED":
+		return ErrRideNotCompleted
+	case !booked:
+		return ErrEarningNotBooked
+	case tippedAt != nil:
+		return ErrTipAlreadyGiven
+	}
+	return ErrRideNotFound
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TrackRepo struct {
+	db *DB
+}
+
+func NewTrackRepo(db *DB) ports.ITrackRepo {
+	return &TrackRepo{db: db}
+}
+
+func (tr *TrackRepo) GetRideParticipants(ctx context.Context, rideId string) (model.RideParticipants, error) {
+	q := `SELECT passenger_id, driver_id FROM rides WHERE ride_id = $1`
+
+	var p model.RideParticipants
+	err := tr.db.conn.QueryRow(ctx, q, rideId).Scan(&p.PassengerId, &p.DriverId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.RideParticipants{}, ErrRideNotFound
+	}
+	return p, err
+}
+
+// GetTrackPoints returns the raw points of the ride in time order, from and to are optional
+func (tr *TrackRepo) GetTrackPoints(ctx context.Context, rideId string, from, to *time.Time) ([]model.TrackPoint, error) {
+	q := `
+	SELECT latitude::float, longitude::float, recorded_at
+	FROM location_history
+	WHERE ride_id = $1
+		AND ($2::timestamptz IS NULL OR recorded_at >= $2)
+		AND ($3::timestamptz IS NULL OR recorded_at <= $3)
+	ORDER BY recorded_at`
+
+	rows, err := tr.db.conn.Query(ctx, q, rideId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []model.TrackPoint
+	for rows.Next() {
+		var (
+			p  model.TrackPoint
+			at time.Time
+		)
+		if err := rows.Scan(&p.Latitude, &p.Longitude, &at); err != nil {
+			return nil, err
+		}
+		p.RecordedAt = &at
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+func (tr *TrackRepo) GetStoredTrack(ctx context.Context, rideId string) (model.StoredTrack, error) {
+	q := `
+	SELECT polyline, raw_points_count, distance_km::float, started_at, ended_at
+	FROM ride_tracks
+	WHERE ride_id = $1`
+
+	var t model.StoredTrack
+	err := tr.db.conn.QueryRow(ctx, q, rideId).Scan(&t.Polyline, &t.RawPointsCount, &t.DistanceKm, &t.StartedAt, &t.EndedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.StoredTrack{}, ErrTrackNotFound
+	}
+	return t, err
+}
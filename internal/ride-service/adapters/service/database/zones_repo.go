@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+)
+
+type ZonesRepo struct {
+	db *DB
+}
+
+func NewZonesRepo(db *DB) ports.IZonesRepo {
+	return &ZonesRepo{
+		db: db,
+	}
+}
+
+// FindZonesAt returns every active zone that covers the given point
+func (zr *ZonesRepo) FindZonesAt(ctx context.Context, latitude, longitude float64) ([]model.Zone, error) {
+	q := `
+	SELECT
+		zone_id,
+		name,
+		zone_type,
+		surcharge::float
+	FROM zones
+	WHERE is_active
+		AND ST_Covers(geom, ST_SetSRID(ST_MakePoint($1, $2), 4326))`
+
+	rows, err := zr.db.conn.Query(ctx, q, longitude, latitude)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []model.Zone
+	for rows.Next() {
+		var zone model.Zone
+		if err := rows.Scan(&zone.Id, &zone.Name, &zone.ZoneType, &zone.Surcharge); err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+	return zones, rows.Err()
+}
+
+func (zr *ZonesRepo) HasServiceAreas(ctx context.Context) (bool, error) {
+	q := `SELECT EXISTS(SELECT 1 FROM zones WHERE is_active AND zone_type = 'SERVICE_AREA')`
+
+	exists := false
+	if err := zr.db.conn.QueryRow(ctx, q).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
@@ -3,10 +3,12 @@ package notification
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 
 	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/adapters/service/database"
 	"ride-hail/internal/ride-service/core/ports"
 
 	messagebrokerdto "ride-hail/internal/ride-service/core/domain/message_broker_dto"
@@ -164,7 +166,7 @@ func (n *Notification) LocationUpdate(msg amqp091.Delivery) error {
 		msg.Nack(false, false)
 		return err
 	}
-	passengerId, estimatedTime, distance, err := n.rideService.EstimateDistance(m2.RideID, m2.Location.Lng, m2.Location.Lat, m2.SpeedKmh)
+	passengerId, estimatedTime, distance, err := n.rideService.EstimateDistance(m2.RideID, m2.Location.Lng, m2.Location.Lat)
 	if err != nil {
 		log.Error("cannot estimate distance", err)
 		msg.Nack(false, false)
@@ -207,8 +209,20 @@ func (n *Notification) DriverStatusUpdate(msg amqp091.Delivery) error {
 		msg.Nack(false, false)
 		return err
 	}
+	if driverStatusUpdateMessage.Version > messagebrokerdto.DriverStatusUpdateVersion {
+		err := fmt.Errorf("unsupported driver status version %d", driverStatusUpdateMessage.Version)
+		log.Error("cannot handle driver status", err, "event_id", driverStatusUpdateMessage.EventId)
+		msg.Nack(false, false)
+		return err
+	}
 
 	passengerId, data, err := n.rideService.UpdateRideStatus(driverStatusUpdateMessage)
+	if errors.Is(err, database.ErrStaleStatus) {
+		// a late event, the passenger already got a newer status
+		log.Info("Skipping stale driver status", "event_id", driverStatusUpdateMessage.EventId, "status", driverStatusUpdateMessage.Status)
+		msg.Ack(false)
+		return nil
+	}
 	if err != nil {
 		log.Error("cannot update ride status", err)
 		msg.Nack(false, false)
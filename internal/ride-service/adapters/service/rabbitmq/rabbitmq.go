@@ -19,6 +19,7 @@ import (
 
 const (
 	exchange       = "ride_topic"
+	relayExchange  = "passenger_relay"
 	reconnInterval = 10
 )
 
@@ -94,6 +95,41 @@ func (r *RabbitMQ) ConsumeMessageFromDrivers(ctx context.Context, queue, driverN
 	return r.ch.ConsumeWithContext(ctx, queue, driverName, false, false, false, false, nil)
 }
 
+// PushMessageToInstance sends an event for a passenger to the replica bound with routingKey
+func (r *RabbitMQ) PushMessageToInstance(ctx context.Context, routingKey string, msg messagebrokerdto.PassengerRelay) error {
+	mylog := r.mylog.Action("pushMessage")
+
+	if r.conn.IsClosed() {
+		mylog.Error("connection between rabbitmq is closed", fmt.Errorf("closed conn"))
+		go r.reconnect(r.ctx)
+		return errors.New("connection is closed")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return r.ch.PublishWithContext(ctx, relayExchange, routingKey, false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+	})
+}
+
+// ConsumeInstance declares a queue of this replica only, deleted with the connection and
+// bound to passenger_relay by its own name
+func (r *RabbitMQ) ConsumeInstance(ctx context.Context, queue string) (<-chan amqp.Delivery, error) {
+	if err := r.ch.ExchangeDeclare(relayExchange, "topic", true, false, false, false, nil); err != nil {
+		return nil, fmt.Errorf("declare exchange: %w", err)
+	}
+	if _, err := r.ch.QueueDeclare(queue, false, true, true, false, nil); err != nil {
+		return nil, fmt.Errorf("declare queue: %w", err)
+	}
+	if err := r.ch.QueueBind(queue, queue, relayExchange, false, nil); err != nil {
+		return nil, fmt.Errorf("bind queue: %w", err)
+	}
+	return r.ch.ConsumeWithContext(ctx, queue, "", true, true, false, false, nil)
+}
+
 func (r *RabbitMQ) IsAlive() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -0,0 +1,16 @@
+package data
+
+type PassengerSessionDto struct {
+	DeviceId    string `json:"device_id"`
+	ConnectedAt string `json:"connected_at"`
+}
+
+type PassengerSessionsResponseDto struct {
+	PassengerId string                `json:"passenger_id"`
+	Sessions    []PassengerSessionDto `json:"sessions"`
+}
+
+type RevokeSessionResponseDto struct {
+	DeviceId string `json:"device_id"`
+	Message  string `json:"message"`
+}
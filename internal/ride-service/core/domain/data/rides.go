@@ -20,6 +20,7 @@ type RidesResponseDto struct {
 	EstimatedFare            float64 `json:"estimated_fare"`
 	EstimatedDurationMinutes float64 `json:"estimated_duration_minutes"`
 	EstimatedDistanceKm      float64 `json:"estimated_distance_km"`
+	ZoneSurcharge            float64 `json:"zone_surcharge,omitempty"`
 }
 
 type RideStatusUpdate struct {
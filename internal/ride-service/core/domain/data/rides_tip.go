@@ -0,0 +1,12 @@
+package data
+
+type RideTipRequestDto struct {
+	Amount float64 `json:"amount"`
+}
+
+type RideTipResponseDto struct {
+	RideId   string  `json:"ride_id"`
+	Tip      float64 `json:"tip"`
+	TippedAt string  `json:"tipped_at"`
+	Message  string  `json:"message"`
+}
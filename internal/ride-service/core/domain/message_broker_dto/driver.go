@@ -23,9 +23,16 @@ type RideAcceptance struct {
 	DriverInfo              DriverInfo `json:"driver_info"`
 }
 
+// DriverStatusUpdateVersion is the newest DriverStatusUpdate this service understands
+const DriverStatusUpdateVersion = 1
+
+// Driver Status Update ← driver_topic exchange ← driver.status.{driver_id}
 type DriverStatusUpdate struct {
+	Version   int    `json:"version"` // 0 for events published before versioning
+	EventId   string `json:"event_id"`
 	DriverId  string `json:"driver_id"`
 	Status    string `json:"status"`
 	RideId    string `json:"ride_id"`
+	Reason    string `json:"reason,omitempty"`
 	Timestamp string `json:"timestamp"`
 }
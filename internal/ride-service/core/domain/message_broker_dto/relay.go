@@ -0,0 +1,17 @@
+package messagebrokerdto
+
+import websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
+
+const (
+	RelayEvent  = "event"  // write Event to every device of the passenger
+	RelayRevoke = "revoke" // close the session of DeviceId
+)
+
+// Passenger Relay ← passenger_relay exchange ← passenger.instance.{instance_id}
+type PassengerRelay struct {
+	Kind        string             `json:"kind,omitempty"` // empty is RelayEvent
+	From        string             `json:"from"`
+	PassengerId string             `json:"passenger_id"`
+	DeviceId    string             `json:"device_id,omitempty"`
+	Event       websocketdto.Event `json:"event"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// PassengerConnection is a device of the passenger connected to a replica
+type PassengerConnection struct {
+	PassengerId string
+	InstanceId  string
+	DeviceId    string
+	ConnectedAt time.Time
+}
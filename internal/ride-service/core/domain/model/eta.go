@@ -0,0 +1,27 @@
+package model
+
+import "ride-hail/internal/geo"
+
+const (
+	EtaKindPickup  = "PICKUP"
+	EtaKindDropoff = "DROPOFF"
+
+	EtaSourceProfile = "profile"
+)
+
+// SpeedProfile is the average speed inside one grid cell for one hour of the week
+type SpeedProfile struct {
+	CellLat     int
+	CellLng     int
+	HourOfWeek  int // 0 = Monday 00:00 UTC
+	AvgSpeedKmh float64
+	Samples     int
+}
+
+// RideRoute is what the ETA needs to know about a ride
+type RideRoute struct {
+	PassengerId string
+	Status      string
+	Pickup      geo.Point
+	Destination geo.Point
+}
@@ -21,6 +21,8 @@ type Rides struct {
 	CancellationReason    string
 	EstimatedFare         float64
 	FinalFare             float64
+	ZoneIds               []string // uuid
+	ZoneSurcharge         float64
 	PickupCoordinate      Coordinates
 	DestinationCoordinate Coordinates
 }
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Tip is added to the driver earnings of a completed ride, once
+type Tip struct {
+	RideId   string
+	DriverId string
+	Amount   float64
+	TippedAt time.Time
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	TrackSourceRaw       = "raw"
+	TrackSourceCompacted = "compacted"
+)
+
+type TrackPoint struct {
+	Latitude   float64
+	Longitude  float64
+	RecordedAt *time.Time // unknown for compacted tracks
+}
+
+// Track is the path a ride actually took
+type Track struct {
+	RideId     string
+	Source     string
+	Points     []TrackPoint
+	RawPoints  int
+	DistanceKm float64
+	StartedAt  *time.Time
+	EndedAt    *time.Time
+}
+
+// StoredTrack is the downsampled track kept after the raw points are dropped
+type StoredTrack struct {
+	Polyline       string
+	RawPointsCount int
+	DistanceKm     float64
+	StartedAt      *time.Time
+	EndedAt        *time.Time
+}
+
+type RideParticipants struct {
+	PassengerId string
+	DriverId    *string
+}
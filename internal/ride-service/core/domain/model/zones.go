@@ -0,0 +1,15 @@
+package model
+
+const (
+	ZoneTypeServiceArea = "SERVICE_AREA"
+	ZoneTypeAirport     = "AIRPORT"
+	ZoneTypeRestricted  = "RESTRICTED"
+	ZoneTypeSurcharge   = "SURCHARGE"
+)
+
+type Zone struct {
+	Id        string // uuid
+	Name      string
+	ZoneType  string
+	Surcharge float64
+}
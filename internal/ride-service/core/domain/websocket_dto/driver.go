@@ -21,5 +21,6 @@ type RideStatusUpdateDto struct {
 	RideNumber    string     `json:"ride_number"`
 	Status        string     `json:"status"`
 	DriverInfo    DriverInfo `json:"driver_info"`
+	Reason        string     `json:"reason,omitempty"`
 	CorrelationID string     `json:"correlation_id"`
 }
@@ -19,4 +19,7 @@ type IRidesBroker interface {
 	PushMessageToStatus(ctx context.Context, msg messagebrokerdto.RideStatus) error
 
 	ConsumeMessageFromDrivers(ctx context.Context, queue, driverName string) (<-chan amqp.Delivery, error)
+
+	PushMessageToInstance(ctx context.Context, routingKey string, msg messagebrokerdto.PassengerRelay) error
+	ConsumeInstance(ctx context.Context, queue string) (<-chan amqp.Delivery, error)
 }
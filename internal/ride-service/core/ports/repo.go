@@ -2,8 +2,8 @@ package ports
 
 import (
 	"context"
+	"time"
 
-	"ride-hail/internal/ride-service/core/domain/data"
 	messagebrokerdto "ride-hail/internal/ride-service/core/domain/message_broker_dto"
 	"ride-hail/internal/ride-service/core/domain/model"
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
@@ -21,14 +21,45 @@ type IRidesRepo interface {
 	CreateRide(context.Context, model.Rides) (string, error)
 	CancelRide(context.Context, string, string) (string, error)
 	ChangeStatus(context.Context, messagebrokerdto.DriverStatusUpdate) (string, string, websocketdto.DriverInfo, error)
-	GetDistance(context.Context, data.RidesRequestDto) (float64, error)
 	GetNumberRides(context.Context) (int64, error)
 	ChangeStatusMatch(context.Context, string, string) (string, string, error)
-	FindDistanceAndPassengerId(ctx context.Context, longitude, latitude float64, rideId string) (distance float64, passengerId string, err error)
+	FindRideRoute(ctx context.Context, rideId string) (model.RideRoute, error)
 	CheckDuplicate(ctx context.Context, passengerId string) (count int, err error)
-	CancelEveryPossibleRides(ctx context.Context) error
+}
+
+type IZonesRepo interface {
+	FindZonesAt(ctx context.Context, latitude, longitude float64) ([]model.Zone, error)
+	HasServiceAreas(ctx context.Context) (bool, error)
+}
+
+type IEtaRepo interface {
+	RefreshSpeedProfiles(ctx context.Context, cellSizeDeg float64, minSamples, lookbackDays int) (int64, error)
+	LoadSpeedProfiles(ctx context.Context) ([]model.SpeedProfile, error)
+	SavePrediction(ctx context.Context, rideId, kind string, arrival time.Time, source string) error
 }
 
 type IPassengerRepo interface {
 	Exist(ctx context.Context, passengerId string) (string, error)
 }
+
+type ITipRepo interface {
+	AddTip(ctx context.Context, rideId, passengerId string, amount float64) (model.Tip, error)
+}
+
+type ITrackRepo interface {
+	GetRideParticipants(ctx context.Context, rideId string) (model.RideParticipants, error)
+	GetTrackPoints(ctx context.Context, rideId string, from, to *time.Time) ([]model.TrackPoint, error)
+	GetStoredTrack(ctx context.Context, rideId string) (model.StoredTrack, error)
+}
+
+// IPresenceRepo tells which ride-service replicas hold the WebSockets of a passenger
+type IPresenceRepo interface {
+	Heartbeat(ctx context.Context, instanceId string, sessions []model.PassengerConnection, ttl time.Duration) error
+	Register(ctx context.Context, instanceId, passengerId, deviceId string) error
+	Remove(ctx context.Context, instanceId, passengerId, deviceId string) error
+	GetConnections(ctx context.Context, ttl time.Duration) ([]model.PassengerConnection, error)
+	GetInstances(ctx context.Context, passengerId string, ttl time.Duration) ([]string, error)
+	GetSessions(ctx context.Context, passengerId string, ttl time.Duration) ([]model.PassengerConnection, error)
+	Revoke(ctx context.Context, passengerId, deviceId string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, passengerId, deviceId string) (bool, error)
+}
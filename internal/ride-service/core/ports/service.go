@@ -1,8 +1,12 @@
 package ports
 
 import (
+	"context"
+	"time"
+
 	"ride-hail/internal/ride-service/core/domain/data"
 	messagebrokerdto "ride-hail/internal/ride-service/core/domain/message_broker_dto"
+	"ride-hail/internal/ride-service/core/domain/model"
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 )
 
@@ -13,8 +17,7 @@ type IRidesService interface {
 	// input: rideId, driverId, output: passengerId, rideNumber, error
 	// set to status match, and also send to the exchange
 	SetStatusMatch(string, string) (passengerId string, rideNumber string, err error)
-	EstimateDistance(rideId string, longitude, latitude, speed float64) (passengerId, estimatedTime string, distance float64, err error)
-	CancelEveryPossibleRides() error
+	EstimateDistance(rideId string, longitude, latitude float64) (passengerId, estimatedTime string, distance float64, err error)
 	UpdateRideStatus(messagebrokerdto.DriverStatusUpdate) (string, websocketdto.Event, error)
 }
 
@@ -22,3 +25,16 @@ type IPassengerService interface {
 	IsPassengerExists(passengerId string) (bool, error)
 	// output passengerId
 }
+
+type ITipService interface {
+	AddTip(ctx context.Context, rideId, passengerId string, amount float64) (data.RideTipResponseDto, error)
+}
+
+type ITrackService interface {
+	GetTrack(ctx context.Context, rideId, userId, role string, from, to *time.Time, simplifyMeters float64) (model.Track, error)
+}
+
+type ISessionService interface {
+	ListSessions(ctx context.Context, passengerId, userId string) (data.PassengerSessionsResponseDto, error)
+	RevokeSession(ctx context.Context, passengerId, userId, deviceId string) (data.RevokeSessionResponseDto, error)
+}
@@ -4,4 +4,6 @@ import websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 
 type INotifyWebsocket interface {
 	WriteToUser(passengerId string, msg websocketdto.Event)
+	// RevokeSession closes the session of the device wherever it is connected
+	RevokeSession(passengerId, deviceId string)
 }
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/routing"
+)
+
+type speedCell struct {
+	lat, lng   int
+	hourOfWeek int
+}
+
+// EtaService predicts arrival times by walking the road route through
+// historical per cell, per hour of week speeds
+type EtaService struct {
+	mylog   logger.Logger
+	cfg     *config.Etaconfig
+	EtaRepo ports.IEtaRepo
+	Router  routing.Router
+
+	refreshRepo ports.IEtaRepo // on a connection of its own, the rebuild takes a while
+
+	mu      sync.RWMutex
+	profile map[speedCell]float64
+}
+
+func NewEtaService(log logger.Logger, cfg *config.Etaconfig, EtaRepo, refreshRepo ports.IEtaRepo, Router routing.Router) *EtaService {
+	return &EtaService{
+		mylog:       log,
+		cfg:         cfg,
+		EtaRepo:     EtaRepo,
+		Router:      Router,
+		refreshRepo: refreshRepo,
+		profile:     make(map[speedCell]float64),
+	}
+}
+
+// Run refreshes the speed profile until ctx is done
+func (es *EtaService) Run(ctx context.Context) {
+	log := es.mylog.Action("EtaRefresh")
+
+	interval := time.Duration(es.cfg.RefreshIntervalSec) * time.Second
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := es.Refresh(ctx); err != nil {
+			log.Error("cannot refresh speed profiles", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh rebuilds the speed profile table and reloads it into memory
+func (es *EtaService) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	n, err := es.refreshRepo.RefreshSpeedProfiles(ctx, es.cfg.CellSizeDeg, es.cfg.MinSamples, es.cfg.LookbackDays)
+	if err != nil {
+		return err
+	}
+	profiles, err := es.refreshRepo.LoadSpeedProfiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	profile := make(map[speedCell]float64, len(profiles))
+	for _, p := range profiles {
+		profile[speedCell{lat: p.CellLat, lng: p.CellLng, hourOfWeek: p.HourOfWeek}] = p.AvgSpeedKmh
+	}
+
+	es.mu.Lock()
+	es.profile = profile
+	es.mu.Unlock()
+
+	es.mylog.Action("EtaRefresh").Info("speed profiles refreshed", "cells", n)
+	return nil
+}
+
+// Predict returns the arrival time from `from` to `to` when leaving at `at`,
+// the road distance in km and the source of the estimate
+func (es *EtaService) Predict(ctx context.Context, from, to geo.Point, at time.Time) (time.Time, float64, string, error) {
+	route, err := es.Router.Route(ctx, from, to)
+	if err != nil {
+		return time.Time{}, 0, "", err
+	}
+
+	points, err := geo.DecodePolyline(route.Polyline)
+	if err != nil || len(points) < 2 || route.DistanceKm <= 0 {
+		return at.Add(minutes(route.DurationMinutes)), route.DistanceKm, route.Source, nil
+	}
+
+	// the router speed is used for cells without history
+	fallbackSpeed := route.DistanceKm / route.DurationMinutes * 60
+	if route.DurationMinutes <= 0 || math.IsInf(fallbackSpeed, 0) {
+		fallbackSpeed = 30
+	}
+
+	// simplified polylines are shorter than the road, scale segments up to the route distance
+	polylineKm := 0.0
+	for i := 1; i < len(points); i++ {
+		polylineKm += geo.HaversineKm(points[i-1], points[i])
+	}
+	scale := 1.0
+	if polylineKm > 0 {
+		scale = route.DistanceKm / polylineKm
+	}
+
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	source := route.Source
+	arrival := at
+	for i := 1; i < len(points); i++ {
+		segmentKm := geo.HaversineKm(points[i-1], points[i]) * scale
+		mid := geo.Point{
+			Lat: (points[i-1].Lat + points[i].Lat) / 2,
+			Lng: (points[i-1].Lng + points[i].Lng) / 2,
+		}
+
+		speed, ok := es.profile[es.cell(mid, arrival)]
+		if ok {
+			source = model.EtaSourceProfile
+		} else {
+			speed = fallbackSpeed
+		}
+		arrival = arrival.Add(minutes(segmentKm / speed * 60))
+	}
+
+	return arrival, route.DistanceKm, source, nil
+}
+
+func (es *EtaService) cell(p geo.Point, at time.Time) speedCell {
+	at = at.UTC()
+	return speedCell{
+		lat:        int(math.Floor(p.Lat / es.cfg.CellSizeDeg)),
+		lng:        int(math.Floor(p.Lng / es.cfg.CellSizeDeg)),
+		hourOfWeek: (int(at.Weekday())+6)%7*24 + at.Hour(),
+	}
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
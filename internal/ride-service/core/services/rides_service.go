@@ -10,18 +10,18 @@ import (
 	"strings"
 	"time"
 
+	"ride-hail/internal/geo"
 	"ride-hail/internal/logger"
 	"ride-hail/internal/ride-service/core/domain/data"
 	"ride-hail/internal/ride-service/core/domain/model"
 	websocketdto "ride-hail/internal/ride-service/core/domain/websocket_dto"
 	"ride-hail/internal/ride-service/core/ports"
+	"ride-hail/internal/routing"
 
 	messagebrokerdto "ride-hail/internal/ride-service/core/domain/message_broker_dto"
 )
 
 const (
-	DEFUALT_RATE_PER_MIN = 40
-
 	ECONOMY = "ECONOMY"
 	PREMIUM = "PREMIUM"
 	XL      = "XL"
@@ -42,23 +42,35 @@ const (
 type RidesService struct {
 	mylog          logger.Logger
 	RidesRepo      ports.IRidesRepo
+	ZonesRepo      ports.IZonesRepo
 	RidesBroker    ports.IRidesBroker
 	RidesWebsocket ports.INotifyWebsocket
+	Router         routing.Router
+	Eta            *EtaService
+	EtaRepo        ports.IEtaRepo
 	ctx            context.Context
 }
 
 func NewRidesService(ctx context.Context,
 	log logger.Logger,
 	RidesRepo ports.IRidesRepo,
+	ZonesRepo ports.IZonesRepo,
 	RidesBroker ports.IRidesBroker,
 	RidesWebsocket ports.INotifyWebsocket,
+	Router routing.Router,
+	Eta *EtaService,
+	EtaRepo ports.IEtaRepo,
 ) ports.IRidesService {
 	return &RidesService{
 		ctx:            ctx,
 		mylog:          log,
 		RidesRepo:      RidesRepo,
+		ZonesRepo:      ZonesRepo,
 		RidesBroker:    RidesBroker,
 		RidesWebsocket: RidesWebsocket,
+		Router:         Router,
+		Eta:            Eta,
+		EtaRepo:        EtaRepo,
 	}
 }
 
@@ -73,12 +85,24 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 
 	ctx, cancel := context.WithTimeout(rs.ctx, time.Second*15)
 	defer cancel()
-	// estimate distance between pick up and destination points
-	distance, err := rs.RidesRepo.GetDistance(ctx, req)
+
+	// service areas, restricted zones and surcharges
+	zones, err := rs.resolveZones(ctx, *req.PickUpLatitude, *req.PickUpLongitude, *req.DestinationLatitude, *req.DestinationLongitude)
+	if err != nil {
+		log.Warn("ride rejected by zones", "err", err)
+		return data.RidesResponseDto{}, err
+	}
+
+	// road distance and duration between pick up and destination points
+	route, err := rs.Router.Route(ctx,
+		geo.Point{Lat: *req.PickUpLatitude, Lng: *req.PickUpLongitude},
+		geo.Point{Lat: *req.DestinationLatitude, Lng: *req.DestinationLongitude},
+	)
 	if err != nil {
-		log.Error("cannot get distance between two points", err)
+		log.Error("cannot get route between two points", err)
 		return data.RidesResponseDto{}, err
 	}
+	distance, duration := route.DistanceKm, route.DurationMinutes
 
 	// only for ride-number
 	numberOfRides, err := rs.RidesRepo.GetNumberRides(ctx)
@@ -96,15 +120,16 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 
 	switch *req.RideType {
 	case ECONOMY:
-		EstimatedFare = ECONOMY_BASE + (distance * ECONOMY_RATE_PER_KM) + (DEFUALT_RATE_PER_MIN * ECONOMY_RATE_PER_MIN)
+		EstimatedFare = ECONOMY_BASE + (distance * ECONOMY_RATE_PER_KM) + (duration * ECONOMY_RATE_PER_MIN)
 	case PREMIUM:
-		EstimatedFare = PREMIUM_BASE + (distance * PREMIUM_RATE_PER_KM) + (DEFUALT_RATE_PER_MIN * PREMIUM_RATE_PER_MIN)
+		EstimatedFare = PREMIUM_BASE + (distance * PREMIUM_RATE_PER_KM) + (duration * PREMIUM_RATE_PER_MIN)
 	case XL:
-		EstimatedFare = XL_BASE + (distance * XL_RATE_PER_KM) + (DEFUALT_RATE_PER_MIN * XL_RATE_PER_MIN)
+		EstimatedFare = XL_BASE + (distance * XL_RATE_PER_KM) + (duration * XL_RATE_PER_MIN)
 	default:
 		log.Warn("unkown ride type", "type", req.RideType)
 		return data.RidesResponseDto{}, fmt.Errorf("unkown ride type")
 	}
+	EstimatedFare += zones.Surcharge
 
 	// PRIORITY estimate
 	if EstimatedFare >= 10000 {
@@ -123,6 +148,8 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 		EstimatedFare: EstimatedFare,
 		FinalFare:     EstimatedFare,
 		Priority:      Priority,
+		ZoneIds:       zones.ZoneIds,
+		ZoneSurcharge: zones.Surcharge,
 	}
 
 	m.PickupCoordinate = model.Coordinates{
@@ -133,7 +160,7 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 		Longitude:       *req.PickUpLongitude,
 		FareAmount:      m.EstimatedFare,
 		DistanceKm:      distance,
-		DurationMinutes: 0,
+		DurationMinutes: duration,
 		IsCurrent:       true,
 	}
 	m.DestinationCoordinate = model.Coordinates{
@@ -144,10 +171,10 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 		Longitude:       *req.DestinationLongitude,
 		FareAmount:      m.EstimatedFare,
 		DistanceKm:      distance,
-		DurationMinutes: 0,
+		DurationMinutes: duration,
 		IsCurrent:       true,
 	}
-	log.Info("creating a ride", "RideNumber", RideNumber, "passenger-id", req.PassengerId, "estimated-fare", EstimatedFare, "distance", distance)
+	log.Info("creating a ride", "RideNumber", RideNumber, "passenger-id", req.PassengerId, "estimated-fare", EstimatedFare, "distance", distance, "duration", duration, "route-source", route.Source)
 	ctx, cancel = context.WithTimeout(rs.ctx, time.Second*15)
 	defer cancel()
 	ride_id, err := rs.RidesRepo.CreateRide(ctx, m)
@@ -195,7 +222,8 @@ func (rs *RidesService) CreateRide(req data.RidesRequestDto) (data.RidesResponse
 		Status:                   "REQUESTED",
 		EstimatedFare:            EstimatedFare,
 		EstimatedDistanceKm:      distance,
-		EstimatedDurationMinutes: distance * 1000 / DEFUALT_RATE_PER_MIN,
+		EstimatedDurationMinutes: duration,
+		ZoneSurcharge:            zones.Surcharge,
 	}
 	return res, nil
 }
@@ -373,38 +401,36 @@ func (rs *RidesService) SetStatusMatch(rideId, driverId string) (string, string,
 	return passengerId, rideNumber, nil
 }
 
-func (rs *RidesService) EstimateDistance(rideId string, longitude, latitude, speed float64) (string, string, float64, error) {
+// EstimateDistance predicts the pickup ETA while the driver is on the way and the dropoff ETA once the ride started
+func (rs *RidesService) EstimateDistance(rideId string, longitude, latitude float64) (string, string, float64, error) {
 	log := rs.mylog.Action("FindPassenger")
 
 	ctx, cancel := context.WithTimeout(rs.ctx, time.Second*5)
 	defer cancel()
 
-	distance, passengerId, err := rs.RidesRepo.FindDistanceAndPassengerId(ctx, longitude, latitude, rideId)
+	ride, err := rs.RidesRepo.FindRideRoute(ctx, rideId)
 	if err != nil {
 		log.Error("cannot get user or something", err)
 		return "", "", 0.0, err
 	}
-	if IsCloseToZero(speed) {
-		speed = DEFUALT_RATE_PER_MIN
-	}
-
-	t := time.Now().Add(time.Duration(distance / speed)).Format(time.RFC3339)
-
-	return passengerId, t, distance, nil
-}
 
-func (rs *RidesService) CancelEveryPossibleRides() error {
-	log := rs.mylog.Action("CancelEveryPossibleRides")
-	ctx, cancel := context.WithTimeout(rs.ctx, time.Second*5)
-	defer cancel()
+	kind, target := model.EtaKindPickup, ride.Pickup
+	if ride.Status == "IN_PROGRESS" {
+		kind, target = model.EtaKindDropoff, ride.Destination
+	}
 
-	err := rs.RidesRepo.CancelEveryPossibleRides(ctx)
+	arrival, distance, source, err := rs.Eta.Predict(ctx, geo.Point{Lat: latitude, Lng: longitude}, target, time.Now())
 	if err != nil {
-		log.Error("cannot cancel every rides", err)
-		return err
+		log.Error("cannot predict arrival", err)
+		return "", "", 0.0, err
 	}
-	log.Info("canceled every possible rides")
-	return nil
+
+	// only the first prediction of each leg is kept for accuracy metrics
+	if err := rs.EtaRepo.SavePrediction(ctx, rideId, kind, arrival, source); err != nil {
+		log.Warn("cannot save eta prediction", "ride_id", rideId, "err", err)
+	}
+
+	return ride.PassengerId, arrival.Format(time.RFC3339), distance, nil
 }
 
 // Generate a new UUID as a correlation ID
@@ -458,7 +484,7 @@ func (ps *RidesService) UpdateRideStatus(msg messagebrokerdto.DriverStatusUpdate
 
 	passengerId, rideNumber, driverInfo, err := ps.RidesRepo.ChangeStatus(ctx, msg)
 	if err != nil {
-		log.Error("Failed to cancel ride", err)
+		log.Error("Failed to change ride status", err)
 		return "", websocketdto.Event{}, err
 	}
 
@@ -468,6 +494,7 @@ func (ps *RidesService) UpdateRideStatus(msg messagebrokerdto.DriverStatusUpdate
 		CorrelationID: generateCorrelationID(),
 		DriverInfo:    driverInfo,
 		RideNumber:    rideNumber,
+		Reason:        msg.Reason,
 	}
 
 	jsonData, err := json.Marshal(data)
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/domain/data"
+	"ride-hail/internal/ride-service/core/ports"
+)
+
+var (
+	ErrSessionForbidden = errors.New("only the passenger can manage their sessions")
+	ErrSessionNotFound  = errors.New("session not found")
+)
+
+type SessionService struct {
+	mylog          logger.Logger
+	cfg            *config.Clusterconfig
+	PresenceRepo   ports.IPresenceRepo
+	RidesWebsocket ports.INotifyWebsocket
+}
+
+func NewSessionService(log logger.Logger, cfg *config.Clusterconfig, PresenceRepo ports.IPresenceRepo, RidesWebsocket ports.INotifyWebsocket) *SessionService {
+	return &SessionService{
+		mylog:          log,
+		cfg:            cfg,
+		PresenceRepo:   PresenceRepo,
+		RidesWebsocket: RidesWebsocket,
+	}
+}
+
+// ListSessions returns the devices the passenger is connected from on any replica
+func (ss *SessionService) ListSessions(ctx context.Context, passengerId, userId string) (data.PassengerSessionsResponseDto, error) {
+	if passengerId != userId {
+		return data.PassengerSessionsResponseDto{}, ErrSessionForbidden
+	}
+
+	connections, err := ss.PresenceRepo.GetSessions(ctx, passengerId, ss.ttl())
+	if err != nil {
+		return data.PassengerSessionsResponseDto{}, err
+	}
+
+	res := data.PassengerSessionsResponseDto{
+		PassengerId: passengerId,
+		Sessions:    make([]data.PassengerSessionDto, 0, len(connections)),
+	}
+	for _, connection := range connections {
+		res.Sessions = append(res.Sessions, data.PassengerSessionDto{
+			DeviceId:    connection.DeviceId,
+			ConnectedAt: connection.ConnectedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return res, nil
+}
+
+// RevokeSession closes the session of the device and refuses it for RevokeTTLSec, the other
+// devices stay connected
+func (ss *SessionService) RevokeSession(ctx context.Context, passengerId, userId, deviceId string) (data.RevokeSessionResponseDto, error) {
+	if passengerId != userId {
+		return data.RevokeSessionResponseDto{}, ErrSessionForbidden
+	}
+
+	connections, err := ss.PresenceRepo.GetSessions(ctx, passengerId, ss.ttl())
+	if err != nil {
+		return data.RevokeSessionResponseDto{}, err
+	}
+	found := false
+	for _, connection := range connections {
+		if connection.DeviceId == deviceId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return data.RevokeSessionResponseDto{}, ErrSessionNotFound
+	}
+
+	// stored first, the device must not get back in with the same token once its socket is closed
+	if err := ss.PresenceRepo.Revoke(ctx, passengerId, deviceId, time.Duration(ss.cfg.RevokeTTLSec)*time.Second); err != nil {
+		return data.RevokeSessionResponseDto{}, err
+	}
+	ss.RidesWebsocket.RevokeSession(passengerId, deviceId)
+	return data.RevokeSessionResponseDto{
+		DeviceId: deviceId,
+		Message:  "session revoked",
+	}, nil
+}
+
+func (ss *SessionService) ttl() time.Duration {
+	return time.Duration(ss.cfg.TTLSec) * time.Second
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/domain/data"
+	"ride-hail/internal/ride-service/core/ports"
+)
+
+var ErrInvalidTip = errors.New("invalid tip")
+
+type TipService struct {
+	mylog   logger.Logger
+	cfg     *config.Earningsconfig
+	TipRepo ports.ITipRepo
+}
+
+func NewTipService(log logger.Logger, cfg *config.Earningsconfig, TipRepo ports.ITipRepo) *TipService {
+	return &TipService{
+		mylog:   log,
+		cfg:     cfg,
+		TipRepo: TipRepo,
+	}
+}
+
+// AddTip lets the passenger tip the driver of a completed ride once, the whole tip goes to the driver
+func (ts *TipService) AddTip(ctx context.Context, rideId, passengerId string, amount float64) (data.RideTipResponseDto, error) {
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 || amount > ts.cfg.MaxTip {
+		return data.RideTipResponseDto{}, fmt.Errorf("%w: tip must be in (0, %.2f]", ErrInvalidTip, ts.cfg.MaxTip)
+	}
+
+	tip, err := ts.TipRepo.AddTip(ctx, rideId, passengerId, amount)
+	if err != nil {
+		return data.RideTipResponseDto{}, err
+	}
+	ts.mylog.Action("AddTip").Info("Ride tipped", "ride_id", rideId, "driver_id", tip.DriverId, "amount", tip.Amount)
+
+	return data.RideTipResponseDto{
+		RideId:   tip.RideId,
+		Tip:      tip.Amount,
+		TippedAt: tip.TippedAt.Format(time.RFC3339),
+		Message:  "Thank you, the tip goes to your driver",
+	}, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+	"ride-hail/internal/ride-service/core/domain/model"
+	"ride-hail/internal/ride-service/core/ports"
+)
+
+var ErrTrackForbidden = errors.New("only the passenger, the driver of the ride and admins can see its track")
+
+type TrackService struct {
+	mylog     logger.Logger
+	TrackRepo ports.ITrackRepo
+}
+
+func NewTrackService(log logger.Logger, TrackRepo ports.ITrackRepo) *TrackService {
+	return &TrackService{
+		mylog:     log,
+		TrackRepo: TrackRepo,
+	}
+}
+
+// GetTrack returns the path of the ride from location_history, or the compacted track once
+// the raw points are gone. simplifyMeters > 0 applies Douglas-Peucker to the points.
+func (ts *TrackService) GetTrack(ctx context.Context, rideId, userId, role string, from, to *time.Time, simplifyMeters float64) (model.Track, error) {
+	participants, err := ts.TrackRepo.GetRideParticipants(ctx, rideId)
+	if err != nil {
+		return model.Track{}, err
+	}
+	if !canSeeTrack(participants, userId, role) {
+		return model.Track{}, ErrTrackForbidden
+	}
+
+	track := model.Track{RideId: rideId, Source: model.TrackSourceRaw}
+	points, err := ts.TrackRepo.GetTrackPoints(ctx, rideId, from, to)
+	if err != nil {
+		return model.Track{}, err
+	}
+
+	if len(points) == 0 && from == nil && to == nil {
+		stored, err := ts.TrackRepo.GetStoredTrack(ctx, rideId)
+		if err != nil {
+			return model.Track{}, err
+		}
+		decoded, err := geo.DecodePolyline(stored.Polyline)
+		if err != nil {
+			return model.Track{}, err
+		}
+		track.Source = model.TrackSourceCompacted
+		track.RawPoints = stored.RawPointsCount
+		track.DistanceKm = stored.DistanceKm
+		track.StartedAt = stored.StartedAt
+		track.EndedAt = stored.EndedAt
+		for _, p := range decoded {
+			track.Points = append(track.Points, model.TrackPoint{Latitude: p.Lat, Longitude: p.Lng})
+		}
+	} else {
+		track.RawPoints = len(points)
+		track.Points = points
+		for i := 1; i < len(points); i++ {
+			track.DistanceKm += geo.HaversineKm(
+				geo.Point{Lat: points[i-1].Latitude, Lng: points[i-1].Longitude},
+				geo.Point{Lat: points[i].Latitude, Lng: points[i].Longitude},
+			)
+		}
+		if len(points) > 0 {
+			track.StartedAt = points[0].RecordedAt
+			track.EndedAt = points[len(points)-1].RecordedAt
+		}
+	}
+
+	if simplifyMeters > 0 && len(track.Points) > 2 {
+		path := make([]geo.Point, len(track.Points))
+		for i, p := range track.Points {
+			path[i] = geo.Point{Lat: p.Latitude, Lng: p.Longitude}
+		}
+		keep := geo.SimplifyIndices(path, simplifyMeters)
+		simplified := make([]model.TrackPoint, len(keep))
+		for i, idx := range keep {
+			simplified[i] = track.Points[idx]
+		}
+		track.Points = simplified
+	}
+	return track, nil
+}
+
+func canSeeTrack(p model.RideParticipants, userId, role string) bool {
+	switch role {
+	case "ADMIN":
+		return true
+	case "PASSENGER":
+		return p.PassengerId == userId
+	case "DRIVER":
+		return p.DriverId != nil && *p.DriverId == userId
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ride-hail/internal/ride-service/core/domain/model"
+)
+
+var (
+	ErrOutsideServiceArea = errors.New("pickup location is outside of the service area")
+	ErrRestrictedZone     = errors.New("location is inside a restricted zone")
+)
+
+// zoneResult is what CreateRide needs to know about the zones of a ride
+type zoneResult struct {
+	ZoneIds   []string
+	Surcharge float64
+}
+
+// resolveZones checks the pickup against service areas, rejects restricted pickups and destinations
+// and sums up airport and surcharge fees. A zone covering both points is only charged once.
+// When no service area is configured at all, pickups are accepted anywhere.
+func (rs *RidesService) resolveZones(ctx context.Context, pickupLat, pickupLng, destinationLat, destinationLng float64) (zoneResult, error) {
+	res := zoneResult{ZoneIds: []string{}}
+
+	pickupZones, err := rs.ZonesRepo.FindZonesAt(ctx, pickupLat, pickupLng)
+	if err != nil {
+		return res, fmt.Errorf("cannot find pickup zones: %w", err)
+	}
+	destinationZones, err := rs.ZonesRepo.FindZonesAt(ctx, destinationLat, destinationLng)
+	if err != nil {
+		return res, fmt.Errorf("cannot find destination zones: %w", err)
+	}
+
+	inServiceArea := false
+	for _, zone := range pickupZones {
+		switch zone.ZoneType {
+		case model.ZoneTypeServiceArea:
+			inServiceArea = true
+		case model.ZoneTypeRestricted:
+			return res, fmt.Errorf("%w: pickup is in %s", ErrRestrictedZone, zone.Name)
+		}
+	}
+	for _, zone := range destinationZones {
+		if zone.ZoneType == model.ZoneTypeRestricted {
+			return res, fmt.Errorf("%w: destination is in %s", ErrRestrictedZone, zone.Name)
+		}
+	}
+
+	if !inServiceArea {
+		hasServiceAreas, err := rs.ZonesRepo.HasServiceAreas(ctx)
+		if err != nil {
+			return res, fmt.Errorf("cannot check service areas: %w", err)
+		}
+		if hasServiceAreas {
+			return res, ErrOutsideServiceArea
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, zone := range append(pickupZones, destinationZones...) {
+		if seen[zone.Id] {
+			continue
+		}
+		seen[zone.Id] = true
+		res.ZoneIds = append(res.ZoneIds, zone.Id)
+
+		if zone.ZoneType == model.ZoneTypeAirport || zone.ZoneType == model.ZoneTypeSurcharge {
+			res.Surcharge += zone.Surcharge
+		}
+	}
+	return res, nil
+}
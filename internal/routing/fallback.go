@@ -0,0 +1,37 @@
+package routing
+
+import (
+	"context"
+
+	"ride-hail/internal/geo"
+)
+
+// FallbackRouter estimates road distance as the haversine distance times a detour factor
+// and the duration from an average city speed
+type FallbackRouter struct {
+	detourFactor float64
+	avgSpeedKmh  float64
+}
+
+func NewFallbackRouter(detourFactor, avgSpeedKmh float64) *FallbackRouter {
+	if detourFactor < 1 {
+		detourFactor = 1
+	}
+	if avgSpeedKmh <= 0 {
+		avgSpeedKmh = 30
+	}
+	return &FallbackRouter{
+		detourFactor: detourFactor,
+		avgSpeedKmh:  avgSpeedKmh,
+	}
+}
+
+func (fr *FallbackRouter) Route(ctx context.Context, from, to geo.Point) (Route, error) {
+	distance := geo.HaversineKm(from, to) * fr.detourFactor
+	return Route{
+		DistanceKm:      distance,
+		DurationMinutes: distance / fr.avgSpeedKmh * 60,
+		Polyline:        geo.EncodePolyline([]geo.Point{from, to}),
+		Source:          SourceFallback,
+	}, nil
+}
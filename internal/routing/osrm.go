@@ -0,0 +1,71 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ride-hail/internal/geo"
+)
+
+// OSRMRouter talks to an OSRM compatible /route/v1 HTTP API
+type OSRMRouter struct {
+	baseURL string
+	profile string
+	client  *http.Client
+}
+
+type osrmResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Routes  []struct {
+		Distance float64 `json:"distance"` // meters
+		Duration float64 `json:"duration"` // seconds
+		Geometry string  `json:"geometry"`
+	} `json:"routes"`
+}
+
+func NewOSRMRouter(baseURL, profile string, timeoutMs int) *OSRMRouter {
+	if profile == "" {
+		profile = "driving"
+	}
+	return &OSRMRouter{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		profile: profile,
+		client:  &http.Client{Timeout: time.Duration(timeoutMs) * time.Millisecond},
+	}
+}
+
+func (or *OSRMRouter) Route(ctx context.Context, from, to geo.Point) (Route, error) {
+	url := fmt.Sprintf("%s/route/v1/%s/%f,%f;%f,%f?overview=simplified&geometries=polyline",
+		or.baseURL, or.profile, from.Lng, from.Lat, to.Lng, to.Lat)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Route{}, fmt.Errorf("osrm request: %w", err)
+	}
+	resp, err := or.client.Do(req)
+	if err != nil {
+		return Route{}, fmt.Errorf("osrm request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body osrmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Route{}, fmt.Errorf("osrm decode: %w", err)
+	}
+	if body.Code != "Ok" || len(body.Routes) == 0 {
+		return Route{}, fmt.Errorf("osrm: %s %s", body.Code, body.Message)
+	}
+
+	route := body.Routes[0]
+	return Route{
+		DistanceKm:      route.Distance / 1000,
+		DurationMinutes: route.Duration / 60,
+		Polyline:        route.Geometry,
+		Source:          SourceOSRM,
+	}, nil
+}
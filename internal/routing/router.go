@@ -0,0 +1,56 @@
+package routing
+
+import (
+	"context"
+
+	"ride-hail/internal/config"
+	"ride-hail/internal/geo"
+	"ride-hail/internal/logger"
+)
+
+const (
+	SourceOSRM     = "osrm"
+	SourceFallback = "fallback"
+)
+
+// Route is a road route between two points
+type Route struct {
+	DistanceKm      float64 `json:"distance_km"`
+	DurationMinutes float64 `json:"duration_minutes"`
+	Polyline        string  `json:"polyline"` // Google encoded polyline
+	Source          string  `json:"source"`
+}
+
+// Router returns road distance, duration and geometry between two points
+type Router interface {
+	Route(ctx context.Context, from, to geo.Point) (Route, error)
+}
+
+// New builds the router from config. Without an OSRM url the built-in fallback is used,
+// otherwise OSRM is asked first and the fallback answers when it fails.
+func New(cfg *config.Routingconfig, log logger.Logger) Router {
+	fallback := NewFallbackRouter(cfg.DetourFactor, cfg.AvgSpeedKmh)
+	if cfg.OSRMURL == "" {
+		return fallback
+	}
+	return &failoverRouter{
+		primary:  NewOSRMRouter(cfg.OSRMURL, cfg.Profile, cfg.TimeoutMs),
+		fallback: fallback,
+		log:      log.Action("routing"),
+	}
+}
+
+type failoverRouter struct {
+	primary  Router
+	fallback Router
+	log      logger.Logger
+}
+
+func (f *failoverRouter) Route(ctx context.Context, from, to geo.Point) (Route, error) {
+	route, err := f.primary.Route(ctx, from, to)
+	if err == nil {
+		return route, nil
+	}
+	f.log.Warn("primary router failed, using fallback", "err", err)
+	return f.fallback.Route(ctx, from, to)
+}
@@ -0,0 +1,40 @@
+package scoring
+
+import "ride-hail/internal/config"
+
+// SearchRadiusKm is the driver search radius, drivers at the edge get no proximity points
+const SearchRadiusKm = 5.0
+
+// Components are the driver quality inputs, rates are in [0, 1] and rating in [1, 5]
+type Components struct {
+	AcceptanceRate   float64 `json:"acceptance_rate"`
+	CancellationRate float64 `json:"cancellation_rate"`
+	CompletionRate   float64 `json:"completion_rate"`
+	Rating           float64 `json:"rating"`
+}
+
+// Defaults for drivers without history
+var Defaults = Components{AcceptanceRate: 1, CancellationRate: 0, CompletionRate: 1, Rating: 5}
+
+// Quality is the weighted driver quality in [0, 1], distance does not take part
+func Quality(w config.ScoreWeights, c Components) float64 {
+	total := w.Rating + w.Acceptance + w.Completion + w.Cancellation
+	if total == 0 {
+		return 0
+	}
+	sum := w.Rating*(c.Rating-1)/4 +
+		w.Acceptance*c.AcceptanceRate +
+		w.Completion*c.CompletionRate +
+		w.Cancellation*(1-c.CancellationRate)
+	return sum / total
+}
+
+// Rank combines proximity to the pickup and quality into a matching rank in [0, 1], higher is better
+func Rank(w config.ScoreWeights, c Components, distanceKm float64) float64 {
+	total := w.Distance + w.Rating + w.Acceptance + w.Completion + w.Cancellation
+	if total == 0 {
+		return 0
+	}
+	proximity := max(0, 1-distanceKm/SearchRadiusKm)
+	return (w.Distance*proximity + Quality(w, c)*(total-w.Distance)) / total
+}
@@ -0,0 +1,73 @@
+package wscodec
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	JSONv1    = "ridehail.json.v1"
+	MsgpackV1 = "ridehail.msgpack.v1"
+)
+
+// Subprotocols are offered to clients in order of preference. A client asking for none of
+// them gets JSON.
+var Subprotocols = []string{MsgpackV1, JSONv1}
+
+// Codec is the wire format of a WebSocket connection. Messages are built and read as JSON
+// inside the services, the codec only changes how they travel to and from the client.
+type Codec interface {
+	Subprotocol() string
+	FrameType() int // websocket.TextMessage or websocket.BinaryMessage
+
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+
+	// FromJSON encodes a message already marshaled to JSON
+	FromJSON(data []byte) ([]byte, error)
+	// ToJSON decodes a frame from the client into JSON
+	ToJSON(data []byte) ([]byte, error)
+}
+
+// ForSubprotocol returns the codec negotiated on the connection, JSON when nothing was
+func ForSubprotocol(subprotocol string) Codec {
+	if subprotocol == MsgpackV1 {
+		return msgpackCodec{}
+	}
+	return jsonCodec{}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string { return JSONv1 }
+func (jsonCodec) FrameType() int      { return websocket.TextMessage }
+
+func (jsonCodec) Marshal(v any) ([]byte, error)        { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error   { return json.Unmarshal(data, v) }
+func (jsonCodec) FromJSON(data []byte) ([]byte, error) { return data, nil }
+func (jsonCodec) ToJSON(data []byte) ([]byte, error)   { return data, nil }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Subprotocol() string { return MsgpackV1 }
+func (msgpackCodec) FrameType() int      { return websocket.BinaryMessage }
+
+func (c msgpackCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.FromJSON(data)
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v any) error {
+	data, err := c.ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (msgpackCodec) FromJSON(data []byte) ([]byte, error) { return jsonToMsgpack(data) }
+func (msgpackCodec) ToJSON(data []byte) ([]byte, error)   { return msgpackToJSON(data) }
@@ -0,0 +1,395 @@
+package wscodec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// maxDepth bounds the nesting of arrays and maps a client can send
+const maxDepth = 32
+
+var (
+	ErrInvalidMsgpack = errors.New("invalid msgpack")
+	ErrTooDeep        = errors.New("message nested too deep")
+)
+
+// member keeps the order of the keys of a JSON object
+type member struct {
+	key   string
+	value any
+}
+
+// jsonToMsgpack re-encodes one JSON value. Integers take the smallest msgpack int, floats
+// are sent as float32 when that loses nothing.
+func jsonToMsgpack(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := readJSON(dec, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after the message")
+	}
+
+	out := make([]byte, 0, len(data)/2)
+	return appendMsgpack(out, value)
+}
+
+func readJSON(dec *json.Decoder, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			var object []member
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readJSON(dec, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, member{key: key.(string), value: value})
+			}
+			_, err := dec.Token() // '}'
+			if object == nil {
+				object = []member{}
+			}
+			return object, err
+		case '[':
+			array := []any{}
+			for dec.More() {
+				value, err := readJSON(dec, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			_, err := dec.Token() // ']'
+			return array, err
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	default:
+		return t, nil
+	}
+}
+
+func appendMsgpack(b []byte, value any) ([]byte, error) {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case string:
+		return appendString(b, v), nil
+	case json.Number:
+		return appendNumber(b, v)
+	case []any:
+		b = appendHeader(b, len(v), 0x90, 16, 0xdc, 0xdd)
+		for _, item := range v {
+			if b, err = appendMsgpack(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case []member:
+		b = appendHeader(b, len(v), 0x80, 16, 0xde, 0xdf)
+		for _, m := range v {
+			b = appendString(b, m.key)
+			if b, err = appendMsgpack(b, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported value %T", value)
+}
+
+func appendNumber(b []byte, n json.Number) ([]byte, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return appendInt(b, i), nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+	}
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return nil, err
+	}
+	if f32 := float32(f); float64(f32) == f {
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(f32)), nil
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f)), nil
+}
+
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(b, byte(i))
+	case i >= -32 && i < 0:
+		return append(b, byte(0xe0|(i+32)))
+	case i >= 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i >= 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(int16(i)))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(int32(i)))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func appendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendHeader writes the length of an array or a map, fix is the format of the short form
+func appendHeader(b []byte, n int, fix byte, fixMax int, code16, code32 byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+	}
+}
+
+// msgpackToJSON decodes one msgpack value into JSON. Maps need string keys, bin becomes a
+// base64 string like []byte in encoding/json, ext types are refused.
+func msgpackToJSON(data []byte) ([]byte, error) {
+	r := &msgpackReader{data: data}
+	out := make([]byte, 0, len(data)*2)
+	out, err := r.appendJSON(out, 0)
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("%w: trailing data after the message", ErrInvalidMsgpack)
+	}
+	return out, nil
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidMsgpack)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) uint(n int) (uint64, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (r *msgpackReader) appendJSON(out []byte, depth int) ([]byte, error) {
+	if depth > maxDepth {
+		return nil, ErrTooDeep
+	}
+	head, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := head[0]
+
+	switch {
+	case c <= 0x7f:
+		return strconv.AppendInt(out, int64(c), 10), nil
+	case c >= 0xe0:
+		return strconv.AppendInt(out, int64(int8(c)), 10), nil
+	case c&0xf0 == 0x80:
+		return r.appendMap(out, int(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return r.appendArray(out, int(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return r.appendString(out, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return append(out, "null"...), nil
+	case 0xc2:
+		return append(out, "false"...), nil
+	case 0xc3:
+		return append(out, "true"...), nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, '"')
+		out = base64.StdEncoding.AppendEncode(out, b)
+		return append(out, '"'), nil
+	case 0xca:
+		u, err := r.uint(4)
+		if err != nil {
+			return nil, err
+		}
+		// formatted as the float64 it widens to, the shortest float32 form would be read
+		// back by JSON as another number
+		return appendFloat(out, float64(math.Float32frombits(uint32(u))))
+	case 0xcb:
+		u, err := r.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		return appendFloat(out, math.Float64frombits(u))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendUint(out, u, 10), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := r.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extend from the size of the value
+		shift := 64 - 8*size
+		return strconv.AppendInt(out, int64(u<<shift)>>shift, 10), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.appendString(out, int(n))
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.appendArray(out, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.appendMap(out, int(n), depth)
+	}
+	return nil, fmt.Errorf("%w: unsupported type 0x%02x", ErrInvalidMsgpack, c)
+}
+
+func (r *msgpackReader) appendString(out []byte, n int) ([]byte, error) {
+	b, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	s, err := json.Marshal(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return append(out, s...), nil
+}
+
+func (r *msgpackReader) appendArray(out []byte, n, depth int) ([]byte, error) {
+	var err error
+	out = append(out, '[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		if out, err = r.appendJSON(out, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return append(out, ']'), nil
+}
+
+func (r *msgpackReader) appendMap(out []byte, n, depth int) ([]byte, error) {
+	out = append(out, '{')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		key, err := r.next(1)
+		if err != nil {
+			return nil, err
+		}
+		var size uint64
+		switch c := key[0]; {
+		case c&0xe0 == 0xa0:
+			size = uint64(c & 0x1f)
+		case c >= 0xd9 && c <= 0xdb:
+			if size, err = r.uint(1 << (c - 0xd9)); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: map keys must be strings", ErrInvalidMsgpack)
+		}
+		if out, err = r.appendString(out, int(size)); err != nil {
+			return nil, err
+		}
+		out = append(out, ':')
+		if out, err = r.appendJSON(out, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return append(out, '}'), nil
+}
+
+func appendFloat(out []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("%w: %v has no JSON form", ErrInvalidMsgpack, f)
+	}
+	return strconv.AppendFloat(out, f, 'g', -1, 64), nil
+}
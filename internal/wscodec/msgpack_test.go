@@ -0,0 +1,322 @@
+package wscodec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func jsonString(n int) string {
+	return `"` + strings.Repeat("a", n) + `"`
+}
+
+func jsonArray(n int) string {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = "0"
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+func jsonObject(n int) string {
+	members := make([]string, n)
+	for i := range members {
+		members[i] = fmt.Sprintf(`"k%d":%d`, i, i)
+	}
+	return "{" + strings.Join(members, ",") + "}"
+}
+
+// nestedJSON returns n arrays, one inside the other
+func nestedJSON(n int) string {
+	return strings.Repeat("[", n) + strings.Repeat("]", n)
+}
+
+// nestedMsgpack returns n arrays, one inside the other
+func nestedMsgpack(n int) []byte {
+	return append(bytes.Repeat([]byte{0x91}, n-1), 0x90)
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // in when empty
+	}{
+		{name: "null", in: `null`},
+		{name: "true", in: `true`},
+		{name: "false", in: `false`},
+		{name: "zero", in: `0`},
+		{name: "negative zero", in: `-0`, want: `0`},
+		{name: "positive fixint max", in: `127`},
+		{name: "uint8 min", in: `128`},
+		{name: "uint8 max", in: `255`},
+		{name: "uint16 min", in: `256`},
+		{name: "uint16 max", in: `65535`},
+		{name: "uint32 min", in: `65536`},
+		{name: "uint32 max", in: `4294967295`},
+		{name: "uint64 min", in: `4294967296`},
+		{name: "int64 max", in: `9223372036854775807`},
+		{name: "uint64 max", in: `18446744073709551615`},
+		{name: "negative fixint min", in: `-32`},
+		{name: "int8 max", in: `-33`},
+		{name: "int8 min", in: `-128`},
+		{name: "int16 max", in: `-129`},
+		{name: "int16 min", in: `-32768`},
+		{name: "int32 max", in: `-32769`},
+		{name: "int32 min", in: `-2147483648`},
+		{name: "int64 max negative", in: `-2147483649`},
+		{name: "int64 min", in: `-9223372036854775808`},
+		{name: "float32", in: `1.5`},
+		{name: "float32 negative", in: `-0.25`},
+		{name: "float32 integral", in: `1.0`, want: `1`},
+		{name: "float32 exact", in: `0.100000001490116119384765625`, want: `0.10000000149011612`},
+		{name: "float32 max", in: `3.4028234663852886e+38`},
+		{name: "float64", in: `0.1`},
+		{name: "float64 beyond float32", in: `1e+300`},
+		{name: "float64 smallest", in: `5e-324`},
+		{name: "coordinate", in: `43.238949`},
+		{name: "empty string", in: `""`},
+		{name: "escaped string", in: `"a\"b\\c\n\u0001"`},
+		{name: "unicode string", in: `"Алматы 🚕"`},
+		{name: "fixstr max", in: jsonString(31)},
+		{name: "str8 min", in: jsonString(32)},
+		{name: "str8 max", in: jsonString(255)},
+		{name: "str16 min", in: jsonString(256)},
+		{name: "str16 max", in: jsonString(65535)},
+		{name: "str32 min", in: jsonString(65536)},
+		{name: "empty array", in: `[]`},
+		{name: "fixarray max", in: jsonArray(15)},
+		{name: "array16 min", in: jsonArray(16)},
+		{name: "array16 max", in: jsonArray(65535)},
+		{name: "array32 min", in: jsonArray(65536)},
+		{name: "empty object", in: `{}`},
+		{name: "fixmap max", in: jsonObject(15)},
+		{name: "map16 min", in: jsonObject(16)},
+		{name: "map16 max", in: jsonObject(65535)},
+		{name: "map32 min", in: jsonObject(65536)},
+		{name: "key order kept", in: `{"b":1,"a":2,"c":3}`},
+		{name: "message", in: `{"type":"ride_status_update","data":{"ride_id":"r1","status":"EN_ROUTE","driver_location":{"lat":43.238949,"lng":76.889709},"eta":[3,null,true]}}`},
+		{name: "whitespace", in: "{ \"a\" : [ 1 , 2 ] }", want: `{"a":[1,2]}`},
+		{name: "nesting limit", in: nestedJSON(maxDepth + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want == "" {
+				want = tt.in
+			}
+			packed, err := jsonToMsgpack([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("jsonToMsgpack: %v", err)
+			}
+			got, err := msgpackToJSON(packed)
+			if err != nil {
+				t.Fatalf("msgpackToJSON: %v", err)
+			}
+			if string(got) != want {
+				t.Errorf("round trip = %.200s, want %.200s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		prefix []byte // first bytes of the encoding
+	}{
+		{name: "positive fixint max", in: `127`, prefix: []byte{0x7f}},
+		{name: "uint8 min", in: `128`, prefix: []byte{0xcc, 0x80}},
+		{name: "uint8 max", in: `255`, prefix: []byte{0xcc, 0xff}},
+		{name: "uint16 min", in: `256`, prefix: []byte{0xcd, 0x01, 0x00}},
+		{name: "uint32 min", in: `65536`, prefix: []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{name: "uint64 min", in: `4294967296`, prefix: []byte{0xcf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{name: "uint64 max", in: `18446744073709551615`, prefix: []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "negative fixint", in: `-1`, prefix: []byte{0xff}},
+		{name: "negative fixint min", in: `-32`, prefix: []byte{0xe0}},
+		{name: "int8 max", in: `-33`, prefix: []byte{0xd0, 0xdf}},
+		{name: "int8 min", in: `-128`, prefix: []byte{0xd0, 0x80}},
+		{name: "int16 max", in: `-129`, prefix: []byte{0xd1, 0xff, 0x7f}},
+		{name: "int32 max", in: `-32769`, prefix: []byte{0xd2, 0xff, 0xff, 0x7f, 0xff}},
+		{name: "int64 max", in: `-2147483649`, prefix: []byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}},
+		{name: "float32", in: `1.5`, prefix: []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}},
+		{name: "float64", in: `0.1`, prefix: []byte{0xcb, 0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{name: "nil", in: `null`, prefix: []byte{0xc0}},
+		{name: "false", in: `false`, prefix: []byte{0xc2}},
+		{name: "true", in: `true`, prefix: []byte{0xc3}},
+		{name: "fixstr max", in: jsonString(31), prefix: []byte{0xbf, 'a'}},
+		{name: "str8 min", in: jsonString(32), prefix: []byte{0xd9, 32, 'a'}},
+		{name: "str8 max", in: jsonString(255), prefix: []byte{0xd9, 0xff, 'a'}},
+		{name: "str16 min", in: jsonString(256), prefix: []byte{0xda, 0x01, 0x00, 'a'}},
+		{name: "str16 max", in: jsonString(65535), prefix: []byte{0xda, 0xff, 0xff, 'a'}},
+		{name: "str32 min", in: jsonString(65536), prefix: []byte{0xdb, 0x00, 0x01, 0x00, 0x00, 'a'}},
+		{name: "fixarray max", in: jsonArray(15), prefix: []byte{0x9f, 0x00}},
+		{name: "array16 min", in: jsonArray(16), prefix: []byte{0xdc, 0x00, 0x10, 0x00}},
+		{name: "array16 max", in: jsonArray(65535), prefix: []byte{0xdc, 0xff, 0xff, 0x00}},
+		{name: "array32 min", in: jsonArray(65536), prefix: []byte{0xdd, 0x00, 0x01, 0x00, 0x00, 0x00}},
+		{name: "fixmap max", in: jsonObject(15), prefix: []byte{0x8f, 0xa2, 'k', '0'}},
+		{name: "map16 min", in: jsonObject(16), prefix: []byte{0xde, 0x00, 0x10, 0xa2, 'k', '0'}},
+		{name: "map16 max", in: jsonObject(65535), prefix: []byte{0xde, 0xff, 0xff, 0xa2, 'k', '0'}},
+		{name: "map32 min", in: jsonObject(65536), prefix: []byte{0xdf, 0x00, 0x01, 0x00, 0x00, 0xa2, 'k', '0'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jsonToMsgpack([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("jsonToMsgpack: %v", err)
+			}
+			if !bytes.HasPrefix(got, tt.prefix) {
+				t.Errorf("encoding starts with % x, want % x", got[:min(len(got), len(tt.prefix))], tt.prefix)
+			}
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr error // any error when nil
+	}{
+		{name: "empty", in: ``},
+		{name: "invalid", in: `{"a":}`},
+		{name: "unterminated", in: `[1,2`},
+		{name: "trailing data", in: `1 2`},
+		{name: "out of range", in: `1e400`},
+		{name: "too deep", in: nestedJSON(maxDepth + 2), wantErr: ErrTooDeep},
+		{name: "too deep in object", in: `{"a":` + nestedJSON(maxDepth+1) + `}`, wantErr: ErrTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsonToMsgpack([]byte(tt.in))
+			if err == nil {
+				t.Fatal("jsonToMsgpack: no error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("jsonToMsgpack: %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []byte
+		want    string
+		wantErr error
+	}{
+		{name: "uint16", in: []byte{0xcd, 0x01, 0x00}, want: `256`},
+		{name: "int8", in: []byte{0xd0, 0x80}, want: `-128`},
+		{name: "int16", in: []byte{0xd1, 0x80, 0x00}, want: `-32768`},
+		{name: "int32", in: []byte{0xd2, 0x80, 0x00, 0x00, 0x00}, want: `-2147483648`},
+		{name: "int64", in: []byte{0xd3, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, want: `-9223372036854775808`},
+		{name: "positive int8", in: []byte{0xd0, 0x05}, want: `5`},
+		{name: "float32", in: []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, want: `1.5`},
+		{name: "float32 widened", in: []byte{0xca, 0x3d, 0xcc, 0xcc, 0xcd}, want: `0.10000000149011612`},
+		{name: "str8 short", in: []byte{0xd9, 0x01, 'a'}, want: `"a"`},
+		{name: "array16 short", in: []byte{0xdc, 0x00, 0x01, 0x01}, want: `[1]`},
+		{name: "map16 short", in: []byte{0xde, 0x00, 0x01, 0xa1, 'a', 0x01}, want: `{"a":1}`},
+		{name: "str8 key", in: []byte{0x81, 0xd9, 0x01, 'a', 0xc0}, want: `{"a":null}`},
+		{name: "bin8", in: []byte{0xc4, 0x02, 0x01, 0x02}, want: `"AQI="`},
+		{name: "bin16", in: []byte{0xc5, 0x00, 0x01, 0xff}, want: `"/w=="`},
+		{name: "string escaped", in: []byte{0xa2, '"', '\n'}, want: `"\"\n"`},
+		{name: "nesting limit", in: nestedMsgpack(maxDepth + 1), want: nestedJSON(maxDepth + 1)},
+		{name: "empty", in: []byte{}, wantErr: ErrInvalidMsgpack},
+		{name: "trailing data", in: []byte{0x01, 0x02}, wantErr: ErrInvalidMsgpack},
+		{name: "unused type", in: []byte{0xc1}, wantErr: ErrInvalidMsgpack},
+		{name: "ext", in: []byte{0xd4, 0x01, 0x00}, wantErr: ErrInvalidMsgpack},
+		{name: "int key", in: []byte{0x81, 0x01, 0x01}, wantErr: ErrInvalidMsgpack},
+		{name: "float32 NaN", in: []byte{0xca, 0x7f, 0xc0, 0x00, 0x00}, wantErr: ErrInvalidMsgpack},
+		{name: "float64 infinity", in: []byte{0xcb, 0x7f, 0xf0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, wantErr: ErrInvalidMsgpack},
+		{name: "str32 length past the data", in: []byte{0xdb, 0xff, 0xff, 0xff, 0xff, 'a'}, wantErr: ErrInvalidMsgpack},
+		{name: "array32 length past the data", in: []byte{0xdd, 0xff, 0xff, 0xff, 0xff, 0x01}, wantErr: ErrInvalidMsgpack},
+		{name: "map32 length past the data", in: []byte{0xdf, 0xff, 0xff, 0xff, 0xff}, wantErr: ErrInvalidMsgpack},
+		{name: "too deep", in: nestedMsgpack(maxDepth + 2), wantErr: ErrTooDeep},
+		{name: "too deep in map", in: append([]byte{0x81, 0xa1, 'a'}, nestedMsgpack(maxDepth+1)...), wantErr: ErrTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := msgpackToJSON(tt.in)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("msgpackToJSON: %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("msgpackToJSON: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("msgpackToJSON = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecodeTruncated cuts valid messages at every byte, msgpack values are prefix free so
+// every cut must be refused
+func TestDecodeTruncated(t *testing.T) {
+	messages := []string{
+		`128`,
+		`65536`,
+		`4294967296`,
+		`-129`,
+		`-2147483649`,
+		`1.5`,
+		`0.1`,
+		jsonString(5),
+		jsonString(32),
+		jsonString(256),
+		jsonArray(3),
+		jsonArray(16),
+		jsonObject(2),
+		jsonObject(16),
+		`{"type":"auth","data":{"token":"Bearer x","nested":[1,[2,[3]]]}}`,
+	}
+
+	for _, message := range messages {
+		packed, err := jsonToMsgpack([]byte(message))
+		if err != nil {
+			t.Fatalf("jsonToMsgpack(%.40s): %v", message, err)
+		}
+		for n := 0; n < len(packed); n++ {
+			if _, err := msgpackToJSON(packed[:n]); !errors.Is(err, ErrInvalidMsgpack) {
+				t.Errorf("msgpackToJSON(%.40s cut at %d of %d): %v, want %v", message, n, len(packed), err, ErrInvalidMsgpack)
+			}
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	type message struct {
+		Type string  `json:"type"`
+		Lat  float64 `json:"lat"`
+		Seq  int     `json:"seq"`
+	}
+	in := message{Type: "location_update", Lat: 43.238949, Seq: 42}
+
+	for _, subprotocol := range []string{JSONv1, MsgpackV1, ""} {
+		t.Run(subprotocol, func(t *testing.T) {
+			codec := ForSubprotocol(subprotocol)
+			data, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var out message
+			if err := codec.Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if out != in {
+				t.Errorf("Unmarshal = %+v, want %+v", out, in)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+ALTER TABLE rides
+  DROP COLUMN IF EXISTS zone_ids,
+  DROP COLUMN IF EXISTS zone_surcharge;
+
+DROP TABLE IF EXISTS zones;
+
+DROP TYPE IF EXISTS zone_type;
@@ -0,0 +1,25 @@
+-- Zone type enumeration
+CREATE TYPE zone_type AS ENUM (
+  'SERVICE_AREA', -- Area where rides can be requested
+  'AIRPORT', -- Airport pickup/drop-off area with a fee
+  'RESTRICTED', -- Area where pickups and drop-offs are not allowed
+  'SURCHARGE' -- Area with an extra fee (city center, events, etc.)
+);
+
+CREATE TABLE IF NOT EXISTS zones (
+  zone_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  name TEXT NOT NULL,
+  zone_type zone_type NOT NULL,
+  geom GEOMETRY (MULTIPOLYGON, 4326) NOT NULL,
+  surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (surcharge >= 0),
+  is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE INDEX IF NOT EXISTS zones_geom_idx ON zones USING GIST (geom);
+
+-- Zones the ride pickup and destination fell into, kept for analytics
+ALTER TABLE rides
+  ADD COLUMN IF NOT EXISTS zone_ids UUID[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS zone_surcharge DECIMAL(10, 2) NOT NULL DEFAULT 0;
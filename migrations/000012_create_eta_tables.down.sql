@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS eta_predictions;
+
+DROP TYPE IF EXISTS eta_kind;
+
+DROP TABLE IF EXISTS speed_profiles;
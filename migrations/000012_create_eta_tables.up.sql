@@ -0,0 +1,31 @@
+-- Average driver speed per grid cell and hour of week (0 = Monday 00:00 UTC),
+-- rebuilt periodically from location_history
+CREATE TABLE IF NOT EXISTS speed_profiles (
+  cell_lat INTEGER NOT NULL,
+  cell_lng INTEGER NOT NULL,
+  hour_of_week SMALLINT NOT NULL CHECK (hour_of_week BETWEEN 0 AND 167),
+  avg_speed_kmh DECIMAL(5, 2) NOT NULL CHECK (avg_speed_kmh > 0),
+  samples INTEGER NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  PRIMARY KEY (cell_lat, cell_lng, hour_of_week)
+);
+
+-- ETA kind enumeration
+CREATE TYPE eta_kind AS ENUM (
+  'PICKUP', -- Driver arrival at the pickup point
+  'DROPOFF' -- Arrival at the destination
+);
+
+-- First ETA shown to the passenger for each ride leg, compared against
+-- rides.arrived_at / rides.completed_at for accuracy reporting
+CREATE TABLE IF NOT EXISTS eta_predictions (
+  prediction_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  ride_id UUID NOT NULL REFERENCES rides (ride_id) ON DELETE CASCADE,
+  kind eta_kind NOT NULL,
+  predicted_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  predicted_arrival TIMESTAMPTZ NOT NULL,
+  source TEXT NOT NULL, -- 'profile' or the routing source
+  UNIQUE (ride_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS eta_predictions_predicted_at_idx ON eta_predictions (predicted_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ride_offers;
+
+DROP TYPE IF EXISTS offer_status;
@@ -0,0 +1,24 @@
+-- Ride offer status enumeration
+CREATE TYPE offer_status AS ENUM (
+  'SENT', -- Waiting for the driver
+  'ACCEPTED', -- Driver accepted, ride matched
+  'DECLINED', -- Driver declined
+  'EXPIRED', -- No answer before expires_at
+  'WITHDRAWN' -- Another driver accepted first
+);
+
+CREATE TABLE IF NOT EXISTS ride_offers (
+  offer_id TEXT PRIMARY KEY,
+  ride_id UUID NOT NULL REFERENCES rides (ride_id) ON DELETE CASCADE,
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id),
+  status offer_status NOT NULL DEFAULT 'SENT',
+  strategy TEXT NOT NULL,
+  decline_reason TEXT,
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  expires_at TIMESTAMPTZ NOT NULL,
+  responded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS ride_offers_driver_sent_idx ON ride_offers (driver_id, sent_at DESC);
+
+CREATE INDEX IF NOT EXISTS ride_offers_ride_idx ON ride_offers (ride_id);
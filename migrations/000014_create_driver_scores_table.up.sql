@@ -0,0 +1,13 @@
+-- Driver quality components over a rolling window, refreshed periodically.
+-- The weighted score is computed on read with the weights of the driver's vehicle type.
+CREATE TABLE IF NOT EXISTS driver_scores (
+  driver_id UUID PRIMARY KEY REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  acceptance_rate DECIMAL(5, 4) NOT NULL CHECK (acceptance_rate BETWEEN 0 AND 1),
+  cancellation_rate DECIMAL(5, 4) NOT NULL CHECK (cancellation_rate BETWEEN 0 AND 1),
+  completion_rate DECIMAL(5, 4) NOT NULL CHECK (completion_rate BETWEEN 0 AND 1),
+  rating DECIMAL(3, 2) NOT NULL,
+  offers_count INTEGER NOT NULL DEFAULT 0, -- answered offers in the window
+  rides_count INTEGER NOT NULL DEFAULT 0, -- completed + cancelled rides in the window
+  window_days INTEGER NOT NULL,
+  computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+);
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS ride_tracks;
+
+ALTER TABLE location_history RENAME TO location_history_partitioned;
+
+CREATE TABLE location_history (
+  location_history_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  coord_id UUID REFERENCES coordinates (coord_id),
+  driver_id UUID REFERENCES drivers (driver_id),
+  latitude DECIMAL(10, 8) NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+  longitude DECIMAL(13, 8) NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+  accuracy_meters DECIMAL(6, 2),
+  speed_kmh DECIMAL(5, 2),
+  heading_degrees DECIMAL(5, 2) CHECK (heading_degrees BETWEEN 0 AND 360),
+  recorded_at timestamptz NOT NULL DEFAULT NOW (),
+  ride_id UUID REFERENCES rides (ride_id)
+);
+
+INSERT INTO location_history
+SELECT location_history_id, coord_id, driver_id, latitude, longitude, accuracy_meters, speed_kmh, heading_degrees, recorded_at, ride_id
+FROM location_history_partitioned;
+
+DROP TABLE location_history_partitioned CASCADE;
@@ -0,0 +1,40 @@
+-- location_history partitioned by day, partitions are created and dropped by driver-location-service
+ALTER TABLE location_history RENAME TO location_history_old;
+
+CREATE TABLE location_history (
+  location_history_id UUID NOT NULL DEFAULT uuid_generate_v4 (),
+  coord_id UUID REFERENCES coordinates (coord_id),
+  driver_id UUID REFERENCES drivers (driver_id),
+  latitude DECIMAL(10, 8) NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+  longitude DECIMAL(13, 8) NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+  accuracy_meters DECIMAL(6, 2),
+  speed_kmh DECIMAL(5, 2),
+  heading_degrees DECIMAL(5, 2) CHECK (heading_degrees BETWEEN 0 AND 360),
+  recorded_at timestamptz NOT NULL DEFAULT NOW (),
+  ride_id UUID REFERENCES rides (ride_id),
+  PRIMARY KEY (location_history_id, recorded_at)
+) PARTITION BY RANGE (recorded_at);
+
+-- rows outside the managed partitions, old data lands here and is removed by retention
+CREATE TABLE location_history_default PARTITION OF location_history DEFAULT;
+
+INSERT INTO location_history
+SELECT location_history_id, coord_id, driver_id, latitude, longitude, accuracy_meters, speed_kmh, heading_degrees, recorded_at, ride_id
+FROM location_history_old;
+
+DROP TABLE location_history_old;
+
+CREATE INDEX idx_location_history_ride ON location_history (ride_id, recorded_at);
+CREATE INDEX idx_location_history_driver ON location_history (driver_id, recorded_at);
+
+-- downsampled path of a completed ride, kept after the raw points are dropped
+CREATE TABLE IF NOT EXISTS ride_tracks (
+  ride_id UUID PRIMARY KEY REFERENCES rides (ride_id) ON DELETE CASCADE,
+  polyline TEXT NOT NULL,
+  points_count INTEGER NOT NULL,
+  raw_points_count INTEGER NOT NULL,
+  distance_km DECIMAL(10, 3) NOT NULL,
+  started_at timestamptz,
+  ended_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT NOW ()
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_driver_sessions_open;
+DROP TABLE IF EXISTS driver_earnings;
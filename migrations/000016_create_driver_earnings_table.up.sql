@@ -0,0 +1,19 @@
+-- One row per completed ride, booked against the session the driver was in.
+-- net = gross_fare - commission + tip, driver_sessions keeps the running totals.
+CREATE TABLE IF NOT EXISTS driver_earnings (
+  earning_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  ride_id UUID NOT NULL UNIQUE REFERENCES rides (ride_id) ON DELETE CASCADE,
+  driver_session_id UUID REFERENCES driver_sessions (driver_session_id) ON DELETE SET NULL,
+  gross_fare DECIMAL(10, 2) NOT NULL CHECK (gross_fare >= 0),
+  commission_rate DECIMAL(5, 4) NOT NULL CHECK (commission_rate BETWEEN 0 AND 1),
+  commission DECIMAL(10, 2) NOT NULL CHECK (commission >= 0),
+  tip DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (tip >= 0),
+  net DECIMAL(10, 2) NOT NULL,
+  earned_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  tipped_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_driver ON driver_earnings (driver_id, earned_at);
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_session ON driver_earnings (driver_session_id);
+CREATE INDEX IF NOT EXISTS idx_driver_sessions_open ON driver_sessions (driver_id) WHERE ended_at IS NULL;
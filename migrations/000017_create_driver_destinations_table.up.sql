@@ -0,0 +1,15 @@
+-- Destination mode: a driver heading to a target only gets rides that bring them closer.
+-- Every activation is a row, the rows of the day count against the daily limit.
+CREATE TABLE IF NOT EXISTS driver_destinations (
+  destination_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  latitude DECIMAL(10, 8) NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+  longitude DECIMAL(11, 8) NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+  address TEXT,
+  is_active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  deactivated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_destinations_active ON driver_destinations (driver_id) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_driver_destinations_driver ON driver_destinations (driver_id, created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS driver_breaks;
+
+ALTER TABLE drivers
+  DROP COLUMN IF EXISTS jurisdiction;
@@ -0,0 +1,17 @@
+-- Jurisdiction decides which driving time limits apply to the driver
+ALTER TABLE drivers
+  ADD COLUMN IF NOT EXISTS jurisdiction TEXT NOT NULL DEFAULT 'DEFAULT';
+
+-- Forced breaks, the driver cannot go online before ends_at
+CREATE TABLE IF NOT EXISTS driver_breaks (
+  break_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,
+  online_hours DECIMAL(6, 2) NOT NULL DEFAULT 0,
+  driving_hours DECIMAL(6, 2) NOT NULL DEFAULT 0,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  ends_at TIMESTAMPTZ NOT NULL,
+  CHECK (ends_at > started_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_breaks_driver ON driver_breaks (driver_id, ends_at);
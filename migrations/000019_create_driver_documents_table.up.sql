@@ -0,0 +1,20 @@
+-- Documents a driver uploads for verification, the file itself is in the file store under file_key.
+-- A driver can go online only with an approved, unexpired document of every required type.
+CREATE TABLE IF NOT EXISTS driver_documents (
+  document_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  document_type TEXT NOT NULL CHECK (document_type IN ('LICENSE', 'INSURANCE', 'VEHICLE_REGISTRATION')),
+  status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+  file_key TEXT NOT NULL,
+  file_name TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+  expires_at DATE NOT NULL,
+  uploaded_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  reviewed_at TIMESTAMPTZ,
+  reviewed_by UUID REFERENCES users (user_id),
+  review_note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_documents_driver ON driver_documents (driver_id, document_type, uploaded_at);
+CREATE INDEX IF NOT EXISTS idx_driver_documents_pending ON driver_documents (uploaded_at) WHERE status = 'PENDING';
@@ -0,0 +1,4 @@
+ALTER TABLE driver_sessions
+  DROP COLUMN IF EXISTS vehicle_id;
+
+DROP TABLE IF EXISTS vehicles;
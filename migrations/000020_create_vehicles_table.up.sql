@@ -0,0 +1,51 @@
+-- Vehicles of a driver, the session records the one the driver went online with.
+-- opt_in_types are other ride types the vehicle takes requests for, e.g. a PREMIUM car
+-- taking ECONOMY requests, within what the eligibility rules allow.
+CREATE TABLE IF NOT EXISTS vehicles (
+  vehicle_id UUID PRIMARY KEY DEFAULT uuid_generate_v4 (),
+  driver_id UUID NOT NULL REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  vehicle_type vehicle_type NOT NULL,
+  make TEXT NOT NULL,
+  model TEXT NOT NULL,
+  year INTEGER CHECK (year BETWEEN 1950 AND 2100),
+  color TEXT NOT NULL DEFAULT '',
+  plate TEXT NOT NULL,
+  seats INTEGER NOT NULL DEFAULT 4 CHECK (seats BETWEEN 1 AND 20),
+  capabilities TEXT[] NOT NULL DEFAULT '{}',
+  opt_in_types vehicle_type[] NOT NULL DEFAULT '{}',
+  is_active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vehicles_driver ON vehicles (driver_id) WHERE is_active;
+
+ALTER TABLE driver_sessions
+  ADD COLUMN IF NOT EXISTS vehicle_id UUID REFERENCES vehicles (vehicle_id);
+
+-- Every driver keeps the vehicle registered with the profile. auth-service stores make,
+-- model, color, plate and year, the vehicle_ prefixed keys come from older seed data.
+-- Drivers without a plate keep the profile vehicle only, a plate already taken by an
+-- earlier driver stays with that driver.
+INSERT INTO vehicles (driver_id, vehicle_type, make, model, year, color, plate)
+SELECT DISTINCT ON (upper(p.plate)) p.driver_id, p.vehicle_type, p.make, p.model, p.year, p.color, p.plate
+FROM (
+  SELECT d.driver_id, d.vehicle_type, d.created_at,
+    COALESCE(d.vehicle_attrs ->> 'make', d.vehicle_attrs ->> 'vehicle_make', '') AS make,
+    COALESCE(d.vehicle_attrs ->> 'model', d.vehicle_attrs ->> 'vehicle_model', '') AS model,
+    -- years the column check refuses are left out instead of failing the migration
+    CASE WHEN y.raw ~ '^[0-9]{4}$' THEN
+      CASE WHEN y.raw::int BETWEEN 1950 AND 2100 THEN y.raw::int END
+    END AS year,
+    COALESCE(d.vehicle_attrs ->> 'color', d.vehicle_attrs ->> 'vehicle_color', '') AS color,
+    btrim(COALESCE(NULLIF(d.vehicle_attrs ->> 'plate', ''), d.vehicle_attrs ->> 'vehicle_plate', '')) AS plate
+  FROM drivers d
+  CROSS JOIN LATERAL (SELECT COALESCE(d.vehicle_attrs ->> 'year', d.vehicle_attrs ->> 'vehicle_year') AS raw) y
+  WHERE NOT EXISTS (SELECT 1 FROM vehicles v WHERE v.driver_id = d.driver_id)
+) p
+WHERE p.plate <> ''
+  AND NOT EXISTS (SELECT 1 FROM vehicles v WHERE v.is_active AND upper(v.plate) = upper(p.plate))
+ORDER BY upper(p.plate), p.created_at;
+
+-- built after the backfill, plates are unique among active vehicles from here on
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vehicles_plate ON vehicles (upper(plate)) WHERE is_active;
@@ -0,0 +1,6 @@
+ALTER TABLE ride_offers
+  DROP COLUMN IF EXISTS instance_id;
+
+DROP TABLE IF EXISTS driver_connections;
+
+DROP TABLE IF EXISTS ws_instances;
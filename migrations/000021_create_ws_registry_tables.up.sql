@@ -0,0 +1,23 @@
+-- Replicas holding WebSocket connections, a replica whose heartbeat is older than
+-- CLUSTER_TTL_SEC is considered gone and its rows are removed by the others.
+CREATE TABLE IF NOT EXISTS ws_instances (
+  instance_id TEXT PRIMARY KEY,
+  service TEXT NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  heartbeat_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+);
+
+-- Replica holding the WebSocket session of a driver. connected is false while the
+-- session waits to be resumed, messages are still routed there and buffered.
+CREATE TABLE IF NOT EXISTS driver_connections (
+  driver_id UUID PRIMARY KEY REFERENCES drivers (driver_id) ON DELETE CASCADE,
+  instance_id TEXT NOT NULL REFERENCES ws_instances (instance_id) ON DELETE CASCADE,
+  connected BOOLEAN NOT NULL DEFAULT TRUE,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_connections_instance ON driver_connections (instance_id);
+
+-- Replica waiting for the answer to the offer
+ALTER TABLE ride_offers
+  ADD COLUMN IF NOT EXISTS instance_id TEXT;
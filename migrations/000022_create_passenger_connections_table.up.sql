@@ -0,0 +1,10 @@
+-- Replicas of ride-service holding the WebSocket of a passenger, rows go with the
+-- replica in ws_instances when its heartbeat stops.
+CREATE TABLE IF NOT EXISTS passenger_connections (
+  passenger_id UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  instance_id TEXT NOT NULL REFERENCES ws_instances (instance_id) ON DELETE CASCADE,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  PRIMARY KEY (passenger_id, instance_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_passenger_connections_instance ON passenger_connections (instance_id);
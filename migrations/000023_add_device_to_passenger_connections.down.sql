@@ -0,0 +1,13 @@
+DELETE FROM passenger_connections a
+USING passenger_connections b
+WHERE a.passenger_id = b.passenger_id
+  AND a.instance_id = b.instance_id
+  AND a.device_id > b.device_id;
+
+ALTER TABLE passenger_connections
+  DROP CONSTRAINT IF EXISTS passenger_connections_pkey,
+  ADD PRIMARY KEY (passenger_id, instance_id);
+
+ALTER TABLE passenger_connections
+  DROP COLUMN IF EXISTS connected_at,
+  DROP COLUMN IF EXISTS device_id;
@@ -0,0 +1,8 @@
+-- A passenger can be connected from several devices, each one is a session of its own
+ALTER TABLE passenger_connections
+  ADD COLUMN IF NOT EXISTS device_id TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS connected_at TIMESTAMPTZ NOT NULL DEFAULT NOW ();
+
+ALTER TABLE passenger_connections
+  DROP CONSTRAINT IF EXISTS passenger_connections_pkey,
+  ADD PRIMARY KEY (passenger_id, instance_id, device_id);
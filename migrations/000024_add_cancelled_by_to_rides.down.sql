@@ -0,0 +1,2 @@
+ALTER TABLE rides
+  DROP COLUMN IF EXISTS cancelled_by;
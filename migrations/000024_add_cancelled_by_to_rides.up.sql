@@ -0,0 +1,4 @@
+-- Who cancelled the ride, only DRIVER cancellations count against the driver's score.
+-- Rides cancelled before this column existed stay unattributed.
+ALTER TABLE rides
+  ADD COLUMN IF NOT EXISTS cancelled_by TEXT CHECK (cancelled_by IN ('PASSENGER', 'DRIVER', 'SYSTEM'));
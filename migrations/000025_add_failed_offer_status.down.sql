@@ -0,0 +1,2 @@
+-- Postgres cannot drop an enum value, failed offers are kept as expired
+UPDATE ride_offers SET status = 'EXPIRED' WHERE status = 'FAILED';
@@ -0,0 +1,2 @@
+-- Offers persisted but never delivered to the driver
+ALTER TYPE offer_status ADD VALUE IF NOT EXISTS 'FAILED';
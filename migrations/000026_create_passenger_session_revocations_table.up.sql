@@ -0,0 +1,11 @@
+-- Devices a passenger revoked, their WebSocket auth is refused until expires_at even with
+-- a token that is still valid.
+CREATE TABLE IF NOT EXISTS passenger_session_revocations (
+  passenger_id UUID NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  device_id TEXT NOT NULL,
+  revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW (),
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (passenger_id, device_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_passenger_session_revocations_expires ON passenger_session_revocations (expires_at);